@@ -0,0 +1,13 @@
+#!/bin/sh
+# Fails when the CRDs in yaml/crds are not what controller-gen generates from
+# the types in pkg/apis, the arguments are the options of the crd generator.
+set -e
+
+generated=$(mktemp -d)
+trap 'rm -rf "$generated"' EXIT
+
+controller-gen "$@" paths=./pkg/apis/... output:crd:dir="$generated"
+if ! diff -ru yaml/crds "$generated"; then
+  echo "yaml/crds is out of date, run make gen-crd" >&2
+  exit 1
+fi
@@ -2,60 +2,150 @@ package main
 
 import (
 	"flag"
+	"net/http"
 	"os"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/golang/glog"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/accessrequest"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/awsclient"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
 	clientset "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/controller"
-
-	"time"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/credstore"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/events"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/health"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/iamauth"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/inventory"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/kms"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/master"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/network"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/quota"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/signals"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/snapshot"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/trace"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/vault"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/webhook"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/worker"
 	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	rds2 "github.com/aws/aws-sdk-go/service/rds"
-	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
 )
 
 var kubeconfig string
-var region string
-var subnetGroup string
-var sgIDs []*string
+var configFile string
 var nsSuffix string
+var skipCRDCheck bool
+var webhookAddr string
+var webhookCertFile string
+var webhookKeyFile string
+var migrateStorageVersion bool
+var sizeCatalogueNamespace string
+var sizeCatalogueName string
+var rdsEventsQueueURL string
+var webIdentityTokenFile string
+var webIdentityRoleARN string
+var assumeRoleARN string
+var assumeRoleExternalID string
+var assumeRoleSessionTags string
+var metricsAddr string
+var healthAddr string
+var leaderElect bool
+var leaderElectNamespace string
+var leaderElectName string
+var leaderElectLeaseDuration time.Duration
+var leaderElectRenewDeadline time.Duration
+var leaderElectRetryPeriod time.Duration
+var shutdownTimeout time.Duration
+var logLevel string
+var otlpEndpoint string
+var otlpServiceName string
+var traceExportInterval time.Duration
+var rdsQPS float64
+var rdsBurst int
+var inventoryInterval time.Duration
+var inventoryQPS float64
+var inventoryNamespace string
+var inventoryName string
 
 func main() {
-
-	if subnetGroup == "" {
-		glog.Fatalf("please provide a subnet group")
+	level, err := log.ParseLevel(logLevel)
+	if err != nil {
+		glog.Fatalf("invalid --log-level: %s", err.Error())
 	}
+	logger := log.New(os.Stderr, level)
+	log.SetDefault(logger)
 
-	if len(sgIDs) == 0 {
-		glog.Fatalf("please provide a comma separated list of security group ids with at least one id.")
+	cfgStore, err := config.NewStore(configFile)
+	if err != nil {
+		glog.Fatalf("invalid operator config: %s", err.Error())
+	}
+	cfg := cfgStore.Get()
+	if nsSuffix == "" {
+		nsSuffix = cfg.Worker.NamespaceSuffix
 	}
+	// the app credentials share the master password
+	p := cfg.Passwords.For(database.GetUserNameForType(database.CredTypeAdmin))
+	glog.Infof("passwords have %d characters out of %d, %.0f bits of entropy", p.Length, len(p.Charset()), p.Entropy())
 
 	// set up signals so we handle the first shutdown signal gracefully
 	stopCh := signals.SetupSignalHandler()
 
-	var config *rest.Config
-	var err error
+	// spans of reconciles are only kept with a collector to export them to
+	if otlpEndpoint != "" {
+		tracer := trace.NewTracer(trace.NewOTLPExporter(otlpEndpoint, otlpServiceName))
+		trace.SetDefault(tracer)
+		go tracer.Run(traceExportInterval, stopCh)
+		defer func() {
+			if err := tracer.Flush(); err != nil {
+				logger.Error("unable to export spans", "component", "trace", "err", err)
+			}
+		}()
+	}
+
+	// the operator is ready once aws works and its caches are synced
+	checks := health.New()
+	awsVerified := health.NewFlag("aws permissions not verified yet")
+	cachesSynced := health.NewFlag("caches not synced yet")
+	checks.AddReadyCheck("aws", awsVerified.Check)
+	checks.AddReadyCheck("caches", cachesSynced.Check)
+	if healthAddr != "" {
+		go func() {
+			glog.Fatal(http.ListenAndServe(healthAddr, checks.Handler()))
+		}()
+	}
+
+	var restConfig *rest.Config
 
 	// if flag has not been passed and env not set, presume running in cluster
 	if kubeconfig != "" {
 		glog.Infof("using kubeconfig %v", kubeconfig)
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+		restConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
 	} else {
 		glog.Infof("running inside cluster")
-		config, err = rest.InClusterConfig()
+		restConfig, err = rest.InClusterConfig()
 	}
 
 	if nil != err {
@@ -63,73 +153,326 @@ func main() {
 		return
 	}
 
-	k8sClient, err := kubernetes.NewForConfig(config)
+	k8sClient, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		glog.Fatalf("error building k8s clientset: %s", err.Error())
 	}
 
-	crdClient, err := clientset.NewForConfig(config)
+	if !skipCRDCheck {
+		checker := k8s.NewCRDChecker(k8s.NewCRDFetcher(k8sClient.Discovery().RESTClient()))
+		if err := checker.Check(k8s.PostgresDBCRD()); err != nil {
+			glog.Fatalf("crd check failed, apply yaml/crds and yaml/crd-conversion.yaml or pass --skip-crd-check: %s", err.Error())
+		}
+		for _, crd := range []*k8s.ExpectedCRD{k8s.PostgresDBClassCRD(), k8s.PostgresDBQuotaCRD(), k8s.PostgresDBSnapshotCRD(), k8s.PostgresDBAccessRequestCRD()} {
+			if err := checker.Check(crd); err != nil {
+				glog.Fatalf("crd check failed, apply yaml/crds or pass --skip-crd-check: %s", err.Error())
+			}
+		}
+	}
+
+	crdClient, err := clientset.NewForConfig(restConfig)
 	if err != nil {
 		glog.Fatalf("error building CRD clientset: %s", err.Error())
 	}
 
-	rdsClient, err := getRDSClient()
+	var server *webhook.Server
+	if webhookCertFile != "" && webhookKeyFile != "" {
+		server = webhook.NewServer(webhookAddr, webhookCertFile, webhookKeyFile)
+		server.Handle("/convert", webhook.NewConversionHandler())
+		go func() {
+			if err := server.Run(stopCh); err != nil {
+				glog.Fatalf("error running webhook server: %s", err.Error())
+			}
+		}()
+	} else {
+		glog.Warningf("no webhook certificate provided, conversion and admission webhooks disabled")
+	}
+
+	go cfgStore.Run(stopCh)
+	namespaces := config.NewResolver(cfgStore, k8sClient)
+	go namespaces.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, namespaces.HasSynced) {
+		glog.Fatalf("error waiting for the namespaces to sync")
+	}
+
+	tags, err := awsclient.ParseSessionTags(assumeRoleSessionTags)
 	if err != nil {
-		glog.Fatalf("error cannot get rds client: %s", err.Error())
+		glog.Fatalf("invalid --assume-role-session-tags: %s", err.Error())
+	}
+	creds := awsclient.Credentials{
+		WebIdentityTokenFile: webIdentityTokenFile,
+		WebIdentityRoleARN:   webIdentityRoleARN,
+		AssumeRoleARN:        assumeRoleARN,
+		ExternalID:           assumeRoleExternalID,
+		SessionTags:          tags,
+	}
+	if err := creds.Validate(); err != nil {
+		glog.Fatalf("invalid aws credentials: %s", err.Error())
+	}
+
+	if metricsAddr != "" {
+		// the aws_permission_checks expvar is published on the default mux
+		http.Handle("/metrics", metrics.Handler())
+		go func() {
+			glog.Fatal(http.ListenAndServe(metricsAddr, nil))
+		}()
+	}
+
+	// clients of other regions and accounts are created as databases need them
+	clients := awsclient.NewPool(cfgStore, creds)
+	// one bucket for all regions and accounts keeps many reconciles at once
+	// under the rate limits of the RDS API
+	clients.RDSLimiter = rds.NewTokenBucket(rdsQPS, rdsBurst)
+	if err := awsclient.CheckPermissions(clients, cfg); err != nil {
+		glog.Fatalf("aws startup check failed: %s", err.Error())
+	}
+	awsVerified.Set()
+	keys := kms.NewKeyResolver(clients)
+
+	sizes := catalogue.NewStore(k8sClient, sizeCatalogueNamespace, sizeCatalogueName)
+	go sizes.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, sizes.HasSynced) {
+		glog.Fatalf("error waiting for the size catalogue to sync")
+	}
+
+	factory := externalversions.NewSharedInformerFactory(crdClient, cfg.Worker.Resync.Duration)
+	classInformer := factory.Postgresdb().V1beta1().PostgresDBClasses()
+	classes := k8s.NewClassResolver(classInformer.Lister())
+	quotaInformer := factory.Postgresdb().V1beta1().PostgresDBQuotas()
+	dbInformer := factory.Postgresdb().V1beta1().PostgresDBs()
+
+	optimus := worker.NewOptimus(sizes, namespaces, classes)
+	quotas := quota.NewChecker(quotaInformer.Lister(), dbInformer.Lister(), optimus, sizes)
+	validator := worker.NewPostgresDBValidator(sizes, namespaces, classes, quotas)
+	if server != nil {
+		server.Handle("/validate", webhook.NewAdmissionHandler(validator))
 	}
 
-	rdsConfig := rds.NewRDSTransformerConfig(&subnetGroup, sgIDs)
+	vaultClient := vault.NewClient(&http.Client{Timeout: 30 * time.Second}, cfgStore)
+
+	// app credentials are copied to the external stores of spec.credentialStores
+	smClient, err := clients.SecretsManager(database.Location{})
+	if err != nil {
+		glog.Fatalf("error cannot get secrets manager client: %s", err.Error())
+	}
+	stores := map[string]credstore.Store{
+		database.CredentialStoreSecretsManager: credstore.NewSecretsManager(smClient, cfgStore),
+		database.CredentialStoreVault:          credstore.NewVault(vaultClient, cfgStore),
+	}
+	credentials := credstore.NewSyncer(metrics.InstrumentCredentials(k8s.NewStoreCredsWithConfig(k8sClient, cfgStore)), stores)
+
+	// master credentials live in their own home, never in kube-system
+	var dataKeys kms.DataKeys
+	switch mc := cfg.MasterCredentials; {
+	case mc.LocalKeyFile != "":
+		dataKeys, err = kms.NewLocalDataKeys(mc.LocalKeyFile)
+		if err != nil {
+			glog.Fatalf("error reading master credentials key: %s", err.Error())
+		}
+	case mc.KMSKeyID != "":
+		kmsClient, err := clients.KMS(database.Location{})
+		if err != nil {
+			glog.Fatalf("error cannot get kms client: %s", err.Error())
+		}
+		dataKeys = kms.NewKMSDataKeys(kmsClient, mc.KMSKeyID)
+	}
+	var externalMasters core.CredentialsStorer
+	if s, ok := stores[cfg.MasterCredentials.Store]; ok {
+		externalMasters = s
+	}
+	masters := master.NewStore(k8sClient, cfgStore, dataKeys, externalMasters)
+
+	rdsConfig := rds.NewRDSTransformerConfig(aws.String(cfg.AWS.SubnetGroup), aws.StringSlice(cfg.AWS.SecurityGroupIDs))
 	rdsTransformer := rds.NewBumblebee(rdsConfig)
+	rdsImpure := rds.NewRDSImpure(clients, rdsTransformer)
+	rdsClient := metrics.InstrumentRDS(rdsImpure)
 	wrkr := worker.NewDBWorker(
-		rds.NewRDSImpure(rdsClient, rdsTransformer),
-		k8s.NewStoreCreds(k8sClient),
-		k8s.NewMetricsExporter(k8sClient),
-		worker.NewConfig(100000, nsSuffix),
-		worker.NewPostgresDBValidator(),
-		worker.NewLogger(),
-		worker.NewOptimus(),
+		rdsClient,
+		credentials,
+		metrics.InstrumentMetricsExporter(k8s.NewMetricsExporterWithConfig(k8sClient, cfgStore)),
+		worker.NewConfigWithOperatorConfig(nsSuffix, cfgStore),
+		validator,
+		logger,
+		optimus,
 		k8s.NewCRDClient(crdClient),
+		network.NewPreparer(clients, namespaces),
+		keys,
+		iamauth.New(k8s.NewIAMRoleGranter(k8sClient, cfgStore), clients),
+		vault.NewDatabaseEngine(vaultClient, cfgStore),
+		masters,
 	)
 
-	factory := externalversions.NewSharedInformerFactory(crdClient, time.Second*30)
-	go factory.Start(stopCh)
+	var source events.Source
+	if rdsEventsQueueURL != "" {
+		sqsClient, err := clients.SQS(database.Location{})
+		if err != nil {
+			glog.Fatalf("error cannot get sqs client: %s", err.Error())
+		}
+		source = events.NewSQSSource(sqsClient, rdsEventsQueueURL)
+	}
 
-	crdController := controller.New(factory, wrkr)
-	crdController.Run(stopCh)
-}
+	// queues are only measured when the provider is set before they are created
+	workqueue.SetProvider(metrics.WorkqueueProvider{})
+	controllerCfg := controller.NewConfig(cfg.Worker.AvailabilityCheckInterval.Duration, cfg.Worker.AvailabilityCheckJitter, cfg.Worker.Concurrency)
+	snapshots := snapshot.New(factory, crdClient, clients, keys, cfg.Worker.AvailabilityCheckInterval.Duration)
+	accessRequests := accessrequest.New(factory, k8sClient, crdClient, masters, rdsClient, cfgStore)
+	reconcilers := []func(<-chan struct{}){
+		quota.NewStatusReporter(quotas, crdClient).Run,
+		snapshots.Run,
+		accessRequests.Run,
+		iamauth.NewRefresher(dbInformer.Lister(), credentials, clients, cfgStore).Run,
+	}
+	if inventoryInterval > 0 {
+		// the inventory fetches the tags of every instance, its clients have
+		// a bucket of their own so it does not hold up reconciles
+		inventoryClients := awsclient.NewPool(cfgStore, creds)
+		inventoryClients.RDSLimiter = rds.NewTokenBucket(inventoryQPS, 1)
+		inventoryRDS := rds.NewRDSImpure(inventoryClients, rdsTransformer)
+		reconcilers = append(reconcilers, inventory.New(inventoryRDS, dbInformer.Lister(), k8sClient, cfgStore, inventoryNamespace, inventoryName, inventoryInterval).Run)
+	}
+
+	// every replica keeps its caches warm and serves the webhooks, classes and
+	// quotas have to be known before the first postgresdb is validated
+	factory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, classInformer.Informer().HasSynced, quotaInformer.Informer().HasSynced, dbInformer.Informer().HasSynced) {
+		glog.Fatalf("error waiting for the postgresdb classes and quotas to sync")
+	}
+	cachesSynced.Set()
 
-func getRDSClient() (rdsiface.RDSAPI, error) {
-	c := aws.NewConfig().WithRegion(region)
-	s, err := session.NewSession(c)
+	// only the leader writes, it returns once the reconciles in flight are done
+	lead := func(stop <-chan struct{}) {
+		if migrateStorageVersion {
+			migrator := k8s.NewStorageMigrator(crdClient, k8s.NewCRDVersionSetter(k8sClient.Discovery().RESTClient()))
+			if err := migrator.Migrate(k8s.PostgresDBCRD().Name); err != nil {
+				glog.Errorf("storage version migration failed: %s", err.Error())
+			}
+		}
+
+		// the postgresdbs already in the cache are handed to the worker as created
+		crdController := controller.New(factory, wrkr, controllerCfg, source, namespaces)
+		var running sync.WaitGroup
+		for _, run := range append(reconcilers, crdController.Run) {
+			running.Add(1)
+			go func(run func(<-chan struct{})) {
+				defer running.Done()
+				run(stop)
+			}(run)
+		}
+		running.Wait()
+	}
+
+	if !leaderElect {
+		lead(stopCh)
+		return
+	}
+	identity, err := os.Hostname()
+	if err != nil {
+		glog.Fatalf("error getting the leader election identity: %s", err.Error())
+	}
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: k8sClient.CoreV1().Events(leaderElectNamespace)})
+	lock := &resourcelock.ConfigMapLock{
+		ConfigMapMeta: metav1.ObjectMeta{Namespace: leaderElectNamespace, Name: leaderElectName},
+		Client:        k8sClient.CoreV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity:      identity,
+			EventRecorder: broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "postgresdb-controller"}),
+		},
+	}
+
+	// the leader stops on shutdown or once it lost its lease
+	leading := make(chan struct{})
+	led := make(chan struct{})
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: leaderElectLeaseDuration,
+		RenewDeadline: leaderElectRenewDeadline,
+		RetryPeriod:   leaderElectRetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(lost <-chan struct{}) {
+				close(leading)
+				defer close(led)
+				stop := make(chan struct{})
+				go func() {
+					select {
+					case <-stopCh:
+					case <-lost:
+					}
+					close(stop)
+				}()
+				lead(stop)
+			},
+			// the replica exits before another one takes over
+			OnStoppedLeading: func() {
+				glog.Fatalf("%s lost lease %s/%s", identity, leaderElectNamespace, leaderElectName)
+			},
+		},
+	})
 	if err != nil {
-		return nil, err
+		glog.Fatalf("invalid leader election: %s", err.Error())
+	}
+	go elector.Run()
+
+	<-stopCh
+	select {
+	case <-leading:
+	default:
+		return
+	}
+	select {
+	case <-led:
+	case <-time.After(shutdownTimeout):
+		glog.Warningf("abandoning reconciles still running after %s, they are picked up by the next leader", shutdownTimeout)
 	}
-	return rds2.New(s), nil
 }
 
 func init() {
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "kubeconfig file")
-	flag.StringVar(&nsSuffix, "ns-suffix", "", "namespace suffix (or env NS_SUFFIX)")
+	flag.StringVar(&configFile, "config", "", "operator config file, settings come from the legacy environment variables if empty")
+	flag.StringVar(&nsSuffix, "ns-suffix", "", "namespace suffix, overrides worker.namespaceSuffix of the config")
+	flag.BoolVar(&skipCRDCheck, "skip-crd-check", false, "do not verify the installed crd matches the operator on startup")
+	flag.StringVar(&webhookAddr, "webhook-addr", ":8443", "address the conversion webhook listens on")
+	flag.StringVar(&webhookCertFile, "webhook-cert-file", "", "tls certificate for the conversion webhook, the webhook is disabled if empty")
+	flag.StringVar(&webhookKeyFile, "webhook-key-file", "", "tls key for the conversion webhook, the webhook is disabled if empty")
+	flag.StringVar(&sizeCatalogueNamespace, "size-catalogue-namespace", "kube-system", "namespace of the size catalogue configmap")
+	flag.StringVar(&sizeCatalogueName, "size-catalogue-name", "postgresdb-sizes", "name of the size catalogue configmap, the built in catalogue is used while it does not exist")
+	flag.StringVar(&rdsEventsQueueURL, "rds-events-queue-url", "", "sqs queue subscribed to rds event notifications, checks databases on events when set")
+	flag.BoolVar(&migrateStorageVersion, "migrate-storage-version", true, "rewrite existing postgresdbs in the v1beta1 storage version on startup")
+	flag.StringVar(&webIdentityTokenFile, "web-identity-token-file", os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), "web identity token the operator assumes --web-identity-role-arn with, eg. of an iam role for service accounts")
+	flag.StringVar(&webIdentityRoleARN, "web-identity-role-arn", os.Getenv("AWS_ROLE_ARN"), "role assumed with --web-identity-token-file")
+	flag.StringVar(&assumeRoleARN, "assume-role-arn", "", "role the operator assumes with its credentials before calling aws")
+	flag.StringVar(&assumeRoleExternalID, "assume-role-external-id", "", "external id passed when assuming --assume-role-arn")
+	flag.StringVar(&assumeRoleSessionTags, "assume-role-session-tags", "", "comma separated key=value session tags passed when assuming --assume-role-arn")
+	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "address /metrics and /debug/vars are served on, disabled if empty")
+	flag.StringVar(&healthAddr, "health-addr", ":8081", "address /healthz and /readyz are served on, disabled if empty")
+	flag.BoolVar(&leaderElect, "leader-elect", true, "elect a leader among the replicas of the operator, only the leader reconciles")
+	flag.StringVar(&leaderElectNamespace, "leader-elect-namespace", envOrDefault("POD_NAMESPACE", "kube-system"), "namespace of the configmap holding the leader lease")
+	flag.StringVar(&leaderElectName, "leader-elect-name", "postgresdb-controller-leader", "name of the configmap holding the leader lease")
+	flag.DurationVar(&leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "how long standby replicas wait for a lease that is not renewed before taking over")
+	flag.DurationVar(&leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "how long the leader tries to renew its lease before it gives up leading")
+	flag.DurationVar(&leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "time between tries to acquire or renew the leader lease")
+	flag.DurationVar(&shutdownTimeout, "shutdown-timeout", 20*time.Second, "how long the leader waits for reconciles in flight on shutdown before it exits")
+	flag.StringVar(&logLevel, "log-level", "info", "level of the structured logs of reconciles: debug, info, warn or error")
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP collector the spans of reconciles are exported to, eg. http://otel-collector:4318, spans are dropped if empty")
+	flag.StringVar(&otlpServiceName, "otlp-service-name", envOrDefault("OTEL_SERVICE_NAME", "postgresdb-controller"), "service name of the exported spans")
+	flag.DurationVar(&traceExportInterval, "trace-export-interval", 5*time.Second, "time between exports of the spans of reconciles")
+	flag.Float64Var(&rdsQPS, "rds-qps", 5, "RDS API requests per second the operator sends at most, across all regions and accounts")
+	flag.IntVar(&rdsBurst, "rds-burst", 10, "RDS API requests the operator may send at once before --rds-qps applies")
+	flag.DurationVar(&inventoryInterval, "inventory-interval", 10*time.Minute, "time between inventories of the RDS instances created by the operator, disabled if 0")
+	flag.Float64Var(&inventoryQPS, "inventory-rds-qps", 1, "RDS API requests per second the inventory sends at most, on top of --rds-qps")
+	flag.StringVar(&inventoryNamespace, "inventory-namespace", envOrDefault("POD_NAMESPACE", "kube-system"), "namespace of the configmap summarising the inventory")
+	flag.StringVar(&inventoryName, "inventory-name", "postgresdb-inventory", "name of the configmap summarising the inventory")
 	flag.Parse()
 
 	// if no flag has been passed, read kubeconfig file from environment
 	if kubeconfig == "" {
 		kubeconfig = os.Getenv("KUBECONFIG")
 	}
-	region = os.Getenv("AWS_REGION")
-	subnetGroup = os.Getenv("DB_SUBNET_GROUP")
-	sgList := os.Getenv("DB_SECURITY_GROUP_IDS")
-
-	if region == "" {
-		region = "ap-southeast-2"
-	}
-
-	t := strings.Split(sgList, ",")
-	for _, v := range t {
-		sgIDs = append(sgIDs, &v)
-	}
+}
 
-	if nsSuffix == "" {
-		nsSuffix = os.Getenv("NS_SUFFIX")
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
 	}
+	return def
 }
@@ -0,0 +1,448 @@
+package accessrequest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/leader"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// MasterPasswordSetter replaces the master password of a database
+type MasterPasswordSetter interface {
+	RotateMasterPassword(loc database.Location, dbID database.DatabaseID, pw database.Password) error
+}
+
+// Controller grants PostgresDBAccessRequests by copying the master credential
+// of their PostgresDB into a secret of their namespace. Once a request
+// expires, or is deleted, the secret is removed and the master password
+// rotated. Granted requests carry a finalizer until then, so a deletion is
+// not missed while the operator is down. Every step is written to the audit
+// log and the request's events.
+type Controller struct {
+	kube     kubernetes.Interface
+	client   versioned.Interface
+	masters  core.CredentialsStorer
+	rds      MasterPasswordSetter
+	config   config.Getter
+	dbs      listers.PostgresDBLister
+	requests listers.PostgresDBAccessRequestLister
+	synced   cache.InformerSynced
+	queue    workqueue.RateLimitingInterface
+	now      func() time.Time
+}
+
+// New returns a Controller watching the access requests of the factory,
+// master credentials are read from and rotated in masters
+func New(factory externalversions.SharedInformerFactory, kube kubernetes.Interface, client versioned.Interface, masters core.CredentialsStorer, rds MasterPasswordSetter, c config.Getter) *Controller {
+	informer := factory.Postgresdb().V1beta1().PostgresDBAccessRequests()
+	ctrl := &Controller{
+		kube:     kube,
+		client:   client,
+		masters:  masters,
+		rds:      rds,
+		config:   c,
+		dbs:      factory.Postgresdb().V1beta1().PostgresDBs().Lister(),
+		requests: informer.Lister(),
+		synced:   informer.Informer().HasSynced,
+		queue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "postgresdbaccessrequests"),
+		now:      time.Now,
+	}
+
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ctrl.enqueue,
+		UpdateFunc: func(old, obj interface{}) {
+			ctrl.enqueue(obj)
+		},
+	})
+	return ctrl
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		glog.Errorf("unable to get key for %v: %v", obj, err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Run processes access requests until stopCh is closed, it returns once the
+// request in flight is done
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, c.synced) {
+		glog.Info("unable to sync postgresdb access requests")
+		c.queue.ShutDown()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait.Until(func() { leader.RunWorker(stopCh, c.processNextItem) }, time.Second, stopCh)
+	}()
+	<-stopCh
+	c.queue.ShutDown()
+	<-done
+}
+
+func (c *Controller) processNextItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key.(string))
+	if err != nil {
+		c.queue.Forget(key)
+		return true
+	}
+
+	var after time.Duration
+	start := time.Now()
+	req, err := c.requests.PostgresDBAccessRequests(ns).Get(name)
+	if errors.IsNotFound(err) {
+		err = nil
+	} else if err == nil {
+		after, err = c.Reconcile(req.DeepCopy())
+	}
+	metrics.ObserveReconcile("postgresdbaccessrequest", start, after > 0, err)
+	if err != nil {
+		glog.Errorf("unable to reconcile postgresdb access request %s: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	if after > 0 {
+		c.queue.AddAfter(key, after)
+	}
+	return true
+}
+
+// Reconcile moves an access request on to its next phase, it returns how long
+// to wait before checking the request again, or 0 if it is done
+func (c *Controller) Reconcile(req *v1beta1.PostgresDBAccessRequest) (time.Duration, error) {
+	if req.DeletionTimestamp != nil {
+		return 0, c.revokeDeleted(req)
+	}
+	switch req.Status.Phase {
+	case "":
+		return c.grant(req)
+	case v1beta1.AccessGranted:
+		return c.expire(req)
+	case v1beta1.AccessRotating:
+		return 0, c.rotate(req)
+	}
+	return 0, nil
+}
+
+func (c *Controller) grant(req *v1beta1.PostgresDBAccessRequest) (time.Duration, error) {
+	breakGlass := c.config.Get().MasterCredentials.BreakGlass
+	duration := breakGlass.DefaultDuration.Duration
+	if req.Spec.Duration != nil {
+		duration = req.Spec.Duration.Duration
+	}
+	if duration <= 0 || duration > breakGlass.MaxDuration.Duration {
+		return 0, c.deny(req, fmt.Sprintf("duration %s is not between 0 and %s", duration, breakGlass.MaxDuration.Duration))
+	}
+	if req.Spec.Reason == "" {
+		return 0, c.deny(req, "a reason is required")
+	}
+
+	db, err := c.dbs.PostgresDBs(req.Namespace).Get(req.Spec.PostgresDB)
+	if errors.IsNotFound(err) {
+		return 0, c.deny(req, fmt.Sprintf("postgresdb %s does not exist", req.Spec.PostgresDB))
+	}
+	if err != nil {
+		return 0, err
+	}
+	master, err := c.masters.GetCred(c.masterScope(), masterID(db))
+	if err != nil {
+		return 0, err
+	}
+	if master == nil {
+		return 0, c.deny(req, fmt.Sprintf("postgresdb %s has no master credential yet", db.Name))
+	}
+
+	// the finalizer is in place before the master credential is revealed
+	if !hasFinalizer(req) {
+		req.Finalizers = append(req.Finalizers, v1beta1.MasterRotationFinalizer)
+		if req, err = c.client.PostgresdbV1beta1().PostgresDBAccessRequests(req.Namespace).Update(req); err != nil {
+			return 0, err
+		}
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName(req),
+			Namespace: req.Namespace,
+			Labels: map[string]string{
+				"deployed-with": "ops-kube-db-operator",
+				"db-name":       db.Name,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(req, v1beta1.SchemeGroupVersion.WithKind("PostgresDBAccessRequest")),
+			},
+		},
+		StringData: k8s.CredentialData(master, c.config.Get().Secrets),
+	}
+	_, err = c.kube.CoreV1().Secrets(req.Namespace).Create(secret)
+	if errors.IsAlreadyExists(err) {
+		// only the secret of an earlier attempt of this request is replaced
+		var existing *v1.Secret
+		if existing, err = c.kube.CoreV1().Secrets(req.Namespace).Get(secret.Name, metav1.GetOptions{}); err != nil {
+			return 0, err
+		}
+		if !metav1.IsControlledBy(existing, req) {
+			return 0, c.deny(req, fmt.Sprintf("secret %s already exists and does not belong to the request", secret.Name))
+		}
+		_, err = c.kube.CoreV1().Secrets(req.Namespace).Update(secret)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	now := metav1.NewTime(c.now())
+	expires := metav1.NewTime(now.Add(duration))
+	req.Status.Phase = v1beta1.AccessGranted
+	req.Status.SecretName = secret.Name
+	req.Status.GrantedAt = &now
+	req.Status.ExpiresAt = &expires
+	req.Status.Message = ""
+	if _, err := c.updateStatus(req); err != nil {
+		return 0, err
+	}
+	c.audit(req, v1.EventTypeWarning, v1beta1.AccessGranted,
+		fmt.Sprintf("master credential of postgresdb %s revealed in secret %s until %s, reason: %s", db.Name, secret.Name, expires.UTC().Format(time.RFC3339), req.Spec.Reason))
+	return duration, nil
+}
+
+// expire removes the secret of an expired request and rotates the master
+// password it revealed
+func (c *Controller) expire(req *v1beta1.PostgresDBAccessRequest) (time.Duration, error) {
+	if req.Status.ExpiresAt != nil {
+		if left := req.Status.ExpiresAt.Sub(c.now()); left > 0 {
+			return left, nil
+		}
+	}
+
+	if err := c.deleteSecret(req); err != nil {
+		return 0, err
+	}
+	secret := req.Status.SecretName
+	req.Status.Phase = v1beta1.AccessRotating
+	req.Status.SecretName = ""
+	req.Status.Message = ""
+	updated, err := c.updateStatus(req)
+	if err != nil {
+		return 0, err
+	}
+	c.audit(req, v1.EventTypeNormal, "Expired", fmt.Sprintf("secret %s removed, rotating the master password of postgresdb %s", secret, req.Spec.PostgresDB))
+	return 0, c.rotate(updated)
+}
+
+func (c *Controller) rotate(req *v1beta1.PostgresDBAccessRequest) error {
+	rotated, err := c.rotateMaster(req)
+	if err != nil {
+		req.Status.Message = fmt.Sprintf("unable to rotate master password: %v", err)
+		c.updateStatus(req)
+		return err
+	}
+
+	req.Status.Phase = v1beta1.AccessExpired
+	req.Status.Rotated = rotated
+	req.Status.Message = ""
+	if !rotated {
+		req.Status.Message = fmt.Sprintf("postgresdb %s no longer exists", req.Spec.PostgresDB)
+	}
+	updated, err := c.updateStatus(req)
+	if err != nil {
+		return err
+	}
+	return c.removeFinalizer(updated)
+}
+
+// revokeDeleted removes the secret of a request deleted before it expired and
+// rotates the master password it revealed, the request goes away once its
+// finalizer is removed
+func (c *Controller) revokeDeleted(req *v1beta1.PostgresDBAccessRequest) error {
+	if !hasFinalizer(req) {
+		return nil
+	}
+	if revealed(req) {
+		if err := c.deleteSecret(req); err != nil {
+			return err
+		}
+		c.audit(req, v1.EventTypeNormal, "Deleted", fmt.Sprintf("request deleted before it expired, rotating the master password of postgresdb %s", req.Spec.PostgresDB))
+		if _, err := c.rotateMaster(req); err != nil {
+			return err
+		}
+	}
+	return c.removeFinalizer(req)
+}
+
+func (c *Controller) removeFinalizer(req *v1beta1.PostgresDBAccessRequest) error {
+	if !hasFinalizer(req) {
+		return nil
+	}
+	var finalizers []string
+	for _, f := range req.Finalizers {
+		if f != v1beta1.MasterRotationFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	req.Finalizers = finalizers
+	_, err := c.client.PostgresdbV1beta1().PostgresDBAccessRequests(req.Namespace).Update(req)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// rotateMaster stores a new master password then sets it on the database, a
+// failure in between is retried with yet another password. The PostgresDB
+// is annotated so its secrets are rewritten. It returns false if the
+// PostgresDB no longer exists.
+func (c *Controller) rotateMaster(req *v1beta1.PostgresDBAccessRequest) (bool, error) {
+	db, err := c.dbs.PostgresDBs(req.Namespace).Get(req.Spec.PostgresDB)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	master, err := c.masters.GetCred(c.masterScope(), masterID(db))
+	if err != nil {
+		return false, err
+	}
+	if master == nil {
+		return false, fmt.Errorf("master credential of postgresdb %s is missing", db.Name)
+	}
+
+	pw, err := core.GenPassword(c.config.Get().Passwords.For(database.GetUserNameForType(database.CredTypeAdmin)))
+	if err != nil {
+		return false, err
+	}
+	master.Password = database.Password(pw)
+	master.ID = masterID(db)
+	master.Scope = c.masterScope()
+	master.CredType = database.CredTypeAdmin
+	if err := c.masters.UpdateCred(master); err != nil {
+		return false, fmt.Errorf("unable to store new master password: %v", err)
+	}
+	loc := database.Location{Region: db.Spec.Region, Account: db.Spec.AWSAccount}
+	if err := c.rds.RotateMasterPassword(loc, database.DatabaseID(db.Status.ID), master.Password); err != nil {
+		return false, fmt.Errorf("unable to set new master password of %s: %v", db.Status.ID, err)
+	}
+
+	db = db.DeepCopy()
+	if db.Annotations == nil {
+		db.Annotations = map[string]string{}
+	}
+	db.Annotations[v1beta1.MasterRotatedAnnotation] = c.now().UTC().Format(time.RFC3339)
+	if _, err := c.client.PostgresdbV1beta1().PostgresDBs(db.Namespace).Update(db); err != nil {
+		return false, fmt.Errorf("unable to annotate postgresdb %s: %v", db.Name, err)
+	}
+	c.audit(req, v1.EventTypeNormal, "Rotated", fmt.Sprintf("master password of postgresdb %s rotated", db.Name))
+	return true, nil
+}
+
+// deny fails a request, denied requests revealed nothing so they need no
+// rotation once deleted
+func (c *Controller) deny(req *v1beta1.PostgresDBAccessRequest, message string) error {
+	req.Status.Phase = v1beta1.AccessDenied
+	req.Status.Message = message
+	updated, err := c.updateStatus(req)
+	if err != nil {
+		return err
+	}
+	c.audit(req, v1.EventTypeWarning, v1beta1.AccessDenied, message)
+	return c.removeFinalizer(updated)
+}
+
+func (c *Controller) deleteSecret(req *v1beta1.PostgresDBAccessRequest) error {
+	err := c.kube.CoreV1().Secrets(req.Namespace).Delete(secretName(req), &metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *Controller) updateStatus(req *v1beta1.PostgresDBAccessRequest) (*v1beta1.PostgresDBAccessRequest, error) {
+	return c.client.PostgresdbV1beta1().PostgresDBAccessRequests(req.Namespace).UpdateStatus(req)
+}
+
+// audit logs a step of a request and records it as an event of the request
+func (c *Controller) audit(req *v1beta1.PostgresDBAccessRequest, eventType, reason, message string) {
+	glog.Infof("audit: postgresdbaccessrequest %s/%s (uid %s) %s: %s", req.Namespace, req.Name, req.UID, reason, message)
+
+	now := metav1.NewTime(c.now())
+	_, err := c.kube.CoreV1().Events(req.Namespace).Create(&v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%s.%x", req.Name, strings.ToLower(reason), now.UnixNano()),
+			Namespace: req.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: v1beta1.SchemeGroupVersion.String(),
+			Kind:       "PostgresDBAccessRequest",
+			Namespace:  req.Namespace,
+			Name:       req.Name,
+			UID:        req.UID,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "postgresdb-controller"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	})
+	if err != nil {
+		glog.Errorf("unable to record event of postgresdbaccessrequest %s/%s: %v", req.Namespace, req.Name, err)
+	}
+}
+
+func (c *Controller) masterScope() database.Scope {
+	return database.Scope(c.config.Get().MasterCredentials.Namespace)
+}
+
+// revealed requests may have handed out the current master password
+func revealed(req *v1beta1.PostgresDBAccessRequest) bool {
+	return req.Status.Phase == v1beta1.AccessGranted || req.Status.Phase == v1beta1.AccessRotating
+}
+
+func hasFinalizer(req *v1beta1.PostgresDBAccessRequest) bool {
+	for _, f := range req.Finalizers {
+		if f == v1beta1.MasterRotationFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func masterID(db *v1beta1.PostgresDB) database.CredentialID {
+	return database.GetCredentialID(db.Namespace, db.Name, database.CredTypeAdmin)
+}
+
+// secretName is the secret revealing the master credential of a request
+func secretName(req *v1beta1.PostgresDBAccessRequest) string {
+	return fmt.Sprintf("%s-master", req.Name)
+}
@@ -0,0 +1,225 @@
+package accessrequest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+type memoryStore map[database.CredentialID]*database.Credential
+
+func (m memoryStore) GetCred(s database.Scope, id database.CredentialID) (*database.Credential, error) {
+	if cred, ok := m[id]; ok {
+		copy := *cred
+		return &copy, nil
+	}
+	return nil, nil
+}
+
+func (m memoryStore) CreateCred(cred *database.Credential) error {
+	m[cred.ID] = cred
+	return nil
+}
+
+func (m memoryStore) UpdateCred(cred *database.Credential) error {
+	m[cred.ID] = cred
+	return nil
+}
+
+type fakeRDS struct {
+	passwords map[database.DatabaseID]database.Password
+}
+
+func (f *fakeRDS) RotateMasterPassword(loc database.Location, id database.DatabaseID, pw database.Password) error {
+	f.passwords[id] = pw
+	return nil
+}
+
+var start = time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func newRequest(duration *metav1.Duration) *v1beta1.PostgresDBAccessRequest {
+	return &v1beta1.PostgresDBAccessRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: "incident-42", Namespace: "team", UID: "1234"},
+		Spec: v1beta1.PostgresDBAccessRequestSpec{
+			PostgresDB: "orders",
+			Reason:     "incident 42, fixing a stuck migration",
+			Duration:   duration,
+		},
+	}
+}
+
+func newController(req *v1beta1.PostgresDBAccessRequest) (*Controller, *fake.Clientset, *kubefake.Clientset, memoryStore, *fakeRDS) {
+	db := &v1beta1.PostgresDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team"},
+		Status:     v1beta1.PostgresDBStatus{ID: "orders-5678", Phase: "Available"},
+	}
+	client := fake.NewSimpleClientset(req, db)
+	kube := kubefake.NewSimpleClientset()
+	masters := memoryStore{"team-orders-master": {ID: "team-orders-master", Scope: "postgresdb-operator", Username: "master", Password: "revealed"}}
+	rds := &fakeRDS{passwords: map[database.DatabaseID]database.Password{}}
+
+	factory := externalversions.NewSharedInformerFactory(client, 0)
+	c := New(factory, kube, client, masters, rds, config.Fixed{Config: config.Default()})
+	c.now = func() time.Time { return start }
+	factory.Postgresdb().V1beta1().PostgresDBs().Informer().GetIndexer().Add(db)
+	return c, client, kube, masters, rds
+}
+
+func get(t *testing.T, client *fake.Clientset) *v1beta1.PostgresDBAccessRequest {
+	req, err := client.PostgresdbV1beta1().PostgresDBAccessRequests("team").Get("incident-42", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestReconcile_GrantThenRotate(t *testing.T) {
+	req := newRequest(&metav1.Duration{Duration: 30 * time.Minute})
+	c, client, kube, masters, rds := newController(req)
+
+	after, err := c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+	assert.Equal(t, 30*time.Minute, after)
+
+	granted := get(t, client)
+	assert.Equal(t, v1beta1.AccessGranted, granted.Status.Phase)
+	assert.Equal(t, []string{v1beta1.MasterRotationFinalizer}, granted.Finalizers)
+	assert.Equal(t, start.Add(30*time.Minute), granted.Status.ExpiresAt.Time.UTC())
+	secret, err := kube.CoreV1().Secrets("team").Get(granted.Status.SecretName, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "revealed", secret.StringData[k8s.PASSWORD])
+	assert.Equal(t, "PostgresDBAccessRequest", secret.OwnerReferences[0].Kind)
+
+	// nothing happens before the request expires
+	c.now = func() time.Time { return start.Add(10 * time.Minute) }
+	after, err = c.Reconcile(granted)
+	assert.Nil(t, err)
+	assert.Equal(t, 20*time.Minute, after)
+
+	c.now = func() time.Time { return start.Add(31 * time.Minute) }
+	after, err = c.Reconcile(granted)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), after)
+
+	expired := get(t, client)
+	assert.Equal(t, v1beta1.AccessExpired, expired.Status.Phase)
+	assert.True(t, expired.Status.Rotated)
+	assert.Empty(t, expired.Finalizers)
+	_, err = kube.CoreV1().Secrets("team").Get(granted.Status.SecretName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+
+	// the database and the master store have the same new password
+	pw := masters["team-orders-master"].Password
+	assert.NotEqual(t, database.Password("revealed"), pw)
+	assert.Equal(t, pw, rds.passwords["orders-5678"])
+	db, _ := client.PostgresdbV1beta1().PostgresDBs("team").Get("orders", metav1.GetOptions{})
+	assert.Equal(t, "2018-05-01T10:31:00Z", db.Annotations[v1beta1.MasterRotatedAnnotation])
+
+	events, _ := kube.CoreV1().Events("team").List(metav1.ListOptions{})
+	assert.Len(t, events.Items, 3)
+}
+
+func TestReconcile_DeniesLongDuration(t *testing.T) {
+	req := newRequest(&metav1.Duration{Duration: 12 * time.Hour})
+	c, client, kube, _, _ := newController(req)
+
+	_, err := c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+
+	assert.Equal(t, v1beta1.AccessDenied, get(t, client).Status.Phase)
+	secrets, _ := kube.CoreV1().Secrets("team").List(metav1.ListOptions{})
+	assert.Empty(t, secrets.Items)
+}
+
+func TestReconcile_DeniesMissingDatabase(t *testing.T) {
+	req := newRequest(nil)
+	req.Spec.PostgresDB = "missing"
+	c, client, _, _, _ := newController(req)
+
+	_, err := c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+
+	denied := get(t, client)
+	assert.Equal(t, v1beta1.AccessDenied, denied.Status.Phase)
+	assert.Equal(t, "postgresdb missing does not exist", denied.Status.Message)
+}
+
+func TestReconcile_DeniesForeignSecret(t *testing.T) {
+	req := newRequest(nil)
+	c, client, kube, _, _ := newController(req)
+	kube.CoreV1().Secrets("team").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "incident-42-master", Namespace: "team"},
+		StringData: map[string]string{"config": "kept"},
+	})
+
+	_, err := c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+
+	denied := get(t, client)
+	assert.Equal(t, v1beta1.AccessDenied, denied.Status.Phase)
+	assert.Equal(t, "secret incident-42-master already exists and does not belong to the request", denied.Status.Message)
+	assert.Empty(t, denied.Finalizers)
+	secret, _ := kube.CoreV1().Secrets("team").Get("incident-42-master", metav1.GetOptions{})
+	assert.Equal(t, map[string]string{"config": "kept"}, secret.StringData)
+
+	// the secret of an earlier attempt of the request is replaced
+	req = newRequest(nil)
+	c, client, kube, _, _ = newController(req)
+	kube.CoreV1().Secrets("team").Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "incident-42-master", Namespace: "team", OwnerReferences: []metav1.OwnerReference{
+			*metav1.NewControllerRef(req, v1beta1.SchemeGroupVersion.WithKind("PostgresDBAccessRequest")),
+		}},
+	})
+	_, err = c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+	assert.Equal(t, v1beta1.AccessGranted, get(t, client).Status.Phase)
+	secret, _ = kube.CoreV1().Secrets("team").Get("incident-42-master", metav1.GetOptions{})
+	assert.Equal(t, "revealed", secret.StringData[k8s.PASSWORD])
+}
+
+func TestReconcile_RevokesDeleted(t *testing.T) {
+	req := newRequest(nil)
+	c, client, kube, masters, rds := newController(req)
+
+	_, err := c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+
+	// the request is only marked for deletion while its finalizer is there
+	deleted := get(t, client)
+	now := metav1.NewTime(start.Add(time.Minute))
+	deleted.DeletionTimestamp = &now
+	_, err = c.Reconcile(deleted)
+	assert.Nil(t, err)
+
+	assert.NotEqual(t, database.Password("revealed"), masters["team-orders-master"].Password)
+	assert.Equal(t, masters["team-orders-master"].Password, rds.passwords["orders-5678"])
+	_, err = kube.CoreV1().Secrets("team").Get(deleted.Status.SecretName, metav1.GetOptions{})
+	assert.NotNil(t, err)
+	assert.Empty(t, get(t, client).Finalizers)
+}
+
+func TestReconcile_DeletedDenied(t *testing.T) {
+	req := newRequest(&metav1.Duration{Duration: 12 * time.Hour})
+	c, client, _, masters, _ := newController(req)
+
+	_, err := c.Reconcile(req.DeepCopy())
+	assert.Nil(t, err)
+	denied := get(t, client)
+	assert.Empty(t, denied.Finalizers)
+
+	now := metav1.NewTime(start.Add(time.Minute))
+	denied.DeletionTimestamp = &now
+	_, err = c.Reconcile(denied)
+	assert.Nil(t, err)
+	assert.Equal(t, database.Password("revealed"), masters["team-orders-master"].Password)
+}
@@ -21,5 +21,6 @@ OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 // +k8s:conversion-gen=github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb
 
 // Package v1alpha1 is the v1alpha1 version of the API.
-// +groupName=postgresdb.myob.com
+// +groupName=myob.com
+// +groupGoName=Postgresdb
 package v1alpha1
@@ -25,6 +25,13 @@ import (
 
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=postgresdbs,shortName=pgdb,singular=postgresdb
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Size",type="string",JSONPath=".spec.size"
+// +kubebuilder:printcolumn:name="Storage",type="string",JSONPath=".spec.storage"
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".status.endpoint"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // PostgresDB is a specification for a DB resource
 type PostgresDB struct {
@@ -36,18 +43,25 @@ type PostgresDB struct {
 
 // PostgresDBSpec is the spec for a DB resource
 type PostgresDBSpec struct {
-	Size    string            `json:"size,omitempty"`
-	Storage string            `json:"storage,omitempty"`
-	Iops    int64             `json:"iops,omitempty"`
-	HA      bool              `json:"ha,omitempty"`
-	Tags    map[string]string `json:"tags,omitempty"`
+	// +kubebuilder:validation:Enum=db.t2.small;db.t2.medium;db.t2.xlarge;db.m4.large;db.m4.2xlarge;db.m4.4xlarge;db.m4.16xlarge
+	Size string `json:"size"`
+	// Storage is the allocated storage in GiB
+	// +kubebuilder:validation:Pattern=^[0-9]+$
+	Storage string `json:"storage"`
+	// +kubebuilder:validation:Enum=gp2;io1;standard
+	StorageType string `json:"storageType,omitempty"`
+	// +kubebuilder:validation:Minimum=1000
+	Iops int64             `json:"iops,omitempty"`
+	HA   bool              `json:"ha,omitempty"`
+	Tags map[string]string `json:"tags,omitempty"`
 }
 
 // PostgresDBStatus is the status for a DB resource
 type PostgresDBStatus struct {
-	Ready string `json:"ready"`
-	ARN   string `json:"arn"`
-	ID    string `json:"id"`
+	Ready    string `json:"ready"`
+	ARN      string `json:"arn"`
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -0,0 +1,151 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ConversionAnnotation keeps the v1beta1 only fields of an object while it is
+// served as v1alpha1 so they survive a round trip
+const ConversionAnnotation = "postgresdb.myob.com/v1beta1-spec"
+
+const gib = int64(1) << 30
+
+// convertedFields are the parts of a v1beta1 spec v1alpha1 cannot represent
+type convertedFields struct {
+	ClassName  string          `json:"className,omitempty"`
+	Size       string          `json:"size,omitempty"`
+	Backup     *BackupSpec     `json:"backup,omitempty"`
+	Network    *NetworkSpec    `json:"network,omitempty"`
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+	Region     string          `json:"region,omitempty"`
+	AWSAccount string          `json:"awsAccount,omitempty"`
+	IAMAuth    bool            `json:"iamAuthentication,omitempty"`
+	Stores     []string        `json:"credentialStores,omitempty"`
+	Vault      bool            `json:"vaultDynamicCredentials,omitempty"`
+}
+
+// StorageGiB returns the allocated storage in whole GiB, rounding up
+func (s *PostgresDBSpec) StorageGiB() int64 {
+	return (s.Storage.Value() + gib - 1) / gib
+}
+
+// ConvertFromV1alpha1 converts a v1alpha1 PostgresDB into out
+func ConvertFromV1alpha1(in *v1alpha1.PostgresDB, out *PostgresDB) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	out.Spec = PostgresDBSpec{
+		InstanceClass: in.Spec.Size,
+		StorageType:   in.Spec.StorageType,
+		Iops:          in.Spec.Iops,
+		HA:            in.Spec.HA,
+	}
+	if in.Spec.Tags != nil {
+		out.Spec.Tags = make(map[string]string, len(in.Spec.Tags))
+		for k, v := range in.Spec.Tags {
+			out.Spec.Tags[k] = v
+		}
+	}
+
+	if in.Spec.Storage != "" {
+		gb, err := strconv.ParseInt(in.Spec.Storage, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid v1alpha1 storage %q: %v", in.Spec.Storage, err)
+		}
+		out.Spec.Storage = *resource.NewQuantity(gb*gib, resource.BinarySI)
+	}
+
+	if raw, ok := out.Annotations[ConversionAnnotation]; ok {
+		fields := &convertedFields{}
+		if err := json.Unmarshal([]byte(raw), fields); err != nil {
+			return fmt.Errorf("invalid %s annotation: %v", ConversionAnnotation, err)
+		}
+		// a tier and a class are mutually exclusive, the tier wins
+		if fields.Size != "" {
+			out.Spec.Size = fields.Size
+			out.Spec.InstanceClass = ""
+		}
+		out.Spec.ClassName = fields.ClassName
+		out.Spec.Backup = fields.Backup
+		out.Spec.Network = fields.Network
+		out.Spec.Encryption = fields.Encryption
+		out.Spec.Region = fields.Region
+		out.Spec.AWSAccount = fields.AWSAccount
+		out.Spec.IAMAuthentication = fields.IAMAuth
+		out.Spec.CredentialStores = fields.Stores
+		out.Spec.VaultDynamicCredentials = fields.Vault
+		delete(out.Annotations, ConversionAnnotation)
+		if len(out.Annotations) == 0 {
+			out.Annotations = nil
+		}
+	}
+
+	out.Status = PostgresDBStatus{
+		Ready:    in.Status.Ready,
+		ARN:      in.Status.ARN,
+		ID:       in.Status.ID,
+		Endpoint: in.Status.Endpoint,
+	}
+	return nil
+}
+
+// ConvertToV1alpha1 converts a v1beta1 PostgresDB into out, fields v1alpha1
+// cannot represent are kept in the ConversionAnnotation
+func ConvertToV1alpha1(in *PostgresDB, out *v1alpha1.PostgresDB) error {
+	out.TypeMeta = in.TypeMeta
+	out.APIVersion = v1alpha1.SchemeGroupVersion.String()
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+
+	out.Spec = v1alpha1.PostgresDBSpec{
+		Size:        in.Spec.InstanceClass,
+		Storage:     strconv.FormatInt(in.Spec.StorageGiB(), 10),
+		StorageType: in.Spec.StorageType,
+		Iops:        in.Spec.Iops,
+		HA:          in.Spec.HA,
+	}
+	if in.Spec.Tags != nil {
+		out.Spec.Tags = make(map[string]string, len(in.Spec.Tags))
+		for k, v := range in.Spec.Tags {
+			out.Spec.Tags[k] = v
+		}
+	}
+
+	fields := convertedFields{
+		ClassName:  in.Spec.ClassName,
+		Size:       in.Spec.Size,
+		Backup:     in.Spec.Backup,
+		Network:    in.Spec.Network,
+		Encryption: in.Spec.Encryption,
+		Region:     in.Spec.Region,
+		AWSAccount: in.Spec.AWSAccount,
+		IAMAuth:    in.Spec.IAMAuthentication,
+		Stores:     in.Spec.CredentialStores,
+		Vault:      in.Spec.VaultDynamicCredentials,
+	}
+	if !reflect.DeepEqual(fields, convertedFields{}) {
+		raw, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		if out.Annotations == nil {
+			out.Annotations = map[string]string{}
+		}
+		out.Annotations[ConversionAnnotation] = string(raw)
+	}
+
+	// phase and conditions are not kept, the operator sets them again on its next status update
+	out.Status = v1alpha1.PostgresDBStatus{
+		Ready:    in.Status.Ready,
+		ARN:      in.Status.ARN,
+		ID:       in.Status.ID,
+		Endpoint: in.Status.Endpoint,
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestConvertFromV1alpha1(t *testing.T) {
+	in := &v1alpha1.PostgresDB{}
+	in.Name = "test"
+	in.Spec.Size = "db.m4.large"
+	in.Spec.Storage = "5"
+	in.Spec.HA = true
+	in.Status.Ready = "available"
+
+	out := &PostgresDB{}
+	err := ConvertFromV1alpha1(in, out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "myob.com/v1beta1", out.APIVersion)
+	assert.Equal(t, "db.m4.large", out.Spec.InstanceClass)
+	assert.Equal(t, "", out.Spec.Size)
+	assert.Equal(t, int64(5), out.Spec.StorageGiB())
+	assert.True(t, out.Spec.HA)
+	assert.Equal(t, "available", out.Status.Ready)
+}
+
+func TestConvertFromV1alpha1_InvalidStorage(t *testing.T) {
+	in := &v1alpha1.PostgresDB{}
+	in.Spec.Storage = "banana"
+
+	err := ConvertFromV1alpha1(in, &PostgresDB{})
+	assert.NotNil(t, err)
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	days := int64(7)
+	in := &PostgresDB{}
+	in.Name = "test"
+	in.Spec.ClassName = "production"
+	in.Spec.Size = "medium"
+	in.Spec.Storage = resource.MustParse("20Gi")
+	in.Spec.Backup = &BackupSpec{RetentionDays: &days, Window: "03:00-04:00"}
+	in.Spec.Network = &NetworkSpec{SubnetGroup: "private", SecurityGroupIDs: []string{"sg-1"}}
+	in.Spec.Encryption = &EncryptionSpec{KMSKeyID: "alias/databases"}
+	in.Spec.Region = "ap-southeast-1"
+	in.Spec.AWSAccount = "dr"
+	in.Spec.IAMAuthentication = true
+	in.Spec.CredentialStores = []string{"vault"}
+	in.Spec.VaultDynamicCredentials = true
+
+	alpha := &v1alpha1.PostgresDB{}
+	err := ConvertToV1alpha1(in, alpha)
+	assert.Nil(t, err)
+	assert.Equal(t, "20", alpha.Spec.Storage)
+	assert.Contains(t, alpha.Annotations, ConversionAnnotation)
+
+	out := &PostgresDB{}
+	err = ConvertFromV1alpha1(alpha, out)
+	assert.Nil(t, err)
+	assert.Equal(t, "production", out.Spec.ClassName)
+	assert.Equal(t, "medium", out.Spec.Size)
+	assert.Equal(t, "", out.Spec.InstanceClass)
+	assert.Equal(t, int64(20), out.Spec.StorageGiB())
+	assert.Equal(t, in.Spec.Backup, out.Spec.Backup)
+	assert.Equal(t, in.Spec.Network, out.Spec.Network)
+	assert.Equal(t, in.Spec.Encryption, out.Spec.Encryption)
+	assert.Equal(t, "ap-southeast-1", out.Spec.Region)
+	assert.Equal(t, "dr", out.Spec.AWSAccount)
+	assert.True(t, out.Spec.IAMAuthentication)
+	assert.Equal(t, []string{"vault"}, out.Spec.CredentialStores)
+	assert.True(t, out.Spec.VaultDynamicCredentials)
+	assert.Nil(t, out.Annotations)
+}
+
+func TestStorageGiB_RoundsUp(t *testing.T) {
+	spec := &PostgresDBSpec{Storage: resource.MustParse("1500Mi")}
+	assert.Equal(t, int64(2), spec.StorageGiB())
+}
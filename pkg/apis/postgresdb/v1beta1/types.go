@@ -0,0 +1,425 @@
+/*
+
+Copyright 2017 MYOB Technology Pty Ltd
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+documentation files (the "Software"), to deal in the Software without restriction, including without limitation
+the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software,
+and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED
+TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT.
+IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY,
+WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE
+OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+*/
+
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=postgresdbs,shortName=pgdb,singular=postgresdb
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Status",type="string",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Size",type="string",JSONPath=".spec.size"
+// +kubebuilder:printcolumn:name="DB Class",type="string",JSONPath=".spec.className"
+// +kubebuilder:printcolumn:name="Class",type="string",JSONPath=".spec.instanceClass"
+// +kubebuilder:printcolumn:name="Storage",type="string",JSONPath=".spec.storage"
+// +kubebuilder:printcolumn:name="Endpoint",type="string",JSONPath=".status.endpoint"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PostgresDB is a specification for a DB resource
+type PostgresDB struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PostgresDBSpec   `json:"spec"`
+	Status            PostgresDBStatus `json:"status"`
+}
+
+// PostgresDBSpec is the spec for a DB resource
+type PostgresDBSpec struct {
+	// ClassName is the PostgresDBClass whose settings apply to fields the spec
+	// leaves empty, the default class is used if it is empty
+	ClassName string `json:"className,omitempty"`
+	// Size is a tier name or an instance class from the cluster's size catalogue,
+	// set either Size or InstanceClass
+	Size string `json:"size,omitempty"`
+	// InstanceClass is an explicit RDS instance class the size catalogue allows,
+	// set either Size or InstanceClass
+	InstanceClass string `json:"instanceClass,omitempty"`
+	// Storage is the allocated storage, rounded up to whole GiB, the default
+	// storage of the size tier is used if it is zero
+	Storage resource.Quantity `json:"storage"`
+	// +kubebuilder:validation:Enum=gp2;io1;standard
+	StorageType string `json:"storageType,omitempty"`
+	// +kubebuilder:validation:Minimum=1000
+	Iops       int64             `json:"iops,omitempty"`
+	HA         bool              `json:"ha,omitempty"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Backup     *BackupSpec       `json:"backup,omitempty"`
+	Network    *NetworkSpec      `json:"network,omitempty"`
+	Encryption *EncryptionSpec   `json:"encryption,omitempty"`
+	// Region is the AWS region of the database, the operator's region is used if it is empty
+	Region string `json:"region,omitempty"`
+	// AWSAccount is the name of an account of the operator config the database
+	// is created in, the operator's own account is used if it is empty
+	AWSAccount string `json:"awsAccount,omitempty"`
+	// IAMAuthentication has apps connect as appuser with short lived IAM auth
+	// tokens the operator keeps in their secrets instead of a password
+	IAMAuthentication bool `json:"iamAuthentication,omitempty"`
+	// CredentialStores are the external stores, secretsmanager or vault, the
+	// app credentials are kept in sync with next to their kubernetes secrets
+	CredentialStores []string `json:"credentialStores,omitempty"`
+	// VaultDynamicCredentials has the operator set up a Vault database secrets
+	// engine handing out short lived users of the database to apps
+	VaultDynamicCredentials bool `json:"vaultDynamicCredentials,omitempty"`
+}
+
+// BackupSpec configures automated backups of a DB resource
+type BackupSpec struct {
+	// RetentionDays is the number of days automated backups are kept
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=35
+	RetentionDays *int64 `json:"retentionDays,omitempty"`
+	// Window is the daily UTC backup window, eg. 13:30-14:30
+	Window string `json:"window,omitempty"`
+	// MaintenanceWindow is the weekly UTC maintenance window, eg. Sat:14:30-Sat:15:30
+	MaintenanceWindow string `json:"maintenanceWindow,omitempty"`
+}
+
+// NetworkSpec configures where a DB resource is placed
+type NetworkSpec struct {
+	SubnetGroup string `json:"subnetGroup,omitempty"`
+	// SecurityGroupIDs replace the security groups of the class and operator config
+	SecurityGroupIDs []string `json:"securityGroupIds,omitempty"`
+	// AdditionalSecurityGroupIDs are added to the security groups of the database
+	AdditionalSecurityGroupIDs []string `json:"additionalSecurityGroupIds,omitempty"`
+	// ManagedSecurityGroup has the operator create a security group of the
+	// database's own allowing the ingress rules
+	ManagedSecurityGroup *ManagedSecurityGroupSpec `json:"managedSecurityGroup,omitempty"`
+	// PubliclyAccessible gives the database a public address, it is false by default
+	PubliclyAccessible bool `json:"publiclyAccessible,omitempty"`
+}
+
+// EncryptionSpec configures the encryption at rest of a DB resource
+type EncryptionSpec struct {
+	// KMSKeyID is the id, ARN or alias, eg. alias/databases, of the customer
+	// managed KMS key of the database, the AWS managed key is used if it is empty
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+}
+
+// ManagedSecurityGroupSpec lists who may connect to a database through the
+// security group the operator manages for it
+type ManagedSecurityGroupSpec struct {
+	Ingress []IngressRule `json:"ingress,omitempty"`
+}
+
+// IngressRule allows postgres connections from a CIDR or from namespaces, set one of them
+type IngressRule struct {
+	// CIDR is an IPv4 block, eg. 10.0.0.0/16
+	CIDR string `json:"cidr,omitempty"`
+	// NamespaceSelector allows the CIDRs of the postgresdb.myob.com/ingress-cidrs
+	// annotation of every namespace it matches
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// PostgresDBStatus is the status for a DB resource
+type PostgresDBStatus struct {
+	Ready    string `json:"ready"`
+	ARN      string `json:"arn"`
+	ID       string `json:"id"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// Phase is the status of the RDS instance, eg. Creating or Available
+	Phase      string                `json:"phase,omitempty"`
+	Conditions []PostgresDBCondition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the spec the creation of the
+	// database last failed for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// PostgresDBConditionType is the type of a PostgresDB condition
+type PostgresDBConditionType string
+
+const (
+	// ConditionReady is true when the database is available
+	ConditionReady PostgresDBConditionType = "Ready"
+	// ConditionProgressing is true while RDS is working on the database
+	ConditionProgressing PostgresDBConditionType = "Progressing"
+	// ConditionFailed is true when the database needs someone to intervene
+	ConditionFailed PostgresDBConditionType = "Failed"
+)
+
+// PostgresDBCondition describes an aspect of the state of a DB resource
+type PostgresDBCondition struct {
+	Type               PostgresDBConditionType `json:"type"`
+	Status             corev1.ConditionStatus  `json:"status"`
+	LastTransitionTime metav1.Time             `json:"lastTransitionTime,omitempty"`
+	Reason             string                  `json:"reason,omitempty"`
+	Message            string                  `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=postgresdbs
+// PostgresDBList is a list of DB resources
+type PostgresDBList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PostgresDB `json:"items"`
+}
+
+// DefaultClassAnnotation marks the PostgresDBClass used by PostgresDBs without a className
+const DefaultClassAnnotation = "postgresdb.myob.com/is-default-class"
+
+// TraceIDAnnotation holds the trace id of the last reconcile of a PostgresDB
+const TraceIDAnnotation = "postgresdb.myob.com/last-reconcile-trace-id"
+
+// TagKeysAnnotation lists the keys of spec.tags last set on the instance of a
+// PostgresDB as JSON, keys taken out of spec.tags are removed from the
+// instance by the next reconcile
+const TagKeysAnnotation = "postgresdb.myob.com/spec-tag-keys"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=postgresdbclasses,shortName=pgdbclass,singular=postgresdbclass,scope=Cluster
+// +kubebuilder:printcolumn:name="Engine",type="string",JSONPath=".spec.engineVersion"
+// +kubebuilder:printcolumn:name="Subnet Group",type="string",JSONPath=".spec.subnetGroup"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PostgresDBClass is a platform defined preset for DB resources
+type PostgresDBClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PostgresDBClassSpec `json:"spec"`
+}
+
+// PostgresDBClassSpec holds the settings a class applies to its DB resources
+type PostgresDBClassSpec struct {
+	SubnetGroup      string   `json:"subnetGroup,omitempty"`
+	SecurityGroupIDs []string `json:"securityGroupIds,omitempty"`
+	// ParameterGroup is the RDS DB parameter group of the databases
+	ParameterGroup string `json:"parameterGroup,omitempty"`
+	EngineVersion  string `json:"engineVersion,omitempty"`
+	// +kubebuilder:validation:Enum=gp2;io1;standard
+	StorageType string `json:"storageType,omitempty"`
+	// Storage is used when a DB resource sets none, before the default of its size tier
+	Storage *resource.Quantity `json:"storage,omitempty"`
+	// HA makes every database of the class multi-AZ
+	HA         bool            `json:"ha,omitempty"`
+	Backup     *BackupSpec     `json:"backup,omitempty"`
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+	// AllowedSizes restricts the sizes, tier names or instance classes, of the
+	// class, any size of the catalogue is allowed if it is empty
+	AllowedSizes []string `json:"allowedSizes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=postgresdbclasses
+// PostgresDBClassList is a list of PostgresDBClass resources
+type PostgresDBClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PostgresDBClass `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=postgresdbquotas,shortName=pgdbquota,singular=postgresdbquota
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Databases",type="integer",JSONPath=".status.used.databases"
+// +kubebuilder:printcolumn:name="Storage",type="string",JSONPath=".status.used.storage"
+// +kubebuilder:printcolumn:name="HA",type="integer",JSONPath=".status.used.ha"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PostgresDBQuota limits the databases a namespace may provision
+type PostgresDBQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PostgresDBQuotaSpec   `json:"spec"`
+	Status            PostgresDBQuotaStatus `json:"status"`
+}
+
+// PostgresDBQuotaSpec holds the limits of a quota, unset limits are not enforced
+type PostgresDBQuotaSpec struct {
+	// +kubebuilder:validation:Minimum=0
+	MaxDatabases *int64 `json:"maxDatabases,omitempty"`
+	// MaxSize is the largest size, a tier name or instance class, databases may
+	// use. Instance classes are compared by their size, eg. large < xlarge < 2xlarge.
+	MaxSize string `json:"maxSize,omitempty"`
+	// MaxStorage is the total storage allocated to the databases of the namespace
+	MaxStorage *resource.Quantity `json:"maxStorage,omitempty"`
+	// MaxHA is the number of multi-AZ databases
+	// +kubebuilder:validation:Minimum=0
+	MaxHA *int64 `json:"maxHA,omitempty"`
+}
+
+// PostgresDBQuotaStatus reports the current usage of the namespace
+type PostgresDBQuotaStatus struct {
+	Used        PostgresDBQuotaUsage `json:"used"`
+	LastUpdated metav1.Time          `json:"lastUpdated,omitempty"`
+}
+
+// PostgresDBQuotaUsage is the usage counted against a quota
+type PostgresDBQuotaUsage struct {
+	Databases int64             `json:"databases"`
+	Storage   resource.Quantity `json:"storage"`
+	HA        int64             `json:"ha"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=postgresdbquotas
+// PostgresDBQuotaList is a list of PostgresDBQuota resources
+type PostgresDBQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PostgresDBQuota `json:"items"`
+}
+
+const (
+	// SnapshotCreating is the phase of a snapshot while RDS takes it
+	SnapshotCreating = "Creating"
+	// SnapshotCopying is the phase of a snapshot while it is copied under spec.kmsKeyId
+	SnapshotCopying = "Copying"
+	// SnapshotAvailable is the phase of a snapshot that can be restored
+	SnapshotAvailable = "Available"
+	// SnapshotFailed is the phase of a snapshot that will not become available
+	SnapshotFailed = "Failed"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=postgresdbsnapshots,shortName=pgdbsnap,singular=postgresdbsnapshot
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="DB",type="string",JSONPath=".spec.postgresDB"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Snapshot",type="string",JSONPath=".status.snapshotId"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// PostgresDBSnapshot is a manual snapshot of the database of a PostgresDB
+type PostgresDBSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PostgresDBSnapshotSpec   `json:"spec"`
+	Status            PostgresDBSnapshotStatus `json:"status"`
+}
+
+// PostgresDBSnapshotSpec says which database to snapshot and who may restore it
+type PostgresDBSnapshotSpec struct {
+	// PostgresDB is the name of the PostgresDB in the namespace of the snapshot
+	PostgresDB string `json:"postgresDB"`
+	// KMSKeyID has the snapshot copied and re-encrypted under this key, eg. a
+	// key the accounts in shareWith are allowed to use
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+	// ShareWith lists the AWS accounts allowed to restore the snapshot, or its
+	// copy. It needs a kmsKeyId unless the database is encrypted with a
+	// customer managed key.
+	ShareWith []string `json:"shareWith,omitempty"`
+}
+
+// PostgresDBSnapshotStatus is the status of a snapshot
+type PostgresDBSnapshotStatus struct {
+	// Phase is Creating, Copying, Available or Failed
+	Phase      string `json:"phase,omitempty"`
+	SnapshotID string `json:"snapshotId,omitempty"`
+	// CopyID is the snapshot re-encrypted under spec.kmsKeyId
+	CopyID  string `json:"copyId,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Region and AWSAccount are where the database was when the snapshot was taken
+	Region     string `json:"region,omitempty"`
+	AWSAccount string `json:"awsAccount,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=postgresdbsnapshots
+// PostgresDBSnapshotList is a list of PostgresDBSnapshot resources
+type PostgresDBSnapshotList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PostgresDBSnapshot `json:"items"`
+}
+
+// MasterRotatedAnnotation is set on a PostgresDB when the master password of
+// its database was rotated, the operator rewrites its secrets
+const MasterRotatedAnnotation = "postgresdb.myob.com/master-rotated-at"
+
+// MasterRotationFinalizer holds back the deletion of a granted access request
+// until the master password it revealed is rotated
+const MasterRotationFinalizer = "postgresdb.myob.com/rotate-master-password"
+
+const (
+	// AccessGranted is the phase of an access request while its secret reveals the master credential
+	AccessGranted = "Granted"
+	// AccessRotating is the phase of an expired access request while the master password is replaced
+	AccessRotating = "Rotating"
+	// AccessExpired is the phase of an access request once the revealed password no longer works
+	AccessExpired = "Expired"
+	// AccessDenied is the phase of an access request that was not granted
+	AccessDenied = "Denied"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:path=postgresdbaccessrequests,shortName=pgdbaccess,singular=postgresdbaccessrequest
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="DB",type="string",JSONPath=".spec.postgresDB"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Secret",type="string",JSONPath=".status.secretName"
+// +kubebuilder:printcolumn:name="Expires",type="date",JSONPath=".status.expiresAt"
+
+// PostgresDBAccessRequest is a break-glass request revealing the master
+// credential of a PostgresDB for a limited time. The master password is
+// rotated once the request expires.
+type PostgresDBAccessRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              PostgresDBAccessRequestSpec   `json:"spec"`
+	Status            PostgresDBAccessRequestStatus `json:"status"`
+}
+
+// PostgresDBAccessRequestSpec says which master credential to reveal, why and for how long
+type PostgresDBAccessRequestSpec struct {
+	// PostgresDB is the name of the PostgresDB in the namespace of the request
+	PostgresDB string `json:"postgresDB"`
+	// Reason is recorded in the audit log and events of the request
+	Reason string `json:"reason"`
+	// Duration the credential is revealed for, the operator's default if empty
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// PostgresDBAccessRequestStatus is the status of an access request
+type PostgresDBAccessRequestStatus struct {
+	// Phase is Granted, Rotating, Expired or Denied
+	Phase string `json:"phase,omitempty"`
+	// SecretName is the secret holding the master credential while granted
+	SecretName string       `json:"secretName,omitempty"`
+	GrantedAt  *metav1.Time `json:"grantedAt,omitempty"`
+	ExpiresAt  *metav1.Time `json:"expiresAt,omitempty"`
+	// Rotated is true once the revealed master password was replaced
+	Rotated bool   `json:"rotated,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +resource:path=postgresdbaccessrequests
+// PostgresDBAccessRequestList is a list of PostgresDBAccessRequest resources
+type PostgresDBAccessRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []PostgresDBAccessRequest `json:"items"`
+}
@@ -0,0 +1,119 @@
+package awsclient
+
+import (
+	"expvar"
+	"fmt"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/golang/glog"
+)
+
+// PermissionChecks is published as the aws_permission_checks expvar, it is
+// 1 for every IAM action the startup check found granted and 0 if missing
+var PermissionChecks = expvar.NewMap("aws_permission_checks")
+
+// CheckedClients are the clients of the operator's own region and account the
+// startup check uses
+type CheckedClients interface {
+	RDS(loc database.Location) (rdsiface.RDSAPI, error)
+	EC2(loc database.Location) (ec2iface.EC2API, error)
+	STS(loc database.Location) (stsiface.STSAPI, error)
+}
+
+// permission is a harmless call that fails if the operator lacks an action
+type permission struct {
+	action string
+	call   func() error
+}
+
+// CheckPermissions makes sure the operator's credentials work and calls a
+// describe for each IAM action it cannot create databases without. It returns
+// an error naming every missing action.
+func CheckPermissions(c CheckedClients, cfg *config.Config) error {
+	stsClient, err := c.STS(database.Location{})
+	if err != nil {
+		return err
+	}
+	rdsClient, err := c.RDS(database.Location{})
+	if err != nil {
+		return err
+	}
+	ec2Client, err := c.EC2(database.Location{})
+	if err != nil {
+		return err
+	}
+
+	identity, err := stsClient.GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		return fmt.Errorf("invalid aws credentials: %v", err)
+	}
+	arn := aws.StringValue(identity.Arn)
+	glog.Infof("running as %s", arn)
+
+	permissions := []permission{
+		{"rds:DescribeDBInstances", func() error {
+			_, err := rdsClient.DescribeDBInstances(&awsrds.DescribeDBInstancesInput{MaxRecords: aws.Int64(20)})
+			return err
+		}},
+	}
+	if cfg.AWS.SubnetGroup != "" {
+		permissions = append(permissions, permission{"rds:DescribeDBSubnetGroups", func() error {
+			_, err := rdsClient.DescribeDBSubnetGroups(&awsrds.DescribeDBSubnetGroupsInput{DBSubnetGroupName: aws.String(cfg.AWS.SubnetGroup)})
+			return err
+		}})
+	}
+	if len(cfg.AWS.SecurityGroupIDs) > 0 {
+		permissions = append(permissions, permission{"ec2:DescribeSecurityGroups", func() error {
+			_, err := ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{GroupIds: aws.StringSlice(cfg.AWS.SecurityGroupIDs)})
+			return err
+		}})
+	}
+
+	var missing []string
+	for _, p := range permissions {
+		err := p.call()
+		if isAccessDenied(err) {
+			missing = append(missing, p.action)
+			PermissionChecks.Set(p.action, intVar(0))
+			continue
+		}
+		PermissionChecks.Set(p.action, intVar(1))
+		// anything but a denial, eg. a missing subnet group, is for the database to report
+		if err != nil {
+			glog.Warningf("unable to check %s: %v", p.action, err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s is missing IAM permissions: %s", arn, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func isAccessDenied(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+	switch awsErr.Code() {
+	case "AccessDenied", "AccessDeniedException", "UnauthorizedOperation":
+		return true
+	}
+	return false
+}
+
+func intVar(v int64) *expvar.Int {
+	i := &expvar.Int{}
+	i.Set(v)
+	return i
+}
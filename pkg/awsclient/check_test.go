@@ -0,0 +1,70 @@
+package awsclient
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSTS struct {
+	stsiface.STSAPI
+}
+
+func (fakeSTS) GetCallerIdentity(*sts.GetCallerIdentityInput) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Arn: aws.String("arn:aws:sts::123456789012:assumed-role/operator/ops-kube-db-operator")}, nil
+}
+
+type fakeRDS struct {
+	rdsiface.RDSAPI
+	err error
+}
+
+func (f fakeRDS) DescribeDBInstances(*awsrds.DescribeDBInstancesInput) (*awsrds.DescribeDBInstancesOutput, error) {
+	return &awsrds.DescribeDBInstancesOutput{}, f.err
+}
+
+func (f fakeRDS) DescribeDBSubnetGroups(*awsrds.DescribeDBSubnetGroupsInput) (*awsrds.DescribeDBSubnetGroupsOutput, error) {
+	return nil, awserr.New(awsrds.ErrCodeDBSubnetGroupNotFoundFault, "not found", nil)
+}
+
+type fakeEC2 struct {
+	ec2iface.EC2API
+	err error
+}
+
+func (f fakeEC2) DescribeSecurityGroups(*ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return &ec2.DescribeSecurityGroupsOutput{}, f.err
+}
+
+func checkConfig() *config.Config {
+	c := config.Default()
+	c.AWS.SubnetGroup = "subnets"
+	c.AWS.SecurityGroupIDs = []string{"sg-1"}
+	return c
+}
+
+func TestCheckPermissions_Granted(t *testing.T) {
+	err := CheckPermissions(Fixed{RDSAPI: fakeRDS{}, EC2API: fakeEC2{}, STSAPI: fakeSTS{}}, checkConfig())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "1", PermissionChecks.Get("rds:DescribeDBSubnetGroups").String())
+}
+
+func TestCheckPermissions_Missing(t *testing.T) {
+	denied := awserr.New("UnauthorizedOperation", "not authorized", nil)
+	err := CheckPermissions(Fixed{RDSAPI: fakeRDS{}, EC2API: fakeEC2{err: denied}, STSAPI: fakeSTS{}}, checkConfig())
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "arn:aws:sts::123456789012:assumed-role/operator/ops-kube-db-operator is missing IAM permissions: ec2:DescribeSecurityGroups", err.Error())
+	assert.Equal(t, "0", PermissionChecks.Get("ec2:DescribeSecurityGroups").String())
+	assert.Equal(t, "1", PermissionChecks.Get("rds:DescribeDBInstances").String())
+}
@@ -0,0 +1,171 @@
+package awsclient
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// SessionName is the name of the role sessions of the operator
+	SessionName = "ops-kube-db-operator"
+
+	// expiryWindow is how long before they expire credentials are refreshed
+	expiryWindow = time.Minute
+)
+
+// Credentials configures how the operator gets its own AWS credentials. The
+// default credential chain is used if it is empty, a web identity token, eg.
+// of an IAM role for service accounts, replaces the chain if set and the
+// operator then assumes AssumeRoleARN if set.
+type Credentials struct {
+	WebIdentityTokenFile string
+	WebIdentityRoleARN   string
+	AssumeRoleARN        string
+	ExternalID           string
+	// SessionTags are passed to AssumeRole, the role has to allow sts:TagSession
+	SessionTags map[string]string
+}
+
+// ParseSessionTags parses comma separated key=value session tags
+func ParseSessionTags(s string) (map[string]string, error) {
+	tags := map[string]string{}
+	for _, kv := range strings.Split(s, ",") {
+		kv = strings.TrimSpace(kv)
+		if kv == "" {
+			continue
+		}
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid session tag %q, expected key=value", kv)
+		}
+		tags[parts[0]] = parts[1]
+	}
+	return tags, nil
+}
+
+// Validate returns an error if the credentials are incomplete
+func (c Credentials) Validate() error {
+	if (c.WebIdentityTokenFile == "") != (c.WebIdentityRoleARN == "") {
+		return fmt.Errorf("a web identity needs both a token file and a role arn")
+	}
+	if c.AssumeRoleARN == "" && (c.ExternalID != "" || len(c.SessionTags) > 0) {
+		return fmt.Errorf("an external id and session tags need a role to assume")
+	}
+	return nil
+}
+
+// session returns a session of the region with the operator's credentials,
+// they are refreshed before they expire
+func (c Credentials) session(region string) (*session.Session, error) {
+	s, err := session.NewSession(aws.NewConfig().WithRegion(region))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.WebIdentityTokenFile != "" {
+		// the token is the credential, the call to sts is not signed
+		anonymous := s.Copy(aws.NewConfig().WithCredentials(credentials.AnonymousCredentials))
+		s = s.Copy(aws.NewConfig().WithCredentials(credentials.NewCredentials(&webIdentityProvider{
+			client:    sts.New(anonymous),
+			roleARN:   c.WebIdentityRoleARN,
+			tokenFile: c.WebIdentityTokenFile,
+		})))
+	}
+
+	if c.AssumeRoleARN != "" {
+		client := sts.New(s)
+		if len(c.SessionTags) > 0 {
+			client.Handlers.Build.PushBack(sessionTagsHandler(c.SessionTags))
+		}
+		creds := stscreds.NewCredentialsWithClient(client, c.AssumeRoleARN, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = SessionName
+			p.ExpiryWindow = expiryWindow
+			if c.ExternalID != "" {
+				p.ExternalID = aws.String(c.ExternalID)
+			}
+		})
+		s = s.Copy(aws.NewConfig().WithCredentials(creds))
+	}
+	return s, nil
+}
+
+// webIdentityAssumer is the part of the sts api the webIdentityProvider uses
+type webIdentityAssumer interface {
+	AssumeRoleWithWebIdentity(*sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error)
+}
+
+// webIdentityProvider exchanges the token in a file for the credentials of a
+// role, the file is read again on every refresh as the token is rotated
+type webIdentityProvider struct {
+	credentials.Expiry
+	client    webIdentityAssumer
+	roleARN   string
+	tokenFile string
+}
+
+// Retrieve assumes the role with the current token
+func (p *webIdentityProvider) Retrieve() (credentials.Value, error) {
+	token, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("unable to read web identity token: %v", err)
+	}
+
+	out, err := p.client.AssumeRoleWithWebIdentity(&sts.AssumeRoleWithWebIdentityInput{
+		RoleArn:          aws.String(p.roleARN),
+		RoleSessionName:  aws.String(SessionName),
+		WebIdentityToken: aws.String(strings.TrimSpace(string(token))),
+	})
+	if err != nil {
+		return credentials.Value{}, fmt.Errorf("unable to assume role %s with web identity: %v", p.roleARN, err)
+	}
+
+	p.SetExpiration(aws.TimeValue(out.Credentials.Expiration), expiryWindow)
+	return credentials.Value{
+		AccessKeyID:     aws.StringValue(out.Credentials.AccessKeyId),
+		SecretAccessKey: aws.StringValue(out.Credentials.SecretAccessKey),
+		SessionToken:    aws.StringValue(out.Credentials.SessionToken),
+		ProviderName:    "WebIdentityProvider",
+	}, nil
+}
+
+// sessionTagsHandler adds session tags to AssumeRole requests, the sdk's
+// AssumeRoleInput predates them
+func sessionTagsHandler(tags map[string]string) func(*request.Request) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return func(r *request.Request) {
+		if r.Error != nil || r.Operation.Name != "AssumeRole" || r.Body == nil {
+			return
+		}
+		raw, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			r.Error = err
+			return
+		}
+		body, err := url.ParseQuery(string(raw))
+		if err != nil {
+			r.Error = err
+			return
+		}
+		for i, k := range keys {
+			body.Set(fmt.Sprintf("Tags.member.%d.Key", i+1), k)
+			body.Set(fmt.Sprintf("Tags.member.%d.Value", i+1), tags[k])
+		}
+		r.SetBufferBody([]byte(body.Encode()))
+	}
+}
@@ -0,0 +1,100 @@
+package awsclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeWebIdentity struct {
+	tokens []string
+}
+
+func (f *fakeWebIdentity) AssumeRoleWithWebIdentity(in *sts.AssumeRoleWithWebIdentityInput) (*sts.AssumeRoleWithWebIdentityOutput, error) {
+	f.tokens = append(f.tokens, aws.StringValue(in.WebIdentityToken))
+	return &sts.AssumeRoleWithWebIdentityOutput{Credentials: &sts.Credentials{
+		AccessKeyId:     aws.String("AKID"),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("session"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func TestWebIdentityProvider_ReadsRotatedToken(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "token")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "token")
+	ioutil.WriteFile(file, []byte("first\n"), 0600)
+
+	client := &fakeWebIdentity{}
+	p := &webIdentityProvider{client: client, roleARN: "arn:aws:iam::123456789012:role/operator", tokenFile: file}
+
+	v, err := p.Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "AKID", v.AccessKeyID)
+	assert.False(t, p.IsExpired())
+
+	ioutil.WriteFile(file, []byte("second"), 0600)
+	_, err = p.Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first", "second"}, client.tokens)
+}
+
+func TestWebIdentityProvider_MissingToken(t *testing.T) {
+	p := &webIdentityProvider{client: &fakeWebIdentity{}, tokenFile: "/does/not/exist"}
+	_, err := p.Retrieve()
+	assert.NotNil(t, err)
+}
+
+func TestSessionTagsHandler(t *testing.T) {
+	var body url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		body = r.PostForm
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	s := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("ap-southeast-2").
+		WithEndpoint(server.URL).
+		WithMaxRetries(0).
+		WithCredentials(credentials.NewStaticCredentials("AKID", "secret", ""))))
+	client := sts.New(s)
+	client.Handlers.Build.PushBack(sessionTagsHandler(map[string]string{"team": "platform", "cluster": "syd"}))
+
+	client.AssumeRole(&sts.AssumeRoleInput{RoleArn: aws.String("arn:aws:iam::123456789012:role/operator"), RoleSessionName: aws.String(SessionName)})
+
+	assert.Equal(t, "AssumeRole", body.Get("Action"))
+	assert.Equal(t, "cluster", body.Get("Tags.member.1.Key"))
+	assert.Equal(t, "syd", body.Get("Tags.member.1.Value"))
+	assert.Equal(t, "team", body.Get("Tags.member.2.Key"))
+	assert.Equal(t, "platform", body.Get("Tags.member.2.Value"))
+}
+
+func TestParseSessionTags(t *testing.T) {
+	tags, err := ParseSessionTags("team=platform, cluster=syd")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"team": "platform", "cluster": "syd"}, tags)
+
+	_, err = ParseSessionTags("team")
+	assert.NotNil(t, err)
+}
+
+func TestCredentials_Validate(t *testing.T) {
+	assert.Nil(t, Credentials{}.Validate())
+	assert.Nil(t, Credentials{AssumeRoleARN: "arn", ExternalID: "id", SessionTags: map[string]string{"a": "b"}}.Validate())
+	assert.NotNil(t, Credentials{WebIdentityTokenFile: "/token"}.Validate())
+	assert.NotNil(t, Credentials{ExternalID: "id"}.Validate())
+}
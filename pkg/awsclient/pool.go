@@ -0,0 +1,222 @@
+package awsclient
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/awsclient/secretsmanager"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/aws/aws-sdk-go/service/rds/rdsutils"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+)
+
+// Pool keeps the AWS clients of every region and account databases are
+// created in. Accounts are reached by assuming the role of the account in
+// the operator config with the operator's own credentials.
+type Pool struct {
+	config      config.Getter
+	credentials Credentials
+
+	// RDSLimiter is waited on before every request of the RDS clients of
+	// every location, retries included, if set before the first client
+	RDSLimiter Limiter
+
+	lock    sync.Mutex
+	clients map[key]*clients
+}
+
+// key identifies the clients of a location, the role is part of it so a
+// changed role in the config is picked up without a restart
+type key struct {
+	region     string
+	roleARN    string
+	externalID string
+}
+
+type clients struct {
+	rds rdsiface.RDSAPI
+	ec2 ec2iface.EC2API
+	kms kmsiface.KMSAPI
+	sts stsiface.STSAPI
+	sqs sqsiface.SQSAPI
+	sm  secretsmanager.SecretsManagerAPI
+	// region and credentials sign the IAM auth tokens of databases
+	region      string
+	credentials *credentials.Credentials
+}
+
+// Limiter limits the rate of AWS requests, eg. an rds.TokenBucket
+type Limiter interface {
+	// Wait returns once the next request may be sent
+	Wait()
+}
+
+// NewPool returns a Pool of the regions and accounts of the config
+func NewPool(c config.Getter, creds Credentials) *Pool {
+	return &Pool{config: c, credentials: creds, clients: map[key]*clients{}}
+}
+
+// RDS returns the RDS client of a location
+func (p *Pool) RDS(loc database.Location) (rdsiface.RDSAPI, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.rds, nil
+}
+
+// EC2 returns the EC2 client of a location
+func (p *Pool) EC2(loc database.Location) (ec2iface.EC2API, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.ec2, nil
+}
+
+// KMS returns the KMS client of a location
+func (p *Pool) KMS(loc database.Location) (kmsiface.KMSAPI, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.kms, nil
+}
+
+// STS returns the STS client of a location
+func (p *Pool) STS(loc database.Location) (stsiface.STSAPI, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.sts, nil
+}
+
+// SQS returns the SQS client of a location
+func (p *Pool) SQS(loc database.Location) (sqsiface.SQSAPI, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.sqs, nil
+}
+
+// SecretsManager returns the Secrets Manager client of a location
+func (p *Pool) SecretsManager(loc database.Location) (secretsmanager.SecretsManagerAPI, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return nil, err
+	}
+	return c.sm, nil
+}
+
+// AuthToken returns an IAM auth token for user of the database at endpoint,
+// host:port, in a location. Tokens are valid for 15 minutes.
+func (p *Pool) AuthToken(loc database.Location, endpoint, user string) (string, error) {
+	c, err := p.get(loc)
+	if err != nil {
+		return "", err
+	}
+	return rdsutils.BuildAuthToken(endpoint, c.region, user, c.credentials)
+}
+
+func (p *Pool) get(loc database.Location) (*clients, error) {
+	cfg := p.config.Get()
+	k := key{region: loc.Region}
+	if k.region == "" {
+		k.region = cfg.AWS.Region
+	}
+	if loc.Account != "" {
+		account, ok := cfg.Account(loc.Account)
+		if !ok {
+			return nil, fmt.Errorf("aws account %s is not configured", loc.Account)
+		}
+		k.roleARN = account.RoleARN
+		k.externalID = account.ExternalID
+	}
+	if _, ok := cfg.Target(k.region, loc.Account); !ok {
+		return nil, fmt.Errorf("no aws target is configured for region %s and account %q", k.region, loc.Account)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if c, ok := p.clients[k]; ok {
+		return c, nil
+	}
+
+	s, err := p.credentials.session(k.region)
+	if err != nil {
+		return nil, err
+	}
+	if k.roleARN != "" {
+		creds := stscreds.NewCredentials(s, k.roleARN, func(r *stscreds.AssumeRoleProvider) {
+			if k.externalID != "" {
+				r.ExternalID = aws.String(k.externalID)
+			}
+			r.RoleSessionName = SessionName
+			r.ExpiryWindow = expiryWindow
+		})
+		s = s.Copy(aws.NewConfig().WithCredentials(creds))
+	}
+
+	rdsClient := rds.New(s)
+	if p.RDSLimiter != nil {
+		rdsClient.Handlers.Send.PushFrontNamed(limiterHandler(p.RDSLimiter))
+	}
+
+	c := &clients{rds: rdsClient, ec2: ec2.New(s), kms: kms.New(s), sts: sts.New(s), sqs: sqs.New(s), sm: secretsmanager.New(s), region: k.region, credentials: s.Config.Credentials}
+	p.clients[k] = c
+	return c, nil
+}
+
+// limiterHandler waits for the limiter before a request is sent
+func limiterHandler(l Limiter) request.NamedHandler {
+	return request.NamedHandler{
+		Name: "awsclient.Limiter",
+		Fn: func(*request.Request) {
+			l.Wait()
+		},
+	}
+}
+
+// Fixed returns the same clients whatever the location
+type Fixed struct {
+	RDSAPI rdsiface.RDSAPI
+	EC2API ec2iface.EC2API
+	KMSAPI kmsiface.KMSAPI
+	STSAPI stsiface.STSAPI
+}
+
+// RDS returns the RDS client
+func (f Fixed) RDS(loc database.Location) (rdsiface.RDSAPI, error) {
+	return f.RDSAPI, nil
+}
+
+// EC2 returns the EC2 client
+func (f Fixed) EC2(loc database.Location) (ec2iface.EC2API, error) {
+	return f.EC2API, nil
+}
+
+// KMS returns the KMS client
+func (f Fixed) KMS(loc database.Location) (kmsiface.KMSAPI, error) {
+	return f.KMSAPI, nil
+}
+
+// STS returns the STS client
+func (f Fixed) STS(loc database.Location) (stsiface.STSAPI, error) {
+	return f.STSAPI, nil
+}
@@ -0,0 +1,86 @@
+package awsclient
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPool() *Pool {
+	c := config.Default()
+	c.AWS.SubnetGroup = "subnets"
+	c.AWS.SecurityGroupIDs = []string{"sg-1"}
+	c.AWS.Accounts = []config.Account{{Name: "dr", RoleARN: "arn:aws:iam::123456789012:role/db-operator"}}
+	c.AWS.Targets = []config.Target{{Region: "ap-southeast-1", Account: "dr", SubnetGroup: "dr-subnets", SecurityGroupIDs: []string{"sg-9"}}}
+	return NewPool(config.Fixed{Config: c}, Credentials{})
+}
+
+func TestPool_ReusesClients(t *testing.T) {
+	p := newPool()
+
+	local, err := p.RDS(database.Location{})
+	assert.Nil(t, err)
+	same, err := p.RDS(database.Location{Region: "ap-southeast-2"})
+	assert.Nil(t, err)
+	assert.True(t, local == same)
+
+	dr, err := p.RDS(database.Location{Region: "ap-southeast-1", Account: "dr"})
+	assert.Nil(t, err)
+	assert.False(t, local == dr)
+	assert.Len(t, p.clients, 2)
+}
+
+type countingLimiter struct {
+	waits int
+}
+
+func (l *countingLimiter) Wait() {
+	l.waits++
+}
+
+func TestPool_RDSLimiter(t *testing.T) {
+	unlimited, err := newPool().RDS(database.Location{})
+	assert.Nil(t, err)
+
+	p := newPool()
+	l := &countingLimiter{}
+	p.RDSLimiter = l
+	limited, err := p.RDS(database.Location{})
+	assert.Nil(t, err)
+
+	sends := func(c interface{}) int {
+		return c.(*rds.RDS).Handlers.Send.Len()
+	}
+	assert.Equal(t, sends(unlimited)+1, sends(limited))
+
+	limiterHandler(l).Fn(nil)
+	assert.Equal(t, 1, l.waits)
+}
+
+func TestPool_UnknownAccount(t *testing.T) {
+	_, err := newPool().EC2(database.Location{Region: "ap-southeast-1", Account: "billing"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "aws account billing is not configured", err.Error())
+}
+
+func TestPool_NoTarget(t *testing.T) {
+	_, err := newPool().KMS(database.Location{Region: "us-east-1"})
+	assert.NotNil(t, err)
+}
+
+func TestPool_AuthToken(t *testing.T) {
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+
+	token, err := newPool().AuthToken(database.Location{}, "db.rds.amazonaws.com:5432", "appuser")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(token, "db.rds.amazonaws.com:5432?Action=connect&DBUser=appuser"), token)
+	assert.Contains(t, token, "X-Amz-Expires=900")
+}
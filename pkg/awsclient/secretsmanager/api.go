@@ -0,0 +1,116 @@
+package secretsmanager
+
+import "time"
+
+// CreateSecret creates a secret with its first value
+func (c *SecretsManager) CreateSecret(input *CreateSecretInput) (*CreateSecretOutput, error) {
+	out := &CreateSecretOutput{}
+	return out, c.send("CreateSecret", input, out)
+}
+
+// GetSecretValue returns the current value of a secret
+func (c *SecretsManager) GetSecretValue(input *GetSecretValueInput) (*GetSecretValueOutput, error) {
+	out := &GetSecretValueOutput{}
+	return out, c.send("GetSecretValue", input, out)
+}
+
+// PutSecretValue stores a new value of a secret, it becomes the current value
+func (c *SecretsManager) PutSecretValue(input *PutSecretValueInput) (*PutSecretValueOutput, error) {
+	out := &PutSecretValueOutput{}
+	return out, c.send("PutSecretValue", input, out)
+}
+
+// TagResource adds tags to a secret, replacing the values of existing keys
+func (c *SecretsManager) TagResource(input *TagResourceInput) (*TagResourceOutput, error) {
+	out := &TagResourceOutput{}
+	return out, c.send("TagResource", input, out)
+}
+
+// DeleteSecret schedules the deletion of a secret after its recovery window
+func (c *SecretsManager) DeleteSecret(input *DeleteSecretInput) (*DeleteSecretOutput, error) {
+	out := &DeleteSecretOutput{}
+	return out, c.send("DeleteSecret", input, out)
+}
+
+// Tag is a tag of a secret
+type Tag struct {
+	_ struct{} `type:"structure"`
+
+	Key   *string `type:"string"`
+	Value *string `type:"string"`
+}
+
+type CreateSecretInput struct {
+	_ struct{} `type:"structure"`
+
+	Name         *string `type:"string" required:"true"`
+	Description  *string `type:"string"`
+	KmsKeyId     *string `type:"string"`
+	SecretString *string `type:"string"`
+	Tags         []*Tag  `type:"list"`
+}
+
+type CreateSecretOutput struct {
+	_ struct{} `type:"structure"`
+
+	ARN       *string `type:"string"`
+	Name      *string `type:"string"`
+	VersionId *string `type:"string"`
+}
+
+type GetSecretValueInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId *string `type:"string" required:"true"`
+}
+
+type GetSecretValueOutput struct {
+	_ struct{} `type:"structure"`
+
+	ARN          *string `type:"string"`
+	Name         *string `type:"string"`
+	SecretString *string `type:"string"`
+	VersionId    *string `type:"string"`
+}
+
+type PutSecretValueInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId     *string `type:"string" required:"true"`
+	SecretString *string `type:"string"`
+}
+
+type PutSecretValueOutput struct {
+	_ struct{} `type:"structure"`
+
+	ARN       *string `type:"string"`
+	Name      *string `type:"string"`
+	VersionId *string `type:"string"`
+}
+
+type TagResourceInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId *string `type:"string" required:"true"`
+	Tags     []*Tag  `type:"list" required:"true"`
+}
+
+type TagResourceOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+type DeleteSecretInput struct {
+	_ struct{} `type:"structure"`
+
+	SecretId                   *string `type:"string" required:"true"`
+	ForceDeleteWithoutRecovery *bool   `type:"boolean"`
+	RecoveryWindowInDays       *int64  `type:"long"`
+}
+
+type DeleteSecretOutput struct {
+	_ struct{} `type:"structure"`
+
+	ARN          *string    `type:"string"`
+	Name         *string    `type:"string"`
+	DeletionDate *time.Time `type:"timestamp" timestampFormat:"unix"`
+}
@@ -0,0 +1,63 @@
+package secretsmanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(h http.HandlerFunc) (*SecretsManager, func()) {
+	s := httptest.NewServer(h)
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("ap-southeast-2").
+		WithEndpoint(s.URL).
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", "")).
+		WithMaxRetries(0)))
+	return New(sess), s.Close
+}
+
+func TestCreateSecret(t *testing.T) {
+	var target string
+	var body map[string]interface{}
+	c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		target = r.Header.Get("X-Amz-Target")
+		raw, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(raw, &body)
+		w.Write([]byte(`{"ARN":"arn:aws:secretsmanager:ap-southeast-2:123456789012:secret:db-AbCdEf","Name":"db"}`))
+	})
+	defer done()
+
+	out, err := c.CreateSecret(&CreateSecretInput{
+		Name:         aws.String("db"),
+		SecretString: aws.String("{}"),
+		Tags:         []*Tag{{Key: aws.String("db-name"), Value: aws.String("orders")}},
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "secretsmanager.CreateSecret", target)
+	assert.Equal(t, "db", body["Name"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"Key": "db-name", "Value": "orders"}}, body["Tags"])
+	assert.Equal(t, "arn:aws:secretsmanager:ap-southeast-2:123456789012:secret:db-AbCdEf", *out.ARN)
+}
+
+func TestGetSecretValue_NotFound(t *testing.T) {
+	c, done := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type":"ResourceNotFoundException","Message":"Secrets Manager can't find the specified secret."}`))
+	})
+	defer done()
+
+	_, err := c.GetSecretValue(&GetSecretValueInput{SecretId: aws.String("db")})
+
+	aerr, ok := err.(awserr.Error)
+	assert.True(t, ok)
+	assert.Equal(t, ErrCodeResourceNotFoundException, aerr.Code())
+}
@@ -0,0 +1,74 @@
+// Package secretsmanager is the part of the AWS Secrets Manager API the
+// operator uses. The vendored aws-sdk-go predates the service, the client is
+// built the way the SDK builds its JSON-RPC clients so it can be swapped for
+// the SDK package once it is upgraded.
+package secretsmanager
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+const (
+	// ServiceName is the endpoint prefix of the service
+	ServiceName = "secretsmanager"
+
+	// ErrCodeResourceNotFoundException is returned for secrets that do not exist
+	ErrCodeResourceNotFoundException = "ResourceNotFoundException"
+)
+
+// SecretsManagerAPI is the interface of the SecretsManager client
+type SecretsManagerAPI interface {
+	CreateSecret(*CreateSecretInput) (*CreateSecretOutput, error)
+	GetSecretValue(*GetSecretValueInput) (*GetSecretValueOutput, error)
+	PutSecretValue(*PutSecretValueInput) (*PutSecretValueOutput, error)
+	TagResource(*TagResourceInput) (*TagResourceOutput, error)
+	DeleteSecret(*DeleteSecretInput) (*DeleteSecretOutput, error)
+}
+
+// SecretsManager is a client of AWS Secrets Manager
+type SecretsManager struct {
+	*client.Client
+}
+
+var _ SecretsManagerAPI = (*SecretsManager)(nil)
+
+// New returns a SecretsManager client of the session p
+func New(p client.ConfigProvider, cfgs ...*aws.Config) *SecretsManager {
+	c := p.ClientConfig(ServiceName, cfgs...)
+	svc := &SecretsManager{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   ServiceName,
+				SigningName:   c.SigningName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2017-10-17",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "secretsmanager",
+			},
+			c.Handlers,
+		),
+	}
+
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+	return svc
+}
+
+func (c *SecretsManager) send(name string, params, data interface{}) error {
+	op := &request.Operation{
+		Name:       name,
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}
+	return c.NewRequest(op, params, data).Send()
+}
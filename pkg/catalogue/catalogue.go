@@ -0,0 +1,121 @@
+package catalogue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// Tier is an abstract database size and the RDS instance it maps to
+type Tier struct {
+	InstanceClass string `json:"instanceClass"`
+	// DefaultStorage is the storage in GiB used when a PostgresDB does not set any
+	DefaultStorage int64 `json:"defaultStorage,omitempty"`
+	// MaxConnections is the connection limit databases of this tier are sized for
+	MaxConnections int64 `json:"maxConnections,omitempty"`
+	// Namespaces the tier may be used in, all namespaces if empty
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// Catalogue lists the size tiers and explicit instance classes a cluster offers
+type Catalogue struct {
+	Tiers map[string]Tier `json:"tiers"`
+	// Classes are instance classes that may be requested directly, the classes
+	// of the tiers are always allowed
+	Classes []string `json:"classes,omitempty"`
+}
+
+// Selection is the result of resolving a size against the catalogue
+type Selection struct {
+	Tier           string
+	InstanceClass  string
+	DefaultStorage int64
+	MaxConnections int64
+}
+
+// Default returns the catalogue used when none is configured
+func Default() *Catalogue {
+	return &Catalogue{
+		Tiers: map[string]Tier{
+			"xsmall":  {InstanceClass: "db.t2.small"},
+			"small":   {InstanceClass: "db.t2.medium"},
+			"medium":  {InstanceClass: "db.t2.xlarge"},
+			"large":   {InstanceClass: "db.m4.large"},
+			"xlarge":  {InstanceClass: "db.m4.2xlarge"},
+			"xxlarge": {InstanceClass: "db.m4.4xlarge"},
+			"massive": {InstanceClass: "db.m4.16xlarge"},
+		},
+	}
+}
+
+// Parse reads and validates a yaml or json catalogue
+func Parse(data []byte) (*Catalogue, error) {
+	c := &Catalogue{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("unable to parse size catalogue: %v", err)
+	}
+
+	if len(c.Tiers) == 0 {
+		return nil, fmt.Errorf("size catalogue has no tiers")
+	}
+	for name, t := range c.Tiers {
+		if !isInstanceClass(t.InstanceClass) {
+			return nil, fmt.Errorf("tier %s has invalid instance class %q", name, t.InstanceClass)
+		}
+		if t.DefaultStorage < 0 || t.MaxConnections < 0 {
+			return nil, fmt.Errorf("tier %s cannot have negative defaults", name)
+		}
+	}
+	for _, class := range c.Classes {
+		if !isInstanceClass(class) {
+			return nil, fmt.Errorf("invalid instance class %q", class)
+		}
+	}
+	return c, nil
+}
+
+// Resolve looks up size, either a tier name or an allowed instance class, for
+// a database in the given namespace
+func (c *Catalogue) Resolve(size, namespace string) (*Selection, error) {
+	if t, ok := c.Tiers[size]; ok {
+		if !t.allows(namespace) {
+			return nil, fmt.Errorf("size %s is not available in namespace %s", size, namespace)
+		}
+		return &Selection{
+			Tier:           size,
+			InstanceClass:  t.InstanceClass,
+			DefaultStorage: t.DefaultStorage,
+			MaxConnections: t.MaxConnections,
+		}, nil
+	}
+
+	for _, class := range c.Classes {
+		if class == size {
+			return &Selection{InstanceClass: class}, nil
+		}
+	}
+	for _, t := range c.Tiers {
+		if t.InstanceClass == size && t.allows(namespace) {
+			return &Selection{InstanceClass: size}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported database size: %s", size)
+}
+
+func (t Tier) allows(namespace string) bool {
+	if len(t.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range t.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func isInstanceClass(class string) bool {
+	return strings.HasPrefix(class, "db.") && len(class) > len("db.")
+}
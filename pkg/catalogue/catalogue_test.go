@@ -0,0 +1,93 @@
+package catalogue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCatalogue = `
+tiers:
+  small:
+    instanceClass: db.t3.medium
+    defaultStorage: 20
+    maxConnections: 100
+  huge:
+    instanceClass: db.r5.4xlarge
+    namespaces:
+    - payments
+classes:
+- db.m5.large
+`
+
+func TestParse_HappyPath(t *testing.T) {
+	c, err := Parse([]byte(testCatalogue))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(c.Tiers))
+	assert.Equal(t, "db.t3.medium", c.Tiers["small"].InstanceClass)
+	assert.Equal(t, []string{"db.m5.large"}, c.Classes)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, data := range []string{
+		"banana",
+		"tiers: {}",
+		"tiers: {small: {instanceClass: t3.medium}}",
+		"tiers: {small: {instanceClass: db.t3.medium, defaultStorage: -1}}",
+		"tiers: {small: {instanceClass: db.t3.medium}}\nclasses: [m5.large]",
+	} {
+		_, err := Parse([]byte(data))
+		assert.NotNil(t, err, data)
+	}
+}
+
+func TestResolve_Tier(t *testing.T) {
+	c, _ := Parse([]byte(testCatalogue))
+
+	sel, err := c.Resolve("small", "any")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Selection{Tier: "small", InstanceClass: "db.t3.medium", DefaultStorage: 20, MaxConnections: 100}, sel)
+}
+
+func TestResolve_TierNamespaceRestricted(t *testing.T) {
+	c, _ := Parse([]byte(testCatalogue))
+
+	_, err := c.Resolve("huge", "other")
+	assert.NotNil(t, err)
+
+	sel, err := c.Resolve("huge", "payments")
+	assert.Nil(t, err)
+	assert.Equal(t, "db.r5.4xlarge", sel.InstanceClass)
+
+	_, err = c.Resolve("db.r5.4xlarge", "other")
+	assert.NotNil(t, err)
+}
+
+func TestResolve_Class(t *testing.T) {
+	c, _ := Parse([]byte(testCatalogue))
+
+	sel, err := c.Resolve("db.m5.large", "any")
+	assert.Nil(t, err)
+	assert.Equal(t, &Selection{InstanceClass: "db.m5.large"}, sel)
+
+	sel, err = c.Resolve("db.t3.medium", "any")
+	assert.Nil(t, err)
+	assert.Equal(t, "", sel.Tier)
+
+	_, err = c.Resolve("db.x1.32xlarge", "any")
+	assert.NotNil(t, err)
+}
+
+func TestDefault_MatchesLegacyClasses(t *testing.T) {
+	c := Default()
+
+	sel, err := c.Resolve("db.m4.large", "any")
+	assert.Nil(t, err)
+	assert.Equal(t, "db.m4.large", sel.InstanceClass)
+
+	sel, err = c.Resolve("xsmall", "any")
+	assert.Nil(t, err)
+	assert.Equal(t, "db.t2.small", sel.InstanceClass)
+}
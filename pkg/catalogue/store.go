@@ -0,0 +1,107 @@
+package catalogue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ConfigMapKey is the key of the catalogue in the ConfigMap data
+const ConfigMapKey = "catalogue.yaml"
+
+// Store holds the current catalogue and keeps it in sync with a ConfigMap
+type Store struct {
+	mu      sync.RWMutex
+	current *Catalogue
+
+	namespace  string
+	name       string
+	controller cache.Controller
+}
+
+// NewStore returns a Store serving the default catalogue until the ConfigMap
+// namespace/name is loaded
+func NewStore(client kubernetes.Interface, namespace, name string) *Store {
+	s := &Store{
+		current:   Default(),
+		namespace: namespace,
+		name:      name,
+	}
+
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return client.CoreV1().ConfigMaps(namespace).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return client.CoreV1().ConfigMaps(namespace).Watch(options)
+		},
+	}
+
+	_, s.controller = cache.NewInformer(lw, &v1.ConfigMap{}, 10*time.Minute, cache.ResourceEventHandlerFuncs{
+		AddFunc:    s.onUpdate,
+		UpdateFunc: func(old, new interface{}) { s.onUpdate(new) },
+		DeleteFunc: s.onDelete,
+	})
+	return s
+}
+
+// Get returns the current catalogue
+func (s *Store) Get() *Catalogue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Resolve resolves size against the current catalogue
+func (s *Store) Resolve(size, namespace string) (*Selection, error) {
+	return s.Get().Resolve(size, namespace)
+}
+
+func (s *Store) set(c *Catalogue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = c
+}
+
+// Run watches the ConfigMap and reloads the catalogue on every change until stopCh is closed
+func (s *Store) Run(stopCh <-chan struct{}) {
+	s.controller.Run(stopCh)
+}
+
+// HasSynced returns true once the ConfigMap has been listed
+func (s *Store) HasSynced() bool {
+	return s.controller.HasSynced()
+}
+
+func (s *Store) onUpdate(obj interface{}) {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return
+	}
+
+	c, err := Parse([]byte(cm.Data[ConfigMapKey]))
+	if err != nil {
+		// keep serving the last good catalogue
+		glog.Errorf("ignoring size catalogue %s/%s: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+
+	s.set(c)
+	glog.Infof("loaded size catalogue %s/%s with %d tiers", cm.Namespace, cm.Name, len(c.Tiers))
+}
+
+func (s *Store) onDelete(obj interface{}) {
+	glog.Warningf("size catalogue %s/%s deleted, falling back to the default catalogue", s.namespace, s.name)
+	s.set(Default())
+}
@@ -0,0 +1,54 @@
+package catalogue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newConfigMap(data string) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "postgresdb-sizes"},
+		Data:       map[string]string{ConfigMapKey: data},
+	}
+}
+
+func TestStore_DefaultsUntilLoaded(t *testing.T) {
+	s := NewStore(fake.NewSimpleClientset(), "kube-system", "postgresdb-sizes")
+
+	assert.Equal(t, Default(), s.Get())
+}
+
+func TestStore_Reload(t *testing.T) {
+	s := NewStore(fake.NewSimpleClientset(), "kube-system", "postgresdb-sizes")
+
+	s.onUpdate(newConfigMap(testCatalogue))
+	_, err := s.Resolve("db.m5.large", "any")
+	assert.Nil(t, err)
+
+	// an invalid update keeps the last good catalogue
+	s.onUpdate(newConfigMap("tiers: {}"))
+	_, err = s.Resolve("db.m5.large", "any")
+	assert.Nil(t, err)
+
+	s.onDelete(newConfigMap(""))
+	assert.Equal(t, Default(), s.Get())
+}
+
+func TestStore_RunLoadsConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset(newConfigMap(testCatalogue))
+	s := NewStore(client, "kube-system", "postgresdb-sizes")
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go s.Run(stopCh)
+
+	for i := 0; i < 100 && !s.HasSynced(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, "db.r5.4xlarge", s.Get().Tiers["huge"].InstanceClass)
+}
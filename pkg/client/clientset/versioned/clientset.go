@@ -24,6 +24,7 @@ package versioned
 import (
 	glog "github.com/golang/glog"
 	postgresdbv1alpha1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/typed/postgresdb/v1alpha1"
+	postgresdbv1beta1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/typed/postgresdb/v1beta1"
 	discovery "k8s.io/client-go/discovery"
 	rest "k8s.io/client-go/rest"
 	flowcontrol "k8s.io/client-go/util/flowcontrol"
@@ -32,8 +33,9 @@ import (
 type Interface interface {
 	Discovery() discovery.DiscoveryInterface
 	PostgresdbV1alpha1() postgresdbv1alpha1.PostgresdbV1alpha1Interface
+	PostgresdbV1beta1() postgresdbv1beta1.PostgresdbV1beta1Interface
 	// Deprecated: please explicitly pick a version if possible.
-	Postgresdb() postgresdbv1alpha1.PostgresdbV1alpha1Interface
+	Postgresdb() postgresdbv1beta1.PostgresdbV1beta1Interface
 }
 
 // Clientset contains the clients for groups. Each group has exactly one
@@ -41,6 +43,7 @@ type Interface interface {
 type Clientset struct {
 	*discovery.DiscoveryClient
 	postgresdbV1alpha1 *postgresdbv1alpha1.PostgresdbV1alpha1Client
+	postgresdbV1beta1  *postgresdbv1beta1.PostgresdbV1beta1Client
 }
 
 // PostgresdbV1alpha1 retrieves the PostgresdbV1alpha1Client
@@ -48,10 +51,15 @@ func (c *Clientset) PostgresdbV1alpha1() postgresdbv1alpha1.PostgresdbV1alpha1In
 	return c.postgresdbV1alpha1
 }
 
+// PostgresdbV1beta1 retrieves the PostgresdbV1beta1Client
+func (c *Clientset) PostgresdbV1beta1() postgresdbv1beta1.PostgresdbV1beta1Interface {
+	return c.postgresdbV1beta1
+}
+
 // Deprecated: Postgresdb retrieves the default version of PostgresdbClient.
 // Please explicitly pick a version.
-func (c *Clientset) Postgresdb() postgresdbv1alpha1.PostgresdbV1alpha1Interface {
-	return c.postgresdbV1alpha1
+func (c *Clientset) Postgresdb() postgresdbv1beta1.PostgresdbV1beta1Interface {
+	return c.postgresdbV1beta1
 }
 
 // Discovery retrieves the DiscoveryClient
@@ -74,6 +82,10 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 	if err != nil {
 		return nil, err
 	}
+	cs.postgresdbV1beta1, err = postgresdbv1beta1.NewForConfig(&configShallowCopy)
+	if err != nil {
+		return nil, err
+	}
 
 	cs.DiscoveryClient, err = discovery.NewDiscoveryClientForConfig(&configShallowCopy)
 	if err != nil {
@@ -88,6 +100,7 @@ func NewForConfig(c *rest.Config) (*Clientset, error) {
 func NewForConfigOrDie(c *rest.Config) *Clientset {
 	var cs Clientset
 	cs.postgresdbV1alpha1 = postgresdbv1alpha1.NewForConfigOrDie(c)
+	cs.postgresdbV1beta1 = postgresdbv1beta1.NewForConfigOrDie(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClientForConfigOrDie(c)
 	return &cs
@@ -97,6 +110,7 @@ func NewForConfigOrDie(c *rest.Config) *Clientset {
 func New(c rest.Interface) *Clientset {
 	var cs Clientset
 	cs.postgresdbV1alpha1 = postgresdbv1alpha1.New(c)
+	cs.postgresdbV1beta1 = postgresdbv1beta1.New(c)
 
 	cs.DiscoveryClient = discovery.NewDiscoveryClient(c)
 	return &cs
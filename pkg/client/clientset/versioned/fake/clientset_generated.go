@@ -25,6 +25,8 @@ import (
 	clientset "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
 	postgresdbv1alpha1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/typed/postgresdb/v1alpha1"
 	fakepostgresdbv1alpha1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/typed/postgresdb/v1alpha1/fake"
+	postgresdbv1beta1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/typed/postgresdb/v1beta1"
+	fakepostgresdbv1beta1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/typed/postgresdb/v1beta1/fake"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/discovery"
@@ -70,7 +72,12 @@ func (c *Clientset) PostgresdbV1alpha1() postgresdbv1alpha1.PostgresdbV1alpha1In
 	return &fakepostgresdbv1alpha1.FakePostgresdbV1alpha1{Fake: &c.Fake}
 }
 
-// Postgresdb retrieves the PostgresdbV1alpha1Client
-func (c *Clientset) Postgresdb() postgresdbv1alpha1.PostgresdbV1alpha1Interface {
-	return &fakepostgresdbv1alpha1.FakePostgresdbV1alpha1{Fake: &c.Fake}
+// PostgresdbV1beta1 retrieves the PostgresdbV1beta1Client
+func (c *Clientset) PostgresdbV1beta1() postgresdbv1beta1.PostgresdbV1beta1Interface {
+	return &fakepostgresdbv1beta1.FakePostgresdbV1beta1{Fake: &c.Fake}
+}
+
+// Postgresdb retrieves the PostgresdbV1beta1Client
+func (c *Clientset) Postgresdb() postgresdbv1beta1.PostgresdbV1beta1Interface {
+	return &fakepostgresdbv1beta1.FakePostgresdbV1beta1{Fake: &c.Fake}
 }
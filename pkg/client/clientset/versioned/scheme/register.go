@@ -23,6 +23,7 @@ package scheme
 
 import (
 	postgresdbv1alpha1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	postgresdbv1beta1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	schema "k8s.io/apimachinery/pkg/runtime/schema"
@@ -54,5 +55,5 @@ func init() {
 // correctly.
 func AddToScheme(scheme *runtime.Scheme) {
 	postgresdbv1alpha1.AddToScheme(scheme)
-
+	postgresdbv1beta1.AddToScheme(scheme)
 }
@@ -37,9 +37,9 @@ type FakePostgresDBs struct {
 	ns   string
 }
 
-var postgresdbsResource = schema.GroupVersionResource{Group: "postgresdb.myob.com", Version: "v1alpha1", Resource: "postgresdbs"}
+var postgresdbsResource = schema.GroupVersionResource{Group: "myob.com", Version: "v1alpha1", Resource: "postgresdbs"}
 
-var postgresdbsKind = schema.GroupVersionKind{Group: "postgresdb.myob.com", Version: "v1alpha1", Kind: "PostgresDB"}
+var postgresdbsKind = schema.GroupVersionKind{Group: "myob.com", Version: "v1alpha1", Kind: "PostgresDB"}
 
 // Get takes name of the postgresDB, and returns the corresponding postgresDB object, and an error if there is any.
 func (c *FakePostgresDBs) Get(name string, options v1.GetOptions) (result *v1alpha1.PostgresDB, err error) {
@@ -33,7 +33,7 @@ type PostgresdbV1alpha1Interface interface {
 	PostgresDBsGetter
 }
 
-// PostgresdbV1alpha1Client is used to interact with features provided by the postgresdb.myob.com group.
+// PostgresdbV1alpha1Client is used to interact with features provided by the myob.com group.
 type PostgresdbV1alpha1Client struct {
 	restClient rest.Interface
 }
@@ -0,0 +1,542 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// APIVersion is the only version of the configuration file understood
+	APIVersion = "operator.myob.com/v1"
+	// Kind of the configuration file
+	Kind = "OperatorConfig"
+
+	// AnnotationPrefix prefixes the namespace annotations overriding the configuration
+	AnnotationPrefix = "postgresdb.myob.com/"
+)
+
+// Config is the operator configuration
+type Config struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	AWS        AWS      `json:"aws"`
+	Defaults   Defaults `json:"defaults"`
+	Worker     Worker   `json:"worker"`
+	Exporter   Exporter `json:"exporter"`
+	Secrets    Secrets  `json:"secrets"`
+	IAMAuth    IAMAuth  `json:"iamAuth"`
+	// CredentialStores configures the external stores of spec.credentialStores
+	CredentialStores  CredentialStores  `json:"credentialStores"`
+	Vault             Vault             `json:"vault"`
+	MasterCredentials MasterCredentials `json:"masterCredentials"`
+	Passwords         Passwords         `json:"passwords"`
+	// Tags are the tags the operator puts on every instance next to spec.tags
+	Tags Tags `json:"tags"`
+}
+
+// AWS configures where databases are created
+type AWS struct {
+	Region           string   `json:"region"`
+	SubnetGroup      string   `json:"subnetGroup"`
+	SecurityGroupIDs []string `json:"securityGroupIDs"`
+	// Accounts are the other AWS accounts databases can be created in
+	Accounts []Account `json:"accounts,omitempty"`
+	// Targets hold the network of databases in other regions and accounts
+	Targets []Target `json:"targets,omitempty"`
+}
+
+// Account is an AWS account reached by assuming a role
+type Account struct {
+	Name       string `json:"name"`
+	RoleARN    string `json:"roleARN"`
+	ExternalID string `json:"externalID,omitempty"`
+}
+
+// Target is the subnet group and security groups of databases in a region
+// and account, the operator's own account if Account is empty
+type Target struct {
+	Region           string   `json:"region"`
+	Account          string   `json:"account,omitempty"`
+	SubnetGroup      string   `json:"subnetGroup"`
+	SecurityGroupIDs []string `json:"securityGroupIDs"`
+}
+
+// Defaults apply to databases whose spec does not set them
+type Defaults struct {
+	EngineVersion       string `json:"engineVersion"`
+	StorageType         string `json:"storageType"`
+	BackupRetentionDays int64  `json:"backupRetentionDays"`
+	BackupWindow        string `json:"backupWindow"`
+	MaintenanceWindow   string `json:"maintenanceWindow"`
+}
+
+// Worker configures the controller, changes need a restart
+type Worker struct {
+	Concurrency               int             `json:"concurrency"`
+	Resync                    metav1.Duration `json:"resync"`
+	AvailabilityCheckInterval metav1.Duration `json:"availabilityCheckInterval"`
+	AvailabilityCheckJitter   float64         `json:"availabilityCheckJitter"`
+	NamespaceSuffix           string          `json:"namespaceSuffix"`
+}
+
+// Exporter configures the metrics exporter deployed next to every database
+type Exporter struct {
+	Image string `json:"image"`
+}
+
+// Secrets configures the connection url stored in the credential secrets
+type Secrets struct {
+	URLScheme string `json:"urlScheme"`
+	SSLMode   string `json:"sslMode"`
+}
+
+// IAMAuth configures the databases using IAM database authentication
+type IAMAuth struct {
+	// GrantImage runs psql to grant rds_iam to the app user
+	GrantImage string `json:"grantImage"`
+	// TokenRefreshInterval is how often the auth tokens in the app secrets
+	// are replaced, tokens expire after 15 minutes
+	TokenRefreshInterval metav1.Duration `json:"tokenRefreshInterval"`
+}
+
+// Tags configures the tags the operator enforces on every instance
+type Tags struct {
+	// ClusterName is the value of the cluster tag, the tag is left out if empty
+	ClusterName string `json:"clusterName,omitempty"`
+	// CostCentreLabel is the label of namespaces the cost-centre tag is taken from
+	CostCentreLabel string `json:"costCentreLabel"`
+	// CostCentre is the value of CostCentreLabel of the namespace, it is
+	// resolved per namespace
+	CostCentre string `json:"-"`
+}
+
+// CredentialStores configures the external stores app credentials are copied to
+type CredentialStores struct {
+	SecretsManager SecretsManagerStore `json:"secretsManager"`
+	Vault          VaultStore          `json:"vault"`
+}
+
+// SecretsManagerStore configures the AWS Secrets Manager secrets of
+// credentials, they are named <prefix>/<namespace>/<secret name>
+type SecretsManagerStore struct {
+	Prefix string `json:"prefix"`
+	// KMSKeyID encrypts the secrets, the AWS managed key is used if it is empty
+	KMSKeyID string `json:"kmsKeyId,omitempty"`
+	// RecoveryWindowDays is how long a deleted secret can still be restored
+	RecoveryWindowDays int64 `json:"recoveryWindowDays"`
+}
+
+// VaultStore configures the Vault KV version 2 secrets of credentials, they
+// are written to <mount>/data/<prefix>/<namespace>/<secret name>
+type VaultStore struct {
+	Mount  string `json:"mount"`
+	Prefix string `json:"prefix"`
+}
+
+// Vault is the Vault server of the vault credential store and of the
+// database secrets engines handing out dynamic credentials
+type Vault struct {
+	// Address of the Vault server, eg. https://vault.example.com:8200
+	Address string `json:"address,omitempty"`
+	// TokenFile holds the Vault token, it is read before every request so an
+	// agent can renew it. The VAULT_TOKEN environment variable is used if it is empty
+	TokenFile string `json:"tokenFile,omitempty"`
+	// DatabaseMountPrefix prefixes the database secrets engine of every
+	// postgresdb, mounted at <prefix>/<namespace>/<name>
+	DatabaseMountPrefix string `json:"databaseMountPrefix"`
+	// CredentialTTL and CredentialMaxTTL are the default and maximum lease
+	// of the dynamic credentials
+	CredentialTTL    metav1.Duration `json:"credentialTTL"`
+	CredentialMaxTTL metav1.Duration `json:"credentialMaxTTL"`
+}
+
+const (
+	// MasterCredentialsSecret keeps master credentials in plain secrets of the master namespace
+	MasterCredentialsSecret = "secret"
+	// MasterCredentialsEncrypted keeps master credentials in secrets of the
+	// master namespace encrypted with a data key
+	MasterCredentialsEncrypted = "encrypted"
+	// MasterCredentialsExternal keeps master credentials in an external
+	// credential store only
+	MasterCredentialsExternal = "external"
+)
+
+// MasterCredentials configures where the master credential of every database
+// is kept, changes need a restart. Master credentials found in kube-system or
+// another home are moved the next time they are stored.
+type MasterCredentials struct {
+	// Mode is secret, encrypted or external
+	Mode string `json:"mode"`
+	// Namespace holds the master secrets of the secret and encrypted modes
+	// and runs the jobs connecting as the master user
+	Namespace string `json:"namespace"`
+	// KMSKeyID generates the data keys of the encrypted mode. LocalKeyFile
+	// holds a 32 byte key used instead where KMS is not available
+	KMSKeyID     string `json:"kmsKeyId,omitempty"`
+	LocalKeyFile string `json:"localKeyFile,omitempty"`
+	// Store is the credential store of the external mode, secretsmanager or vault
+	Store      string     `json:"store,omitempty"`
+	BreakGlass BreakGlass `json:"breakGlass"`
+}
+
+// BreakGlass configures the PostgresDBAccessRequests revealing master credentials
+type BreakGlass struct {
+	// DefaultDuration applies to requests without spec.duration
+	DefaultDuration metav1.Duration `json:"defaultDuration"`
+	// MaxDuration is the longest a master credential is revealed for
+	MaxDuration metav1.Duration `json:"maxDuration"`
+}
+
+var (
+	backupWindow      = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d-([01]\d|2[0-3]):[0-5]\d$`)
+	maintenanceWindow = regexp.MustCompile(`^(?i)(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d-(mon|tue|wed|thu|fri|sat|sun):([01]\d|2[0-3]):[0-5]\d$`)
+)
+
+// Default returns the configuration used for everything the file does not set
+func Default() *Config {
+	return &Config{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		AWS: AWS{
+			Region: "ap-southeast-2",
+		},
+		Defaults: Defaults{
+			EngineVersion:       "9.6.5",
+			StorageType:         "gp2",
+			BackupRetentionDays: 35,
+			BackupWindow:        "13:30-14:30",         // Sun 00:30-01:30 AEDT
+			MaintenanceWindow:   "Sat:14:30-Sat:15:30", // Sun 01:30-02:30 AEDT
+		},
+		Worker: Worker{
+			Concurrency:               1,
+			Resync:                    metav1.Duration{Duration: 30 * time.Second},
+			AvailabilityCheckInterval: metav1.Duration{Duration: 30 * time.Second},
+			AvailabilityCheckJitter:   0.2,
+		},
+		Exporter: Exporter{
+			Image: "wrouesnel/postgres_exporter:v0.4.1",
+		},
+		Secrets: Secrets{
+			URLScheme: "postgresql",
+			SSLMode:   "require",
+		},
+		IAMAuth: IAMAuth{
+			GrantImage:           "postgres:10-alpine",
+			TokenRefreshInterval: metav1.Duration{Duration: 5 * time.Minute},
+		},
+		CredentialStores: CredentialStores{
+			SecretsManager: SecretsManagerStore{
+				Prefix:             "ops-kube-db-operator",
+				RecoveryWindowDays: 7,
+			},
+			Vault: VaultStore{
+				Mount:  "secret",
+				Prefix: "ops-kube-db-operator",
+			},
+		},
+		Vault: Vault{
+			DatabaseMountPrefix: "postgresdb",
+			CredentialTTL:       metav1.Duration{Duration: time.Hour},
+			CredentialMaxTTL:    metav1.Duration{Duration: 24 * time.Hour},
+		},
+		MasterCredentials: MasterCredentials{
+			Mode:      MasterCredentialsSecret,
+			Namespace: "postgresdb-operator",
+			BreakGlass: BreakGlass{
+				DefaultDuration: metav1.Duration{Duration: time.Hour},
+				MaxDuration:     metav1.Duration{Duration: 4 * time.Hour},
+			},
+		},
+		Passwords: Passwords{
+			Default: PasswordPolicy{
+				Length:  30,
+				Classes: []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol},
+			},
+		},
+		Tags: Tags{
+			CostCentreLabel: "cost-centre",
+		},
+	}
+}
+
+// FromEnv returns the default configuration with the legacy environment
+// variables AWS_REGION, DB_SUBNET_GROUP, DB_SECURITY_GROUP_IDS and NS_SUFFIX applied
+func FromEnv() *Config {
+	c := Default()
+	if v := os.Getenv("AWS_REGION"); v != "" {
+		c.AWS.Region = v
+	}
+	c.AWS.SubnetGroup = os.Getenv("DB_SUBNET_GROUP")
+	c.AWS.SecurityGroupIDs = splitList(os.Getenv("DB_SECURITY_GROUP_IDS"))
+	c.Worker.NamespaceSuffix = os.Getenv("NS_SUFFIX")
+	return c
+}
+
+// Parse reads and validates a yaml or json configuration, fields it does not
+// set keep their default
+func Parse(data []byte) (*Config, error) {
+	c := Default()
+	c.APIVersion = ""
+	c.Kind = ""
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("unable to parse operator config: %v", err)
+	}
+	if c.APIVersion != APIVersion || c.Kind != Kind {
+		return nil, fmt.Errorf("unsupported operator config %s %s, expected %s %s", c.APIVersion, c.Kind, APIVersion, Kind)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns the first problem found in the configuration
+func (c *Config) Validate() error {
+	if c.AWS.Region == "" {
+		return fmt.Errorf("aws.region cannot be empty")
+	}
+	if c.AWS.SubnetGroup == "" {
+		return fmt.Errorf("aws.subnetGroup cannot be empty")
+	}
+	if len(c.AWS.SecurityGroupIDs) == 0 {
+		return fmt.Errorf("aws.securityGroupIDs needs at least one security group")
+	}
+	for _, id := range c.AWS.SecurityGroupIDs {
+		if !strings.HasPrefix(id, "sg-") {
+			return fmt.Errorf("aws.securityGroupIDs has invalid security group %q", id)
+		}
+	}
+	if err := c.validateAccounts(); err != nil {
+		return err
+	}
+
+	if c.Defaults.EngineVersion == "" {
+		return fmt.Errorf("defaults.engineVersion cannot be empty")
+	}
+	switch c.Defaults.StorageType {
+	case "gp2", "standard":
+	default:
+		return fmt.Errorf("defaults.storageType must be gp2 or standard, not %q", c.Defaults.StorageType)
+	}
+	if c.Defaults.BackupRetentionDays < 0 || c.Defaults.BackupRetentionDays > 35 {
+		return fmt.Errorf("defaults.backupRetentionDays must be between 0 and 35")
+	}
+	if !backupWindow.MatchString(c.Defaults.BackupWindow) {
+		return fmt.Errorf("defaults.backupWindow %q is not hh:mm-hh:mm", c.Defaults.BackupWindow)
+	}
+	if !maintenanceWindow.MatchString(c.Defaults.MaintenanceWindow) {
+		return fmt.Errorf("defaults.maintenanceWindow %q is not ddd:hh:mm-ddd:hh:mm", c.Defaults.MaintenanceWindow)
+	}
+
+	if c.Worker.Concurrency < 1 {
+		return fmt.Errorf("worker.concurrency must be at least 1")
+	}
+	if c.Worker.Resync.Duration <= 0 || c.Worker.AvailabilityCheckInterval.Duration <= 0 {
+		return fmt.Errorf("worker.resync and worker.availabilityCheckInterval must be positive")
+	}
+	if c.Worker.AvailabilityCheckJitter < 0 {
+		return fmt.Errorf("worker.availabilityCheckJitter cannot be negative")
+	}
+
+	if c.Exporter.Image == "" {
+		return fmt.Errorf("exporter.image cannot be empty")
+	}
+
+	switch c.Secrets.URLScheme {
+	case "postgres", "postgresql":
+	default:
+		return fmt.Errorf("secrets.urlScheme must be postgres or postgresql, not %q", c.Secrets.URLScheme)
+	}
+	switch c.Secrets.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		return fmt.Errorf("secrets.sslMode %q is not a libpq sslmode", c.Secrets.SSLMode)
+	}
+
+	if c.IAMAuth.GrantImage == "" {
+		return fmt.Errorf("iamAuth.grantImage cannot be empty")
+	}
+	if i := c.IAMAuth.TokenRefreshInterval.Duration; i <= 0 || i >= 15*time.Minute {
+		return fmt.Errorf("iamAuth.tokenRefreshInterval must be between 0 and 15 minutes, tokens expire after 15")
+	}
+
+	if d := c.CredentialStores.SecretsManager.RecoveryWindowDays; d < 7 || d > 30 {
+		return fmt.Errorf("credentialStores.secretsManager.recoveryWindowDays must be between 7 and 30")
+	}
+	if c.CredentialStores.Vault.Mount == "" {
+		return fmt.Errorf("credentialStores.vault.mount cannot be empty")
+	}
+
+	if a := c.Vault.Address; a != "" {
+		if u, err := url.Parse(a); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("vault.address %q is not an http or https url", a)
+		}
+	}
+	if c.Vault.DatabaseMountPrefix == "" {
+		return fmt.Errorf("vault.databaseMountPrefix cannot be empty")
+	}
+	if ttl := c.Vault.CredentialTTL.Duration; ttl <= 0 || ttl > c.Vault.CredentialMaxTTL.Duration {
+		return fmt.Errorf("vault.credentialTTL must be positive and at most vault.credentialMaxTTL")
+	}
+	if err := c.validateMasterCredentials(); err != nil {
+		return err
+	}
+	return c.Passwords.Validate()
+}
+
+func (c *Config) validateMasterCredentials() error {
+	m := c.MasterCredentials
+	if m.Namespace == "" {
+		return fmt.Errorf("masterCredentials.namespace cannot be empty")
+	}
+	if m.Namespace == "kube-system" && m.Mode == MasterCredentialsSecret {
+		return fmt.Errorf("masterCredentials.namespace cannot be kube-system for plain secrets")
+	}
+	switch m.Mode {
+	case MasterCredentialsSecret:
+	case MasterCredentialsEncrypted:
+		if (m.KMSKeyID == "") == (m.LocalKeyFile == "") {
+			return fmt.Errorf("masterCredentials.mode encrypted needs one of kmsKeyId or localKeyFile")
+		}
+	case MasterCredentialsExternal:
+		switch m.Store {
+		case "secretsmanager", "vault":
+		default:
+			return fmt.Errorf("masterCredentials.store must be secretsmanager or vault, not %q", m.Store)
+		}
+		if m.Store == "vault" && c.Vault.Address == "" {
+			return fmt.Errorf("masterCredentials.store vault needs vault.address")
+		}
+	default:
+		return fmt.Errorf("masterCredentials.mode must be secret, encrypted or external, not %q", m.Mode)
+	}
+	if d := m.BreakGlass.DefaultDuration.Duration; d <= 0 || d > m.BreakGlass.MaxDuration.Duration {
+		return fmt.Errorf("masterCredentials.breakGlass.defaultDuration must be positive and at most maxDuration")
+	}
+	return nil
+}
+
+func (c *Config) validateAccounts() error {
+	names := map[string]bool{}
+	for _, a := range c.AWS.Accounts {
+		if a.Name == "" {
+			return fmt.Errorf("aws.accounts needs a name for every account")
+		}
+		if names[a.Name] {
+			return fmt.Errorf("aws.accounts has account %s more than once", a.Name)
+		}
+		names[a.Name] = true
+		if !strings.HasPrefix(a.RoleARN, "arn:aws:iam::") {
+			return fmt.Errorf("aws.accounts %s has invalid roleARN %q", a.Name, a.RoleARN)
+		}
+	}
+
+	for _, t := range c.AWS.Targets {
+		if t.Region == "" {
+			return fmt.Errorf("aws.targets needs a region for every target")
+		}
+		if t.Account != "" && !names[t.Account] {
+			return fmt.Errorf("aws.targets %s refers to unknown account %s", t.Region, t.Account)
+		}
+		if t.SubnetGroup == "" || len(t.SecurityGroupIDs) == 0 {
+			return fmt.Errorf("aws.targets %s needs a subnetGroup and securityGroupIDs", t.Region)
+		}
+		for _, id := range t.SecurityGroupIDs {
+			if !strings.HasPrefix(id, "sg-") {
+				return fmt.Errorf("aws.targets %s has invalid security group %q", t.Region, id)
+			}
+		}
+	}
+	return nil
+}
+
+// Account returns the account of the given name
+func (c *Config) Account(name string) (*Account, bool) {
+	for i := range c.AWS.Accounts {
+		if c.AWS.Accounts[i].Name == name {
+			return &c.AWS.Accounts[i], true
+		}
+	}
+	return nil, false
+}
+
+// Target returns the target of a region and account, the region defaults to
+// the operator's region. The operator's own subnet group and security groups
+// are returned for its region and account.
+func (c *Config) Target(region, account string) (*Target, bool) {
+	if region == "" {
+		region = c.AWS.Region
+	}
+	for i := range c.AWS.Targets {
+		if t := &c.AWS.Targets[i]; t.Region == region && t.Account == account {
+			return t, true
+		}
+	}
+	if region == c.AWS.Region && account == "" {
+		return &Target{Region: region, SubnetGroup: c.AWS.SubnetGroup, SecurityGroupIDs: c.AWS.SecurityGroupIDs}, true
+	}
+	return nil, false
+}
+
+// ForNamespace returns a copy of the configuration with the overrides of the
+// postgresdb.myob.com/ annotations of a namespace applied
+func (c *Config) ForNamespace(annotations map[string]string) (*Config, error) {
+	o := c.copy()
+
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, AnnotationPrefix) {
+			continue
+		}
+		switch strings.TrimPrefix(k, AnnotationPrefix) {
+		case "subnet-group":
+			o.AWS.SubnetGroup = v
+		case "security-group-ids":
+			o.AWS.SecurityGroupIDs = splitList(v)
+		case "engine-version":
+			o.Defaults.EngineVersion = v
+		case "storage-type":
+			o.Defaults.StorageType = v
+		case "backup-retention-days":
+			days, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("annotation %s: %v", k, err)
+			}
+			o.Defaults.BackupRetentionDays = days
+		case "backup-window":
+			o.Defaults.BackupWindow = v
+		case "maintenance-window":
+			o.Defaults.MaintenanceWindow = v
+		}
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// copy returns a copy of the configuration the overrides of a namespace can
+// be applied to
+func (c *Config) copy() *Config {
+	o := *c
+	o.AWS.SecurityGroupIDs = append([]string(nil), c.AWS.SecurityGroupIDs...)
+	return &o
+}
+
+func splitList(s string) []string {
+	var l []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			l = append(l, v)
+		}
+	}
+	return l
+}
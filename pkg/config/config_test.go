@@ -0,0 +1,189 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+apiVersion: operator.myob.com/v1
+kind: OperatorConfig
+aws:
+  subnetGroup: db-subnets
+  securityGroupIDs: [sg-1, sg-2]
+defaults:
+  engineVersion: "10.4"
+worker:
+  concurrency: 4
+  availabilityCheckInterval: 1m
+exporter:
+  image: exporter:v1
+secrets:
+  sslMode: verify-full
+`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(testConfig))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ap-southeast-2", c.AWS.Region)
+	assert.Equal(t, []string{"sg-1", "sg-2"}, c.AWS.SecurityGroupIDs)
+	assert.Equal(t, "10.4", c.Defaults.EngineVersion)
+	assert.Equal(t, "gp2", c.Defaults.StorageType)
+	assert.Equal(t, 4, c.Worker.Concurrency)
+	assert.Equal(t, time.Minute, c.Worker.AvailabilityCheckInterval.Duration)
+	assert.Equal(t, 30*time.Second, c.Worker.Resync.Duration)
+	assert.Equal(t, "exporter:v1", c.Exporter.Image)
+	assert.Equal(t, "postgresql", c.Secrets.URLScheme)
+	assert.Equal(t, "verify-full", c.Secrets.SSLMode)
+	assert.Equal(t, 5*time.Minute, c.IAMAuth.TokenRefreshInterval.Duration)
+	assert.Equal(t, "secret", c.CredentialStores.Vault.Mount)
+	assert.Equal(t, int64(7), c.CredentialStores.SecretsManager.RecoveryWindowDays)
+	assert.Equal(t, time.Hour, c.Vault.CredentialTTL.Duration)
+	assert.Equal(t, "postgresdb-operator", c.MasterCredentials.Namespace)
+	assert.Equal(t, MasterCredentialsSecret, c.MasterCredentials.Mode)
+	assert.Equal(t, 30, c.Passwords.For("master").Length)
+}
+
+func TestPasswords_For(t *testing.T) {
+	c, err := Parse([]byte(testConfig + `
+passwords:
+  types:
+    master:
+      length: 40
+      classes: [lower, upper, digit]
+      minimum: {digit: 4}
+      excludeAmbiguous: true
+`))
+
+	assert.Nil(t, err)
+	assert.Equal(t, 40, c.Passwords.For("master").Length)
+	assert.Equal(t, 30, c.Passwords.For("appuser").Length)
+	assert.Equal(t, "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789", c.Passwords.For("master").Charset())
+	assert.InDelta(t, 232.3, c.Passwords.For("master").Entropy(), 0.1)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"wrong version":     "apiVersion: operator.myob.com/v2\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}",
+		"missing kind":      "apiVersion: operator.myob.com/v1\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}",
+		"no subnet group":   "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {securityGroupIDs: [sg-1]}",
+		"bad sg":            "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [banana]}",
+		"bad window":        "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\ndefaults: {backupWindow: '25:00-26:00'}",
+		"bad retention":     "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\ndefaults: {backupRetentionDays: 36}",
+		"no workers":        "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nworker: {concurrency: 0}",
+		"bad ssl mode":      "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nsecrets: {sslMode: always}",
+		"slow tokens":       "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\niamAuth: {tokenRefreshInterval: 20m}",
+		"bad recovery":      "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\ncredentialStores: {secretsManager: {recoveryWindowDays: 3}}",
+		"bad vault":         "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nvault: {address: 'vault:8200'}",
+		"long ttl":          "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nvault: {credentialTTL: 48h}",
+		"plain kube-system": "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nmasterCredentials: {namespace: kube-system}",
+		"no data key":       "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nmasterCredentials: {mode: encrypted}",
+		"bad master store":  "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nmasterCredentials: {mode: external, store: kube}",
+		"long break-glass":  "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\nmasterCredentials: {breakGlass: {defaultDuration: 8h}}",
+		"short password":    "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\npasswords: {default: {length: 6}}",
+		"low entropy":       "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\npasswords: {default: {length: 16, classes: [digit]}}",
+		"bad minimum":       "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\npasswords: {default: {classes: [lower, upper], minimum: {digit: 2}}}",
+		"unknown user":      "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\npasswords: {types: {root: {length: 30, classes: [lower]}}}",
+		"app user policy":   "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1]}\npasswords: {types: {appuser: {length: 40, classes: [lower, upper, digit]}}}",
+		"bad role":          "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1], accounts: [{name: dr, roleARN: dr}]}",
+		"unknown account":   "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1], targets: [{region: ap-southeast-1, account: dr, subnetGroup: b, securityGroupIDs: [sg-2]}]}",
+		"bad target":        "apiVersion: operator.myob.com/v1\nkind: OperatorConfig\naws: {subnetGroup: a, securityGroupIDs: [sg-1], targets: [{region: ap-southeast-1}]}",
+		"not yaml":          "{",
+	}
+
+	for name, data := range tests {
+		_, err := Parse([]byte(data))
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	c, err := Parse([]byte(`
+apiVersion: operator.myob.com/v1
+kind: OperatorConfig
+aws:
+  subnetGroup: db-subnets
+  securityGroupIDs: [sg-1]
+  accounts:
+  - name: dr
+    roleARN: arn:aws:iam::123456789012:role/db-operator
+  targets:
+  - region: ap-southeast-1
+    account: dr
+    subnetGroup: dr-subnets
+    securityGroupIDs: [sg-9]
+`))
+	assert.Nil(t, err)
+
+	target, ok := c.Target("", "")
+	assert.True(t, ok)
+	assert.Equal(t, "db-subnets", target.SubnetGroup)
+
+	target, ok = c.Target("ap-southeast-1", "dr")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"sg-9"}, target.SecurityGroupIDs)
+
+	_, ok = c.Target("ap-southeast-1", "")
+	assert.False(t, ok)
+
+	account, ok := c.Account("dr")
+	assert.True(t, ok)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/db-operator", account.RoleARN)
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{
+		"AWS_REGION":            "us-east-1",
+		"DB_SUBNET_GROUP":       "subnets",
+		"DB_SECURITY_GROUP_IDS": "sg-1, sg-2",
+		"NS_SUFFIX":             "shadow",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	c := FromEnv()
+
+	assert.Nil(t, c.Validate())
+	assert.Equal(t, "us-east-1", c.AWS.Region)
+	assert.Equal(t, "subnets", c.AWS.SubnetGroup)
+	assert.Equal(t, []string{"sg-1", "sg-2"}, c.AWS.SecurityGroupIDs)
+	assert.Equal(t, "shadow", c.Worker.NamespaceSuffix)
+}
+
+func TestForNamespace(t *testing.T) {
+	c, _ := Parse([]byte(testConfig))
+
+	o, err := c.ForNamespace(map[string]string{
+		"postgresdb.myob.com/subnet-group":          "team-subnets",
+		"postgresdb.myob.com/security-group-ids":    "sg-3",
+		"postgresdb.myob.com/backup-retention-days": "7",
+		"postgresdb.myob.com/maintenance-window":    "sun:10:00-sun:11:00",
+		"unrelated":                                 "ignored",
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "team-subnets", o.AWS.SubnetGroup)
+	assert.Equal(t, []string{"sg-3"}, o.AWS.SecurityGroupIDs)
+	assert.Equal(t, int64(7), o.Defaults.BackupRetentionDays)
+	assert.Equal(t, "sun:10:00-sun:11:00", o.Defaults.MaintenanceWindow)
+
+	// the original is left alone
+	assert.Equal(t, "db-subnets", c.AWS.SubnetGroup)
+	assert.Equal(t, int64(35), c.Defaults.BackupRetentionDays)
+}
+
+func TestForNamespace_Invalid(t *testing.T) {
+	c, _ := Parse([]byte(testConfig))
+
+	_, err := c.ForNamespace(map[string]string{"postgresdb.myob.com/backup-retention-days": "many"})
+	assert.NotNil(t, err)
+
+	_, err = c.ForNamespace(map[string]string{"postgresdb.myob.com/storage-type": "floppy"})
+	assert.NotNil(t, err)
+}
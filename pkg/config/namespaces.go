@@ -0,0 +1,137 @@
+package config
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Getter returns the current configuration
+type Getter interface {
+	Get() *Config
+}
+
+// Resolver applies the annotation overrides of namespaces to the current configuration
+type Resolver struct {
+	config     Getter
+	namespaces cache.Store
+	controller cache.Controller
+	watchers   watchers
+}
+
+// reloader is a configuration that changes while the operator runs
+type reloader interface {
+	Watch(stopCh <-chan struct{}, changed func())
+}
+
+// NewResolver returns a Resolver watching the namespaces of the cluster
+func NewResolver(config Getter, client kubernetes.Interface) *Resolver {
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().Namespaces().List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().Namespaces().Watch(options)
+		},
+	}
+
+	r := &Resolver{config: config}
+	r.namespaces, r.controller = cache.NewInformer(lw, &v1.Namespace{}, 10*time.Minute, cache.ResourceEventHandlerFuncs{
+		UpdateFunc: r.onUpdate,
+	})
+	return r
+}
+
+// onUpdate notifies the watchers of namespaces whose overrides or labels,
+// eg. the cost centre, changed
+func (r *Resolver) onUpdate(obj, newObj interface{}) {
+	old, ok := obj.(*v1.Namespace)
+	if !ok {
+		return
+	}
+	ns := newObj.(*v1.Namespace)
+	if reflect.DeepEqual(old.Annotations, ns.Annotations) && reflect.DeepEqual(old.Labels, ns.Labels) {
+		return
+	}
+	r.watchers.notify(ns.Name)
+}
+
+// Watch calls changed with the name of a namespace whenever its configuration
+// may have changed, and with "" whenever the configuration of every namespace
+// may have, until stopCh is closed
+func (r *Resolver) Watch(stopCh <-chan struct{}, changed func(namespace string)) {
+	if c, ok := r.config.(reloader); ok {
+		go c.Watch(stopCh, func() { changed("") })
+	}
+	r.watchers.watch(stopCh, changed)
+}
+
+// Get returns the configuration without namespace overrides
+func (r *Resolver) Get() *Config {
+	return r.config.Get()
+}
+
+// ForNamespace returns the configuration for databases of a namespace. Invalid
+// overrides are ignored so a bad annotation does not stop the namespace.
+func (r *Resolver) ForNamespace(namespace string) *Config {
+	c := r.config.Get()
+
+	obj, exists, err := r.namespaces.GetByKey(namespace)
+	if err != nil || !exists {
+		return c
+	}
+	ns := obj.(*v1.Namespace)
+
+	o, err := c.ForNamespace(ns.Annotations)
+	if err != nil {
+		glog.Errorf("ignoring operator config overrides of namespace %s: %v", namespace, err)
+		o = c.copy()
+	}
+	o.Tags.CostCentre = ns.Labels[o.Tags.CostCentreLabel]
+	return o
+}
+
+// Select returns the namespaces whose labels match selector
+func (r *Resolver) Select(selector labels.Selector) []*v1.Namespace {
+	var matched []*v1.Namespace
+	for _, obj := range r.namespaces.List() {
+		ns := obj.(*v1.Namespace)
+		if selector.Matches(labels.Set(ns.Labels)) {
+			matched = append(matched, ns)
+		}
+	}
+	return matched
+}
+
+// Run watches the namespaces until stopCh is closed
+func (r *Resolver) Run(stopCh <-chan struct{}) {
+	r.controller.Run(stopCh)
+}
+
+// HasSynced returns true once the namespaces have been listed
+func (r *Resolver) HasSynced() bool {
+	return r.controller.HasSynced()
+}
+
+// Fixed serves the same configuration to every namespace
+type Fixed struct {
+	Config *Config
+}
+
+// Get returns the configuration
+func (f Fixed) Get() *Config {
+	return f.Config
+}
+
+// ForNamespace returns the configuration whatever the namespace
+func (f Fixed) ForNamespace(namespace string) *Config {
+	return f.Config
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Character classes of password policies
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// classChars are the characters of every class. The symbols are the
+// unreserved characters of urls, so passwords are not escaped in DATABASE_URL,
+// and none of them is a / " @ ' or space RDS rejects in master passwords.
+var classChars = map[string]string{
+	ClassLower:  "abcdefghijklmnopqrstuvwxyz",
+	ClassUpper:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	ClassDigit:  "0123456789",
+	ClassSymbol: "-._~",
+}
+
+// ambiguousChars are easily mistaken for each other when read out
+const ambiguousChars = "0Oo1lI"
+
+// MinPasswordEntropy is the least entropy in bits a password policy may have
+const MinPasswordEntropy = 64
+
+// passwordUsers are the user names of the credential types with a password policy
+var passwordUsers = []string{"master", "appuser", "appadmin", "appreadonly", "monitoring"}
+
+// Passwords configures the passwords generated for the credentials of databases
+type Passwords struct {
+	// Default applies to the credential types without a policy in Types
+	Default PasswordPolicy `json:"default"`
+	// Types holds the policies of credential types by user name, only master
+	// for now as the app credentials share the master password
+	Types map[string]PasswordPolicy `json:"types,omitempty"`
+}
+
+// PasswordPolicy says how long generated passwords are and which characters they use
+type PasswordPolicy struct {
+	Length int `json:"length"`
+	// Classes are the character classes passwords are made of: lower, upper, digit and symbol
+	Classes []string `json:"classes"`
+	// Minimum is the least number of characters of a class in every password
+	Minimum map[string]int `json:"minimum,omitempty"`
+	// ExcludeAmbiguous leaves out 0, O, o, 1, l and I
+	ExcludeAmbiguous bool `json:"excludeAmbiguous,omitempty"`
+}
+
+// For returns the policy of the passwords of a user
+func (p Passwords) For(user string) PasswordPolicy {
+	if policy, ok := p.Types[user]; ok {
+		return policy
+	}
+	return p.Default
+}
+
+// ClassChars returns the characters of a class passwords are made of
+func (p PasswordPolicy) ClassChars(class string) string {
+	chars := classChars[class]
+	if !p.ExcludeAmbiguous {
+		return chars
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(ambiguousChars, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+// Charset returns all the characters passwords are made of
+func (p PasswordPolicy) Charset() string {
+	var chars string
+	for _, class := range p.Classes {
+		chars += p.ClassChars(class)
+	}
+	return chars
+}
+
+// Entropy returns the bits of entropy of a password, the minimum counts take
+// a little of it away
+func (p PasswordPolicy) Entropy() float64 {
+	n := len(p.Charset())
+	if n == 0 {
+		return 0
+	}
+	return float64(p.Length) * math.Log2(float64(n))
+}
+
+// Validate returns the first problem found in the password policies
+func (p Passwords) Validate() error {
+	if err := p.Default.validate("passwords.default"); err != nil {
+		return err
+	}
+	for user, policy := range p.Types {
+		if !containsString(passwordUsers, user) {
+			return fmt.Errorf("passwords.types has unknown user %s, expected one of %s", user, strings.Join(passwordUsers, ", "))
+		}
+		// the app credentials are generated with the master password, a
+		// policy of their own would be silently ignored
+		if user != "master" {
+			return fmt.Errorf("passwords.types.%s has no effect, the app credentials share the master password, set passwords.types.master instead", user)
+		}
+		if err := policy.validate("passwords.types." + user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p PasswordPolicy) validate(field string) error {
+	// RDS takes master passwords of 8 to 128 characters
+	if p.Length < 8 || p.Length > 128 {
+		return fmt.Errorf("%s.length must be between 8 and 128", field)
+	}
+	if len(p.Classes) == 0 {
+		return fmt.Errorf("%s.classes needs at least one class", field)
+	}
+	seen := map[string]bool{}
+	for _, class := range p.Classes {
+		if _, ok := classChars[class]; !ok {
+			return fmt.Errorf("%s.classes has unknown class %q, expected lower, upper, digit or symbol", field, class)
+		}
+		if seen[class] {
+			return fmt.Errorf("%s.classes has %s more than once", field, class)
+		}
+		seen[class] = true
+	}
+	total := 0
+	for class, n := range p.Minimum {
+		if !seen[class] {
+			return fmt.Errorf("%s.minimum has %s which is not one of its classes", field, class)
+		}
+		if n < 0 {
+			return fmt.Errorf("%s.minimum of %s cannot be negative", field, class)
+		}
+		total += n
+	}
+	if total > p.Length {
+		return fmt.Errorf("%s.minimum adds up to more than its length", field)
+	}
+	if e := p.Entropy(); e < MinPasswordEntropy {
+		return fmt.Errorf("%s has %.0f bits of entropy, at least %d are needed", field, e, MinPasswordEntropy)
+	}
+	return nil
+}
+
+func containsString(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
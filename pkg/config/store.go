@@ -0,0 +1,108 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// Store holds the current configuration and reloads it when its file changes
+type Store struct {
+	mu      sync.RWMutex
+	current *Config
+	// raw is the file content last read, valid or not
+	raw []byte
+
+	path   string
+	period time.Duration
+
+	watchers watchers
+}
+
+// NewStore loads and validates the configuration file at path. Without a path
+// the configuration comes from the legacy environment variables and never changes.
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		period: 10 * time.Second,
+	}
+
+	if path == "" {
+		c := FromEnv()
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		s.current = c
+		return s, nil
+	}
+
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read operator config: %v", err)
+	}
+	c, err := Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	s.set(c, raw)
+	return s, nil
+}
+
+// Get returns the current configuration
+func (s *Store) Get() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+func (s *Store) set(c *Config, raw []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = c
+	s.raw = raw
+}
+
+// Run reloads the configuration file whenever it changes until stopCh is closed
+func (s *Store) Run(stopCh <-chan struct{}) {
+	if s.path == "" {
+		return
+	}
+	wait.Until(s.reload, s.period, stopCh)
+}
+
+// Watch calls changed whenever the configuration is reloaded until stopCh is
+// closed
+func (s *Store) Watch(stopCh <-chan struct{}, changed func()) {
+	s.watchers.watch(stopCh, func(string) { changed() })
+}
+
+func (s *Store) reload() {
+	raw, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		glog.Errorf("unable to read operator config %s: %v", s.path, err)
+		return
+	}
+
+	s.mu.Lock()
+	unchanged := bytes.Equal(raw, s.raw)
+	s.raw = raw
+	s.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	c, err := Parse(raw)
+	if err != nil {
+		// keep running with the last good configuration
+		glog.Errorf("ignoring operator config %s: %v", s.path, err)
+		return
+	}
+	s.set(c, raw)
+	glog.Infof("reloaded operator config %s", s.path)
+	s.watchers.notify("")
+}
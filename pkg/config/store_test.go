@@ -0,0 +1,152 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func writeConfig(t *testing.T, path, data string) {
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_Reload(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, testConfig)
+
+	s, err := NewStore(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.4", s.Get().Defaults.EngineVersion)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	reloads := make(chan struct{}, 10)
+	go s.Watch(stopCh, func() { reloads <- struct{}{} })
+	for i := 0; i < 100 && s.watchers.len() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writeConfig(t, path, strings.Replace(testConfig, `"10.4"`, `"10.5"`, 1))
+	s.reload()
+	assert.Equal(t, "10.5", s.Get().Defaults.EngineVersion)
+	assert.Equal(t, 1, len(reloads))
+
+	// an unchanged file is not reloaded
+	s.reload()
+	assert.Equal(t, 1, len(reloads))
+
+	// an invalid file keeps the last good configuration
+	writeConfig(t, path, "kind: banana")
+	s.reload()
+	assert.Equal(t, "10.5", s.Get().Defaults.EngineVersion)
+	assert.Equal(t, 1, len(reloads))
+}
+
+func TestNewStore_Invalid(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "config")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	writeConfig(t, path, "kind: banana")
+
+	_, err := NewStore(path)
+	assert.NotNil(t, err)
+
+	_, err = NewStore(filepath.Join(dir, "missing.yaml"))
+	assert.NotNil(t, err)
+}
+
+func TestResolver_ForNamespace(t *testing.T) {
+	c, _ := Parse([]byte(testConfig))
+	client := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team", Annotations: map[string]string{
+			"postgresdb.myob.com/engine-version": "10.6",
+		}, Labels: map[string]string{"cost-centre": "cc-1234"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "broken", Annotations: map[string]string{
+			"postgresdb.myob.com/backup-window": "whenever",
+		}}},
+	)
+	r := NewResolver(Fixed{Config: c}, client)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go r.Run(stopCh)
+
+	for i := 0; i < 100 && !r.HasSynced(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(t, "10.6", r.ForNamespace("team").Defaults.EngineVersion)
+	assert.Equal(t, "cc-1234", r.ForNamespace("team").Tags.CostCentre)
+	assert.Empty(t, c.Tags.CostCentre)
+	assert.Equal(t, c, r.ForNamespace("broken"))
+	assert.Equal(t, c, r.ForNamespace("unknown"))
+}
+
+func TestResolver_Watch(t *testing.T) {
+	c, _ := Parse([]byte(testConfig))
+	r := NewResolver(Fixed{Config: c}, fake.NewSimpleClientset())
+
+	stopCh := make(chan struct{})
+	changed := make(chan string, 10)
+	go r.Watch(stopCh, func(namespace string) { changed <- namespace })
+	for i := 0; i < 100 && r.watchers.len() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team", Labels: map[string]string{"cost-centre": "cc-1234"}}}
+	r.onUpdate(ns, ns.DeepCopy())
+	assert.Equal(t, 0, len(changed))
+
+	relabelled := ns.DeepCopy()
+	relabelled.Labels["cost-centre"] = "cc-5678"
+	r.onUpdate(ns, relabelled)
+	assert.Equal(t, "team", <-changed)
+
+	overridden := ns.DeepCopy()
+	overridden.Annotations = map[string]string{"postgresdb.myob.com/engine-version": "10.6"}
+	r.onUpdate(ns, overridden)
+	assert.Equal(t, "team", <-changed)
+
+	// stopped watches are not called any more
+	close(stopCh)
+	for i := 0; i < 100 && r.watchers.len() > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	r.onUpdate(ns, relabelled)
+	assert.Equal(t, 0, len(changed))
+}
+
+func TestResolver_Select(t *testing.T) {
+	c, _ := Parse([]byte(testConfig))
+	client := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team", Labels: map[string]string{"tier": "web"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	)
+	r := NewResolver(Fixed{Config: c}, client)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go r.Run(stopCh)
+
+	for i := 0; i < 100 && !r.HasSynced(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	matched := r.Select(labels.SelectorFromSet(labels.Set{"tier": "web"}))
+	assert.Equal(t, 1, len(matched))
+	assert.Equal(t, "team", matched[0].Name)
+	assert.Equal(t, 2, len(r.Select(labels.Everything())))
+}
@@ -0,0 +1,42 @@
+package config
+
+import "sync"
+
+// watchers calls the functions watching for changes until their watch stops
+type watchers struct {
+	mu   sync.RWMutex
+	next int
+	fs   map[int]func(namespace string)
+}
+
+// watch calls changed with every change until stopCh is closed
+func (w *watchers) watch(stopCh <-chan struct{}, changed func(namespace string)) {
+	w.mu.Lock()
+	if w.fs == nil {
+		w.fs = map[int]func(string){}
+	}
+	id := w.next
+	w.next++
+	w.fs[id] = changed
+	w.mu.Unlock()
+
+	<-stopCh
+
+	w.mu.Lock()
+	delete(w.fs, id)
+	w.mu.Unlock()
+}
+
+func (w *watchers) len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.fs)
+}
+
+func (w *watchers) notify(namespace string) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, f := range w.fs {
+		f(namespace)
+	}
+}
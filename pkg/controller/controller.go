@@ -1,55 +1,329 @@
 package controller
 
 import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
-	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/events"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/leader"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
 	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 )
 
 // Worker handles change events for CRDs
 type Worker interface {
-	OnCreate(obj interface{})
+	// OnCreate returns a terminal error if the database of the CRD cannot be
+	// created until the CRD changes
+	OnCreate(obj interface{}) error
 	OnUpdate(obj interface{}, newObj interface{})
 	OnDelete(obj interface{})
+	// CheckAvailability returns true while the database of the CRD is not available yet
+	CheckAvailability(obj interface{}) (bool, error)
+}
+
+// ConfigWatcher reports changes of the operator configuration of namespaces
+type ConfigWatcher interface {
+	// Watch calls changed with the name of a namespace whose configuration
+	// changed, or "" when that of every namespace did, until stopCh is closed
+	Watch(stopCh <-chan struct{}, changed func(namespace string))
+}
+
+// Config configures how often databases that are not available yet are
+// checked and by how many workers
+type Config struct {
+	checkInterval  time.Duration
+	jitter         float64
+	workers        int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+}
+
+// NewConfig returns a controller Config, every check is delayed by up to
+// jitter * interval on top of the interval
+func NewConfig(interval time.Duration, jitter float64, workers int) *Config {
+	return NewConfigWithRetries(interval, jitter, workers, 5*time.Second, 5*time.Minute)
+}
+
+// NewConfigWithRetries returns a controller Config retrying checks that failed
+// with throttling or other errors that go away on their own. The delay doubles
+// with every failure of a postgresdb from the base delay up to the max delay.
+func NewConfigWithRetries(interval time.Duration, jitter float64, workers int, base, max time.Duration) *Config {
+	return &Config{
+		checkInterval:  interval,
+		jitter:         jitter,
+		workers:        workers,
+		retryBaseDelay: base,
+		retryMaxDelay:  max,
+	}
 }
 
 // PgController is a controller for Postgres RDS DBs.
 type PgController struct {
-	dbsLister v1alpha1.PostgresDBLister
+	*Config
+	worker    Worker
+	dbsLister v1beta1.PostgresDBLister
 	dbsSynced cache.InformerSynced
+	queue     workqueue.RateLimitingInterface
+	events    events.Source
+	configs   ConfigWatcher
 }
 
-// New instantiates an pgController
-func New(factory externalversions.SharedInformerFactory, worker Worker) *PgController {
+// New instantiates an pgController, events and configs may be nil
+func New(factory externalversions.SharedInformerFactory, worker Worker, cfg *Config, source events.Source, configs ConfigWatcher) *PgController {
 
-	informer := factory.Postgresdb().V1alpha1().PostgresDBs()
+	informer := factory.Postgresdb().V1beta1().PostgresDBs()
 	c := &PgController{
+		Config:    cfg,
+		worker:    worker,
 		dbsLister: informer.Lister(),
 		dbsSynced: informer.Informer().HasSynced,
+		queue:     workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(cfg.retryBaseDelay, cfg.retryMaxDelay), "postgresdbs"),
+		events:    source,
+		configs:   configs,
 	}
 
 	// Just Call worker function rather then add, update, delete
+	// created dbs are then checked on through the queue until they are available
 	informer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
-			AddFunc:    worker.OnCreate,
-			UpdateFunc: worker.OnUpdate,
+			AddFunc:    c.onCreate,
+			UpdateFunc: c.onUpdate,
 			DeleteFunc: worker.OnDelete,
 		},
 	)
 	return c
 }
 
+func (c *PgController) onCreate(obj interface{}) {
+	// postgresdbs that were rejected are not checked, their database is not
+	// on its way
+	if err := c.worker.OnCreate(obj); rds.IsTerminal(err) {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		glog.Errorf("unable to get key for %v: %v", obj, err)
+		return
+	}
+	c.queue.AddAfter(key, c.nextCheck())
+}
+
+// onUpdate checks a db again when its spec changed or its master password
+// was rotated, the check copies its credentials to the credential stores that
+// were added, rewrites its secrets and reconciles its tags
+func (c *PgController) onUpdate(obj, newObj interface{}) {
+	c.worker.OnUpdate(obj, newObj)
+
+	old, ok := obj.(*crds.PostgresDB)
+	if !ok {
+		return
+	}
+	db := newObj.(*crds.PostgresDB)
+	if reflect.DeepEqual(old.Spec, db.Spec) &&
+		old.Annotations[crds.MasterRotatedAnnotation] == db.Annotations[crds.MasterRotatedAnnotation] {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(newObj)
+	if err != nil {
+		glog.Errorf("unable to get key for %v: %v", newObj, err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+func (c *PgController) nextCheck() time.Duration {
+	return wait.Jitter(c.checkInterval, c.jitter)
+}
+
+// Run checks postgresdbs until stopCh is closed, it returns once the checks
+// in flight are done
 func (c *PgController) Run(stopCh <-chan struct{}) {
 	glog.Info("starting the controller")
 	if !cache.WaitForCacheSync(stopCh, c.dbsSynced) {
 		glog.Info("unable to sync cache")
+		c.queue.ShutDown()
 		return
 	}
 	glog.Info("caches are synced")
+	metrics.PostgresDBs.Collect(c.countByPhase)
+	metrics.MasterPasswordAge.Collect(c.masterPasswordAges)
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(func() { leader.RunWorker(stopCh, c.processNextItem) }, time.Second, stopCh)
+		}()
+	}
+
+	if c.events != nil {
+		ids := make(chan database.DatabaseID)
+		go c.events.Run(stopCh, ids)
+		go c.watchEvents(stopCh, ids)
+	}
+	if c.configs != nil {
+		go c.configs.Watch(stopCh, c.enqueueNamespace)
+	}
 
 	// wait until we're told to stop
 	glog.Info("waiting for stop signal")
 	<-stopCh
-	glog.Info("received stop signal")
+	glog.Info("received stop signal, waiting for checks in flight")
+	c.queue.ShutDown()
+	workers.Wait()
+}
+
+func (c *PgController) processNextItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	start := time.Now()
+	requeue, err := c.checkAvailability(key.(string))
+	retry := err != nil && !rds.IsTerminal(err)
+	metrics.ObserveReconcile("postgresdb", start, requeue || retry, err)
+
+	// anything but errors that need the postgresdb or the account changed is
+	// retried with a backoff growing with every failure in a row, eg.
+	// throttling or secrets that could not be written when finalising
+	if retry {
+		glog.Warningf("retrying check of %s after %s: %v", key, rds.Classify(err), err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	if err != nil {
+		glog.Errorf("unable to check availability of %s: %v", key, err)
+	}
+
+	c.queue.Forget(key)
+	if requeue {
+		c.queue.AddAfter(key, c.nextCheck())
+	}
+	return true
+}
+
+func (c *PgController) checkAvailability(key string) (bool, error) {
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	crd, err := c.dbsLister.PostgresDBs(ns).Get(name)
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	requeue, err := c.worker.CheckAvailability(crd.DeepCopy())
+	if err == nil && !requeue && crd.Status.Phase != database.StatusAvailable.String() {
+		metrics.TimeToAvailable.With(crd.Namespace).Observe(time.Since(crd.CreationTimestamp.Time).Seconds())
+	}
+	return requeue, err
+}
+
+// countByPhase returns the number of PostgresDBs of every phase for the postgresdbs metric
+func (c *PgController) countByPhase() []metrics.Sample {
+	dbs, err := c.dbsLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("unable to list postgresdbs: %v", err)
+		return nil
+	}
+	counts := map[string]float64{}
+	for _, db := range dbs {
+		counts[db.Status.Phase]++
+	}
+	samples := make([]metrics.Sample, 0, len(counts))
+	for phase, n := range counts {
+		samples = append(samples, metrics.Sample{Labels: []string{phase}, Value: n})
+	}
+	return samples
+}
+
+// masterPasswordAges returns the seconds since the master password of every
+// PostgresDB was rotated, or the PostgresDB was created if it never was
+func (c *PgController) masterPasswordAges() []metrics.Sample {
+	dbs, err := c.dbsLister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("unable to list postgresdbs: %v", err)
+		return nil
+	}
+	now := time.Now()
+	samples := make([]metrics.Sample, 0, len(dbs))
+	for _, db := range dbs {
+		set := db.CreationTimestamp.Time
+		if rotated, err := time.Parse(time.RFC3339, db.Annotations[crds.MasterRotatedAnnotation]); err == nil {
+			set = rotated
+		}
+		samples = append(samples, metrics.Sample{Labels: []string{db.Namespace, db.Name}, Value: now.Sub(set).Seconds()})
+	}
+	return samples
+}
+
+// watchEvents checks a db right away when RDS published an event for it
+func (c *PgController) watchEvents(stopCh <-chan struct{}, ids <-chan database.DatabaseID) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case id := <-ids:
+			key, err := c.keyForDB(id)
+			if err != nil {
+				glog.Errorf("unable to find postgresdb for db %s: %v", id, err)
+				continue
+			}
+			if key != "" {
+				c.queue.Add(key)
+			}
+		}
+	}
+}
+
+// enqueueNamespace checks the dbs of a namespace, or every db for "", again
+// after its configuration changed, eg. to reconcile the cost centre tag
+func (c *PgController) enqueueNamespace(namespace string) {
+	var dbs []*crds.PostgresDB
+	var err error
+	if namespace == "" {
+		dbs, err = c.dbsLister.List(labels.Everything())
+	} else {
+		dbs, err = c.dbsLister.PostgresDBs(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		glog.Errorf("unable to list postgresdbs: %v", err)
+		return
+	}
+	for _, db := range dbs {
+		c.queue.Add(fmt.Sprintf("%s/%s", db.Namespace, db.Name))
+	}
+}
+
+func (c *PgController) keyForDB(id database.DatabaseID) (string, error) {
+	dbs, err := c.dbsLister.List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	for _, db := range dbs {
+		if db.Status.ID == string(id) {
+			return fmt.Sprintf("%s/%s", db.Namespace, db.Name), nil
+		}
+	}
+	return "", nil
 }
@@ -1,20 +1,38 @@
 package controller_test
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/controller"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sTesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
 )
 
 type mockWorker struct {
-	Calls map[string][]interface{}
+	Calls   map[string][]interface{}
+	checks  chan string
+	pending int
+	// errs are returned by the first checks
+	errs []error
+	// createErr is returned by OnCreate
+	createErr error
 }
 
-func (w *mockWorker) OnCreate(obj interface{}) {
+func (w *mockWorker) OnCreate(obj interface{}) error {
 	w.Calls["create"] = append(w.Calls["create"], obj)
+	return w.createErr
 }
 
 func (w *mockWorker) OnUpdate(obj interface{}, newObj interface{}) {
@@ -25,9 +43,56 @@ func (w *mockWorker) OnDelete(obj interface{}) {
 	w.Calls["delete"] = append(w.Calls["delete"], obj)
 }
 
+// CheckAvailability reports the db as pending for the first checks
+func (w *mockWorker) CheckAvailability(obj interface{}) (bool, error) {
+	w.checks <- obj.(*v1beta1.PostgresDB).Name
+	if len(w.errs) > 0 {
+		err := w.errs[0]
+		w.errs = w.errs[1:]
+		return false, err
+	}
+	w.pending--
+	return w.pending >= 0, nil
+}
+
+type mockSource struct {
+	id database.DatabaseID
+}
+
+func (s *mockSource) Run(stopCh <-chan struct{}, ids chan<- database.DatabaseID) {
+	ids <- s.id
+}
+
+type mockConfigs struct {
+	namespace string
+}
+
+func (c *mockConfigs) Watch(stopCh <-chan struct{}, changed func(namespace string)) {
+	changed(c.namespace)
+}
+
 func newMockWorker() *mockWorker {
 	return &mockWorker{
-		Calls: make(map[string][]interface{}),
+		Calls:  make(map[string][]interface{}),
+		checks: make(chan string, 10),
+	}
+}
+
+func newPostgresDB(name, id string) *v1beta1.PostgresDB {
+	return &v1beta1.PostgresDB{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "test"},
+		Status:     v1beta1.PostgresDBStatus{ID: id},
+	}
+}
+
+func expectCheck(t *testing.T, w *mockWorker, name string) {
+	select {
+	case n := <-w.checks:
+		if n != name {
+			t.Errorf("expected check of %s, got %s", name, n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected check of %s", name)
 	}
 }
 
@@ -39,9 +104,171 @@ func TestPgController(t *testing.T) {
 	i.Start(stopCh)
 	wrkr := newMockWorker()
 
-	c := controller.New(i, wrkr)
+	c := controller.New(i, wrkr, controller.NewConfig(time.Second, 0, 1), nil, nil)
 	go c.Run(stopCh)
 	defer func() {
 		stopCh <- struct{}{}
 	}()
 }
+
+func TestPgController_RequeuesUntilAvailable(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newPostgresDB("test", "db-id"))
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	wrkr.pending = 1
+	c := controller.New(i, wrkr, controller.NewConfig(10*time.Millisecond, 0, 1), nil, nil)
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	expectCheck(t, wrkr, "test")
+	expectCheck(t, wrkr, "test")
+
+	select {
+	case n := <-wrkr.checks:
+		t.Errorf("unexpected check of %s after the db became available", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPgController_RetriesThrottled(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newPostgresDB("test", "db-id"))
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	wrkr.errs = []error{
+		awserr.New("Throttling", "Rate exceeded", nil),
+		awserr.New("InsufficientDBInstanceCapacity", "no capacity", nil),
+		errors.New("unable to store credentials: the object has been modified"),
+		awserr.New("InvalidParameterCombination", "invalid", nil),
+	}
+	c := controller.New(i, wrkr, controller.NewConfigWithRetries(10*time.Millisecond, 0, 1, 10*time.Millisecond, 20*time.Millisecond), nil, nil)
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	// checks failing with errors that are not terminal are retried, the
+	// others are left alone
+	expectCheck(t, wrkr, "test")
+	expectCheck(t, wrkr, "test")
+	expectCheck(t, wrkr, "test")
+	expectCheck(t, wrkr, "test")
+
+	select {
+	case n := <-wrkr.checks:
+		t.Errorf("unexpected check of %s after an error that is not retried", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPgController_SkipsRejected(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newPostgresDB("test", ""))
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	wrkr.createErr = &database.InvalidSpecError{Err: errors.New("size cannot be empty")}
+	c := controller.New(i, wrkr, controller.NewConfig(10*time.Millisecond, 0, 1), nil, nil)
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	select {
+	case n := <-wrkr.checks:
+		t.Errorf("unexpected check of %s after it was rejected", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPgController_ChecksOnEvent(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newPostgresDB("test", "db-id"))
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	c := controller.New(i, wrkr, controller.NewConfig(time.Hour, 0, 1), &mockSource{id: "db-id"}, nil)
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	expectCheck(t, wrkr, "test")
+}
+
+func TestPgController_ChecksOnSpecChange(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newPostgresDB("test", "db-id"))
+	// the fake clientset does not watch its objects, the updates are sent here
+	updates := watch.NewFake()
+	clientset.PrependWatchReactor("postgresdbs", k8sTesting.DefaultWatchReactor(updates, nil))
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	c := controller.New(i, wrkr, controller.NewConfig(time.Hour, 0, 1), nil, nil)
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	// resyncs are not checked
+	updates.Modify(newPostgresDB("test", "db-id"))
+	select {
+	case n := <-wrkr.checks:
+		t.Errorf("unexpected check of %s without changes", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	db := newPostgresDB("test", "db-id")
+	db.Spec.Tags = map[string]string{"team": "ledger"}
+	updates.Modify(db)
+	expectCheck(t, wrkr, "test")
+}
+
+func TestPgController_ChecksOnNamespaceChange(t *testing.T) {
+	other := newPostgresDB("other", "other-id")
+	other.Namespace = "other"
+	clientset := fake.NewSimpleClientset(newPostgresDB("test", "db-id"), other)
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	c := controller.New(i, wrkr, controller.NewConfig(time.Hour, 0, 1), nil, &mockConfigs{namespace: "test"})
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	expectCheck(t, wrkr, "test")
+	select {
+	case n := <-wrkr.checks:
+		t.Errorf("unexpected check of %s in another namespace", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPgController_Metrics(t *testing.T) {
+	workqueue.SetProvider(metrics.WorkqueueProvider{})
+	db := newPostgresDB("metered", "metered-id")
+	db.Namespace = "metrics"
+	db.Status.Phase = "Creating"
+	clientset := fake.NewSimpleClientset(db)
+	i := externalversions.NewSharedInformerFactory(clientset, time.Second*30)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	wrkr := newMockWorker()
+	c := controller.New(i, wrkr, controller.NewConfig(10*time.Millisecond, 0, 1), nil, nil)
+	i.Start(stopCh)
+	go c.Run(stopCh)
+
+	expectCheck(t, wrkr, "metered")
+	// the check is recorded once the worker returns
+	time.Sleep(50 * time.Millisecond)
+
+	var b bytes.Buffer
+	metrics.DefaultRegistry.Write(&b)
+	assert.Contains(t, b.String(), `postgresdb_operator_postgresdbs{phase="Creating"} 1`)
+	assert.Contains(t, b.String(), `postgresdb_operator_time_to_available_seconds_count{namespace="metrics"} 1`)
+	assert.Contains(t, b.String(), `postgresdb_operator_master_password_age_seconds{namespace="metrics",name="metered"}`)
+	assert.Contains(t, b.String(), `postgresdb_operator_workqueue_depth{name="postgresdbs"}`)
+}
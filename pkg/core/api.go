@@ -1,10 +1,12 @@
 package core
 
 import (
+	"context"
 	"fmt"
-	"time"
 
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/trace"
 )
 
 //go:generate mockgen -source=$GOFILE -destination=../mocks/mock_core.go -package=mocks
@@ -16,7 +18,19 @@ type DBCreator interface {
 
 // DBGetter checks if a Database already exists for the request
 type DBGetter interface {
-	GetDB(database.DatabaseID) (*database.Database, error)
+	GetDB(database.Location, database.DatabaseID) (*database.Database, error)
+}
+
+// DBLister lists the databases the operator created in a region and account
+type DBLister interface {
+	ListDBs(loc database.Location) ([]*database.Database, error)
+}
+
+// TagReconciler brings the tags of a database in line with its request, of
+// the tags it does not ask for only those the operator manages and the
+// removed keys are taken off
+type TagReconciler interface {
+	ReconcileTags(loc database.Location, id database.DatabaseID, tags map[string]string, removed []string) error
 }
 
 // Gets credential
@@ -32,10 +46,41 @@ type CredsCreator interface {
 	CreateCred(credential *database.Credential) error
 }
 
+// CredsDeleter removes a credential from the external stores it was copied to
+type CredsDeleter interface {
+	DeleteCred(credential *database.Credential) error
+}
+
 type MetricsExporterCreator interface {
 	CreateMetricsExporter(s database.Scope, name string, id database.CredentialID) error
 }
 
+// NetworkPreparer checks the subnet group and security groups of a request
+// exist before its database is created, it may add security groups to the request
+type NetworkPreparer interface {
+	PrepareNetwork(req *database.Request) error
+}
+
+// KeyResolver returns the ARN of a customer managed KMS key, or an error if
+// the key cannot be used to encrypt
+type KeyResolver interface {
+	ResolveKey(loc database.Location, keyID string) (string, error)
+}
+
+// IAMAuthenticator grants a user of a database IAM database authentication
+// and signs the auth tokens it connects with
+type IAMAuthenticator interface {
+	GrantIAMRole(master *database.Credential, user string) error
+	AuthToken(loc database.Location, endpoint, user string) (string, error)
+}
+
+// DynamicCredentials sets up the Vault database secrets engine handing out
+// short lived users of a database, and removes it with the database
+type DynamicCredentials interface {
+	ConfigureDynamicCredentials(s database.Scope, name string, master *database.Credential) (map[database.CredentialType]string, error)
+	RemoveDynamicCredentials(s database.Scope, name string) error
+}
+
 type StatusUpdater interface {
 	StatusUpdate(sReq *database.StatusRequest) error
 }
@@ -56,20 +101,28 @@ type CreateDatabase interface {
 	DBCreateGetter
 }
 
-func CreateDatabaseIfNotExist(i DBCreateGetter, req *database.Request, cred *database.Credential) (*database.Database, error) {
+func CreateDatabaseIfNotExist(ctx context.Context, i DBCreateGetter, req *database.Request, cred *database.Credential, l log.Logger) (*database.Database, error) {
 
 	// check if database already exists
-	db, err := i.GetDB(req.ID)
+	db, err := getDB(ctx, i, req.Location, req.ID)
 	if err != nil {
 		return nil, err
 	}
 
 	if db != nil {
+		// the identifier may be taken by the instance of another postgresdb
+		if !db.OwnedBy(req.OwnerUID) {
+			return nil, &database.OwnershipError{ID: req.ID, OwnerUID: db.OwnerUID}
+		}
+		l.Debug("database already exists", "status", db.Status)
 		return db, nil
 	}
 
 	// create the database with master credential
+	l.Info("creating database", "instance-class", req.InstanceClass, "engine-version", req.EngineVersion, "storage-gb", req.Storage, "region", req.Location.Region)
+	_, span := trace.StartClient(ctx, "rds.CreateDBInstance", "db-id", req.ID, "region", req.Location.Region, "instance-class", req.InstanceClass)
 	db, err = i.CreateDB(req, cred)
+	span.End(err)
 	if err != nil {
 		return nil, err
 	}
@@ -77,29 +130,38 @@ func CreateDatabaseIfNotExist(i DBCreateGetter, req *database.Request, cred *dat
 	return db, nil
 }
 
-func WaitForDBToBeAvailable(i DBGetter, id database.DatabaseID, checkIntervalMillis int) (*database.Database, error) {
-
-	numberOfChecks := 10
-	tick := time.Tick(time.Duration(checkIntervalMillis) * time.Millisecond)
-	timeout := time.After(time.Duration(numberOfChecks*checkIntervalMillis) * time.Millisecond)
+// getDB gets the db in a span of the DescribeDBInstances call
+func getDB(ctx context.Context, i DBGetter, loc database.Location, id database.DatabaseID) (*database.Database, error) {
+	_, span := trace.StartClient(ctx, "rds.DescribeDBInstances", "db-id", id, "region", loc.Region)
+	db, err := i.GetDB(loc, id)
+	if db != nil {
+		span.SetAttributes("status", db.Status.String())
+	}
+	span.End(err)
+	return db, err
+}
 
-	for {
-		select {
-		case <-timeout:
-			return nil, fmt.Errorf("database unavailable in %d milliseconds", numberOfChecks*checkIntervalMillis)
-		case <-tick:
-			db, err := i.GetDB(id)
-			if err != nil {
-				return nil, err
-			}
-			ok := verifyDBStatus(db.Status)
-			if ok {
-				return db, nil
-			}
+// CheckDBAvailability gets the db and reports whether it is available, the db
+// is returned along with an error when it reached a status it will not recover from
+func CheckDBAvailability(ctx context.Context, i DBGetter, loc database.Location, id database.DatabaseID, l log.Logger) (*database.Database, bool, error) {
+	db, err := getDB(ctx, i, loc, id)
+	if err != nil {
+		return nil, false, err
+	}
+	if db == nil {
+		return nil, false, fmt.Errorf("database %s does not exist", id)
+	}
 
-		}
+	l.Debug("checked database", "status", db.Status)
+	if verifyDBStatus(db.Status) {
+		return db, true, nil
 	}
 
+	// no point waiting for a db that will not become available on its own
+	if !db.Status.IsTransitional() {
+		return db, false, fmt.Errorf("database will not become available: %s", database.GetMessageForStatus(db.Status))
+	}
+	return db, false, nil
 }
 
 func StoreDBCredentials(i CredentialsStorer, creds *database.Credentials) error {
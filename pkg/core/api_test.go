@@ -1,11 +1,13 @@
 package core
 
 import (
+	"context"
 	"testing"
 
 	"fmt"
 
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -17,9 +19,9 @@ func TestCreateDatabaseIfNotExist_GetDBError(t *testing.T) {
 
 	i, _, req, cred := getCreateDBIfNotExistsScenario(ctrl)
 
-	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.ID).Return(nil, fmt.Errorf("error")).Times(1)
+	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.Location, req.ID).Return(nil, fmt.Errorf("error")).Times(1)
 
-	db, err := CreateDatabaseIfNotExist(i, req, cred)
+	db, err := CreateDatabaseIfNotExist(context.Background(), i, req, cred, log.Nop())
 	assert.NotNil(t, err)
 	assert.Nil(t, db)
 }
@@ -30,23 +32,39 @@ func TestCreateDatabaseIfNotExist_GetDBReturnExisting(t *testing.T) {
 
 	i, retDB, req, cred := getCreateDBIfNotExistsScenario(ctrl)
 
-	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.ID).Return(retDB, nil).Times(1)
+	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.Location, req.ID).Return(retDB, nil).Times(1)
 
-	db, err := CreateDatabaseIfNotExist(i, req, cred)
+	db, err := CreateDatabaseIfNotExist(context.Background(), i, req, cred, log.Nop())
 	assert.NotNil(t, db)
 	assert.Nil(t, err)
 }
 
+func TestCreateDatabaseIfNotExist_OwnedByAnother(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	i, retDB, req, cred := getCreateDBIfNotExistsScenario(ctrl)
+	req.OwnerUID = "1234"
+	retDB.OwnerUID = "5678"
+
+	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.Location, req.ID).Return(retDB, nil).Times(1)
+
+	db, err := CreateDatabaseIfNotExist(context.Background(), i, req, cred, log.Nop())
+	assert.Nil(t, db)
+	assert.IsType(t, &database.OwnershipError{}, err)
+	assert.EqualError(t, err, fmt.Sprintf("db instance %s is owned by postgresdb 5678", req.ID))
+}
+
 func TestCreateDatabaseIfNotExist_CreateDBError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	i, _, req, cred := getCreateDBIfNotExistsScenario(ctrl)
 
-	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.ID).Return(nil, nil).Times(1)
+	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.Location, req.ID).Return(nil, nil).Times(1)
 	i.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("error")).Times(1)
 
-	db, err := CreateDatabaseIfNotExist(i, req, cred)
+	db, err := CreateDatabaseIfNotExist(context.Background(), i, req, cred, log.Nop())
 	assert.NotNil(t, err)
 	assert.Nil(t, db)
 }
@@ -56,67 +74,81 @@ func TestCreateDatabaseIfNotExistHappyPath(t *testing.T) {
 	defer ctrl.Finish()
 
 	i, retDB, req, cred := getCreateDBIfNotExistsScenario(ctrl)
-	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.ID).Return(nil, nil).Times(1)
+	i.(*mocks.MockDBCreateGetter).EXPECT().GetDB(req.Location, req.ID).Return(nil, nil).Times(1)
 	i.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDB, nil).Times(1)
 
-	db, err := CreateDatabaseIfNotExist(i, req, cred)
+	l := log.NewRecorder()
+	db, err := CreateDatabaseIfNotExist(context.Background(), i, req, cred, l)
 	assert.Nil(t, err)
 	assert.Equal(t, db, retDB)
+	assert.Equal(t, []string{"creating database"}, l.Messages(log.InfoLevel))
 }
 
-// WaitForDBToAvailable
+// CheckDBAvailability
 
-func TestWaitForDBToBeAvailable_StraightAway(t *testing.T) {
+func TestCheckDBAvailability_Available(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	i := mocks.NewMockDBGetter(ctrl)
 	id := database.DatabaseID("test1")
-	retDB := getReturnDB(id, database.StatusAvailable)
+	retDBAvailable := getReturnDB(id, database.StatusAvailable)
 
-	i.EXPECT().GetDB(id).Return(retDB, nil).Times(1)
+	i.EXPECT().GetDB(database.Location{}, id).Return(retDBAvailable, nil).Times(1)
 
-	db, err := WaitForDBToBeAvailable(i, id, 1)
+	db, available, err := CheckDBAvailability(context.Background(), i, database.Location{}, id, log.Nop())
 	assert.Nil(t, err)
-	assert.Equal(t, db, retDB)
+	assert.True(t, available)
+	assert.Equal(t, retDBAvailable, db)
 }
 
-func TestWaitForDBToBeAvailable_AfterOneMili(t *testing.T) {
+func TestCheckDBAvailability_Creating(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	i := mocks.NewMockDBGetter(ctrl)
 	id := database.DatabaseID("test1")
-	retDBUnavailable := getReturnDB(id, database.StatusUnavailable)
-	retDBAvailable := getReturnDB(id, database.StatusAvailable)
+	retDBCreating := getReturnDB(id, database.StatusCreating)
 
-	gomock.InOrder(
-		i.EXPECT().GetDB(id).Return(retDBUnavailable, nil).Times(9),
-		i.EXPECT().GetDB(id).Return(retDBAvailable, nil).Times(1),
-	)
-	db, err := WaitForDBToBeAvailable(i, id, 5)
+	i.EXPECT().GetDB(database.Location{}, id).Return(retDBCreating, nil).Times(1)
 
+	db, available, err := CheckDBAvailability(context.Background(), i, database.Location{}, id, log.Nop())
 	assert.Nil(t, err)
-	assert.Equal(t, db, retDBAvailable)
+	assert.False(t, available)
+	assert.Equal(t, retDBCreating, db)
 }
 
-func TestWaitForDBToBeAvailable_NotBeforeTimeout(t *testing.T) {
+func TestCheckDBAvailability_Failed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	i := mocks.NewMockDBGetter(ctrl)
+	id := database.DatabaseID("test1")
+	retDBFailed := getReturnDB(id, database.StatusFailed)
+
+	i.EXPECT().GetDB(database.Location{}, id).Return(retDBFailed, nil).Times(1)
+
+	db, available, err := CheckDBAvailability(context.Background(), i, database.Location{}, id, log.Nop())
+	assert.NotNil(t, err)
+	assert.False(t, available)
+	assert.Equal(t, retDBFailed, db)
+}
 
+func TestCheckDBAvailability_NotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	i := mocks.NewMockDBGetter(ctrl)
 	id := database.DatabaseID("test1")
-	retDBUnavailable := getReturnDB(id, database.StatusUnavailable)
 
-	i.EXPECT().GetDB(id).Return(retDBUnavailable, nil).Times(10)
+	i.EXPECT().GetDB(database.Location{}, id).Return(nil, nil).Times(1)
 
-	db, err := WaitForDBToBeAvailable(i, id, 5)
+	db, _, err := CheckDBAvailability(context.Background(), i, database.Location{}, id, log.Nop())
 	assert.NotNil(t, err)
 	assert.Nil(t, db)
 }
 
-func TestWaitForDBToBeAvailable_GetDBError(t *testing.T) {
+func TestCheckDBAvailability_GetDBError(t *testing.T) {
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -124,10 +156,11 @@ func TestWaitForDBToBeAvailable_GetDBError(t *testing.T) {
 	i := mocks.NewMockDBGetter(ctrl)
 	id := database.DatabaseID("test1")
 
-	i.EXPECT().GetDB(id).Return(nil, fmt.Errorf("error")).Times(1)
+	i.EXPECT().GetDB(database.Location{}, id).Return(nil, fmt.Errorf("error")).Times(1)
 
-	db, err := WaitForDBToBeAvailable(i, id, 1)
+	db, available, err := CheckDBAvailability(context.Background(), i, database.Location{}, id, log.Nop())
 	assert.NotNil(t, err)
+	assert.False(t, available)
 	assert.Nil(t, db)
 }
 
@@ -250,12 +283,12 @@ func getCreateDBIfNotExistsScenario(ctrl *gomock.Controller) (DBCreateGetter, *d
 		ID: database.DatabaseID("test1"),
 	}
 	r := &database.Request{
-		Size:    database.SizeXSmall,
-		Storage: 5,
-		Name:    "test",
-		Owner:   "test",
-		HA:      false,
-		ID:      "banana",
+		InstanceClass: "db.t2.small",
+		Storage:       5,
+		Name:          "test",
+		Owner:         "test",
+		HA:            false,
+		ID:            "banana",
 	}
 	c := &database.Credential{
 		CredType: database.CredTypeAdmin,
@@ -3,55 +3,63 @@ package core
 import (
 	"crypto/rand"
 	"fmt"
-	"strings"
-	"unicode"
-)
+	"math/big"
 
-// genPasswords generates password of length l
-func GenPasswords(l int) (*string, error) {
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+)
 
-	p, err := genPassword(l)
-	if err != nil {
-		return nil, err
+// GenPassword generates a password satisfying a policy: the minimum number
+// of characters of each class are drawn from their class, the rest from all
+// of the policy's characters, and the result is shuffled
+func GenPassword(policy config.PasswordPolicy) (string, error) {
+	charset := policy.Charset()
+	if charset == "" || policy.Length <= 0 {
+		return "", fmt.Errorf("generate password: policy has no characters")
 	}
-	return &p, nil
-}
 
-// genPassword generates a password of length l
-func genPassword(l int) (string, error) {
-	var p string
-	var err error
-
-	// url non-encode characters: [0-9a-zA-Z$.+!*(),_-]
-	allow := &unicode.RangeTable{
-		R16: []unicode.Range16{
-			{0x0030, 0x0039, 1}, // '0' - '9'
-			{0x0021, 0x0021, 1}, // '!' - '!'
-			{0x0024, 0x0024, 1}, // '$' - '$'
-			{0x0028, 0x002e, 1}, // '(' - '.'
-			{0x0041, 0x005a, 1}, // 'A' - 'Z'
-			{0x005f, 0x005f, 1}, // '_' - '_'
-			{0x0061, 0x007a, 1}, // 'a' - 'z'
-		},
+	p := make([]byte, 0, policy.Length)
+	for _, class := range policy.Classes {
+		chars := policy.ClassChars(class)
+		for i := 0; i < policy.Minimum[class] && len(p) < policy.Length; i++ {
+			c, err := randomChar(chars)
+			if err != nil {
+				return "", err
+			}
+			p = append(p, c)
+		}
 	}
-
-	strip := func(r rune) rune {
-		if unicode.Is(allow, r) {
-			return r
+	for len(p) < policy.Length {
+		c, err := randomChar(charset)
+		if err != nil {
+			return "", err
 		}
-		return -1
+		p = append(p, c)
 	}
 
-	for len(p) < l {
-		b := make([]byte, l*2)
-		_, err = rand.Read(b)
+	// Fisher-Yates, so the minimum characters are not all up front
+	for i := len(p) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
 		if err != nil {
-			err = fmt.Errorf("generate password: %v", err)
 			return "", err
 		}
+		p[i], p[j] = p[j], p[i]
+	}
+	return string(p), nil
+}
+
+func randomChar(chars string) (byte, error) {
+	i, err := randomInt(len(chars))
+	if err != nil {
+		return 0, err
+	}
+	return chars[i], nil
+}
 
-		p += strings.Map(strip, string(b))
+// randomInt returns a uniform random int in [0, n)
+func randomInt(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, fmt.Errorf("generate password: %v", err)
 	}
-	p = p[:l]
-	return p, nil
+	return int(i.Int64()), nil
 }
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var classes = []string{config.ClassLower, config.ClassUpper, config.ClassDigit, config.ClassSymbol}
+
+// policyFrom turns random values into a policy, which may not be valid
+func policyFrom(length uint8, classMask uint8, minimum [4]uint8, ambiguous bool) config.PasswordPolicy {
+	p := config.PasswordPolicy{
+		Length:           8 + int(length)%121,
+		Minimum:          map[string]int{},
+		ExcludeAmbiguous: ambiguous,
+	}
+	for i, class := range classes {
+		if classMask&(1<<uint(i)) != 0 {
+			p.Classes = append(p.Classes, class)
+			p.Minimum[class] = int(minimum[i]) % 8
+		}
+	}
+	return p
+}
+
+// checkPassword reports whether a password satisfies its policy
+func checkPassword(p config.PasswordPolicy, pw string) bool {
+	if len(pw) != p.Length {
+		return false
+	}
+	for _, class := range p.Classes {
+		n := 0
+		for _, r := range pw {
+			if strings.ContainsRune(p.ClassChars(class), r) {
+				n++
+			}
+		}
+		if n < p.Minimum[class] {
+			return false
+		}
+	}
+	for _, r := range pw {
+		if !strings.ContainsRune(p.Charset(), r) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGenPassword_SatisfiesPolicy(t *testing.T) {
+	valid := 0
+	property := func(length, classMask uint8, minimum [4]uint8, ambiguous bool) bool {
+		p := policyFrom(length, classMask, minimum, ambiguous)
+		if (config.Passwords{Default: p}).Validate() != nil {
+			return true
+		}
+		valid++
+
+		pw, err := GenPassword(p)
+		return err == nil && checkPassword(p, pw)
+	}
+
+	assert.Nil(t, quick.Check(property, &quick.Config{MaxCount: 2000}))
+	assert.NotZero(t, valid)
+}
+
+func TestGenPassword_URLSafe(t *testing.T) {
+	property := func(length, classMask uint8, minimum [4]uint8, ambiguous bool) bool {
+		p := policyFrom(length, classMask, minimum, ambiguous)
+		if len(p.Classes) == 0 {
+			return true
+		}
+		p.Minimum = nil
+
+		pw, err := GenPassword(p)
+		if err != nil {
+			return false
+		}
+		// the password goes into DATABASE_URL unescaped, and RDS takes it as a master password
+		return url.UserPassword("master", pw).String() == "master:"+pw &&
+			!strings.ContainsAny(pw, `/"@' `)
+	}
+
+	assert.Nil(t, quick.Check(property, &quick.Config{MaxCount: 2000}))
+}
+
+func TestGenPassword_ExcludesAmbiguous(t *testing.T) {
+	p := config.PasswordPolicy{Length: 128, Classes: classes, ExcludeAmbiguous: true}
+	for i := 0; i < 20; i++ {
+		pw, err := GenPassword(p)
+		assert.Nil(t, err)
+		assert.False(t, strings.ContainsAny(pw, "0Oo1lI"), pw)
+	}
+}
+
+func TestGenPassword_NoCharacters(t *testing.T) {
+	_, err := GenPassword(config.PasswordPolicy{Length: 30})
+	assert.NotNil(t, err)
+}
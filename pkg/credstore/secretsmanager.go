@@ -0,0 +1,131 @@
+package credstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/awsclient/secretsmanager"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+)
+
+// SecretsManager keeps credentials in AWS Secrets Manager secrets named
+// <prefix>/<namespace>/<secret name>, holding the payload of their kubernetes
+// secret as JSON
+type SecretsManager struct {
+	client secretsmanager.SecretsManagerAPI
+	config config.Getter
+}
+
+// NewSecretsManager returns a SecretsManager store of the secrets of client
+func NewSecretsManager(client secretsmanager.SecretsManagerAPI, c config.Getter) *SecretsManager {
+	return &SecretsManager{client: client, config: c}
+}
+
+// GetCred returns the credential in a secret, or nil if there is no secret
+func (s *SecretsManager) GetCred(credScope database.Scope, id database.CredentialID) (*database.Credential, error) {
+	out, err := s.client.GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(s.name(credScope, id)),
+	})
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]string{}
+	if err := json.Unmarshal([]byte(aws.StringValue(out.SecretString)), &data); err != nil {
+		return nil, fmt.Errorf("secret %s is not a credential: %v", aws.StringValue(out.Name), err)
+	}
+	cred := k8s.CredentialFromData(data)
+	cred.ID = id
+	cred.Scope = credScope
+	return cred, nil
+}
+
+// CreateCred creates the tagged secret of a credential
+func (s *SecretsManager) CreateCred(credential *database.Credential) error {
+	value, err := s.value(credential)
+	if err != nil {
+		return err
+	}
+	in := &secretsmanager.CreateSecretInput{
+		Name:         aws.String(s.name(credential.Scope, credential.ID)),
+		Description:  aws.String(fmt.Sprintf("credentials of kubernetes secret %s/%s", credential.Scope, credential.ID)),
+		SecretString: aws.String(value),
+		Tags:         smTags(credential),
+	}
+	if key := s.config.Get().CredentialStores.SecretsManager.KMSKeyID; key != "" {
+		in.KmsKeyId = aws.String(key)
+	}
+	_, err = s.client.CreateSecret(in)
+	return err
+}
+
+// UpdateCred stores the credential as the current value of its secret
+func (s *SecretsManager) UpdateCred(credential *database.Credential) error {
+	value, err := s.value(credential)
+	if err != nil {
+		return err
+	}
+	name := aws.String(s.name(credential.Scope, credential.ID))
+	if _, err := s.client.PutSecretValue(&secretsmanager.PutSecretValueInput{
+		SecretId:     name,
+		SecretString: aws.String(value),
+	}); err != nil {
+		return err
+	}
+	_, err = s.client.TagResource(&secretsmanager.TagResourceInput{
+		SecretId: name,
+		Tags:     smTags(credential),
+	})
+	return err
+}
+
+// DeleteCred schedules the deletion of the secret of a credential after the
+// configured recovery window
+func (s *SecretsManager) DeleteCred(credential *database.Credential) error {
+	_, err := s.client.DeleteSecret(&secretsmanager.DeleteSecretInput{
+		SecretId:             aws.String(s.name(credential.Scope, credential.ID)),
+		RecoveryWindowInDays: aws.Int64(s.config.Get().CredentialStores.SecretsManager.RecoveryWindowDays),
+	})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *SecretsManager) name(credScope database.Scope, id database.CredentialID) string {
+	return path.Join(s.config.Get().CredentialStores.SecretsManager.Prefix, string(credScope), string(id))
+}
+
+func (s *SecretsManager) value(credential *database.Credential) (string, error) {
+	raw, err := json.Marshal(k8s.CredentialData(credential, s.config.Get().Secrets))
+	return string(raw), err
+}
+
+func smTags(cred *database.Credential) []*secretsmanager.Tag {
+	t := tags(cred)
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := make([]*secretsmanager.Tag, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, &secretsmanager.Tag{Key: aws.String(k), Value: aws.String(t[k])})
+	}
+	return out
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == secretsmanager.ErrCodeResourceNotFoundException
+}
@@ -0,0 +1,91 @@
+package credstore
+
+import (
+	"fmt"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+)
+
+// Store is an external store credentials are copied to
+type Store interface {
+	core.CredentialsStorer
+	core.CredsDeleter
+}
+
+// Syncer stores credentials in kubernetes secrets and copies them to the
+// external stores of their Stores
+type Syncer struct {
+	core.CredentialsStorer
+	stores map[string]Store
+}
+
+// NewSyncer returns a Syncer reading credentials from k and copying them to
+// stores, by name
+func NewSyncer(k core.CredentialsStorer, stores map[string]Store) *Syncer {
+	return &Syncer{CredentialsStorer: k, stores: stores}
+}
+
+// CreateCred creates the kubernetes secret of a credential and stores it
+// in its external stores
+func (s *Syncer) CreateCred(credential *database.Credential) error {
+	if err := s.CredentialsStorer.CreateCred(credential); err != nil {
+		return err
+	}
+	return s.copy(credential)
+}
+
+// UpdateCred updates the kubernetes secret of a credential and stores it
+// in its external stores
+func (s *Syncer) UpdateCred(credential *database.Credential) error {
+	if err := s.CredentialsStorer.UpdateCred(credential); err != nil {
+		return err
+	}
+	return s.copy(credential)
+}
+
+// DeleteCred removes a credential from its external stores, its kubernetes
+// secret is left alone
+func (s *Syncer) DeleteCred(credential *database.Credential) error {
+	for _, name := range credential.Stores {
+		store, err := s.store(name)
+		if err != nil {
+			return err
+		}
+		if err := store.DeleteCred(credential); err != nil {
+			return fmt.Errorf("unable to delete credential %s from %s: %v", credential.ID, name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) copy(credential *database.Credential) error {
+	for _, name := range credential.Stores {
+		store, err := s.store(name)
+		if err != nil {
+			return err
+		}
+		if err := core.StoreDBCredentials(store, &database.Credentials{credential.CredType: credential}); err != nil {
+			return fmt.Errorf("unable to store credential %s in %s: %v", credential.ID, name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) store(name string) (Store, error) {
+	store, ok := s.stores[name]
+	if !ok {
+		return nil, fmt.Errorf("credential store %s is not configured", name)
+	}
+	return store, nil
+}
+
+// tags describe a credential in the metadata of its external copies
+func tags(cred *database.Credential) map[string]string {
+	return map[string]string{
+		"deployed-with": "ops-kube-db-operator",
+		"db-name":       cred.DatabaseName,
+		"namespace":     string(cred.Scope),
+		"secret-name":   string(cred.ID),
+	}
+}
@@ -0,0 +1,52 @@
+package credstore
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncer(t *testing.T) {
+	k := fake.NewSimpleClientset()
+	sm := newFakeSecretsManager()
+	s := NewSyncer(k8s.NewStoreCreds(k), map[string]Store{
+		database.CredentialStoreSecretsManager: NewSecretsManager(sm, config.Fixed{Config: config.Default()}),
+	})
+	name := "ops-kube-db-operator/team/team-orders-appuser"
+
+	cred := testCred()
+	cred.Stores = []string{database.CredentialStoreSecretsManager}
+	assert.Nil(t, core.StoreDBCredentials(s, &database.Credentials{cred.CredType: cred}))
+
+	_, err := k.CoreV1().Secrets("team").Get("team-orders-appuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Contains(t, sm.values, name)
+
+	// the secret exists, the copy is updated
+	cred.Password = "rotated"
+	assert.Nil(t, core.StoreDBCredentials(s, &database.Credentials{cred.CredType: cred}))
+	assert.Contains(t, sm.values[name], "rotated")
+
+	assert.Nil(t, s.DeleteCred(cred))
+	assert.NotContains(t, sm.values, name)
+	_, err = k.CoreV1().Secrets("team").Get("team-orders-appuser", metav1.GetOptions{})
+	assert.Nil(t, err)
+}
+
+func TestSyncer_UnknownStore(t *testing.T) {
+	k := fake.NewSimpleClientset()
+	s := NewSyncer(k8s.NewStoreCreds(k), map[string]Store{})
+
+	cred := testCred()
+	cred.Stores = []string{database.CredentialStoreVault}
+	err := s.CreateCred(cred)
+
+	assert.Equal(t, "credential store vault is not configured", err.Error())
+	assert.NotNil(t, s.DeleteCred(cred))
+}
@@ -0,0 +1,75 @@
+package credstore
+
+import (
+	"path"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/vault"
+)
+
+// Vault keeps credentials in a Vault KV version 2 engine at
+// <prefix>/<namespace>/<secret name>, holding the payload of their kubernetes
+// secret, the tags of the secret are its custom metadata
+type Vault struct {
+	client *vault.Client
+	config config.Getter
+}
+
+// NewVault returns a Vault store of the secrets of client
+func NewVault(client *vault.Client, c config.Getter) *Vault {
+	return &Vault{client: client, config: c}
+}
+
+type vaultSecret struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+// GetCred returns the credential of the latest version of a secret, or nil
+// if there is no secret or its latest version is deleted
+func (v *Vault) GetCred(credScope database.Scope, id database.CredentialID) (*database.Credential, error) {
+	secret := &vaultSecret{}
+	found, err := v.client.Do("GET", v.path("data", credScope, id), nil, secret)
+	if err != nil || !found || secret.Data.Data == nil {
+		return nil, err
+	}
+	cred := k8s.CredentialFromData(secret.Data.Data)
+	cred.ID = id
+	cred.Scope = credScope
+	return cred, nil
+}
+
+// CreateCred writes the first version of the secret of a credential
+func (v *Vault) CreateCred(credential *database.Credential) error {
+	return v.write(credential)
+}
+
+// UpdateCred writes a new version of the secret of a credential
+func (v *Vault) UpdateCred(credential *database.Credential) error {
+	return v.write(credential)
+}
+
+// DeleteCred removes every version of the secret of a credential
+func (v *Vault) DeleteCred(credential *database.Credential) error {
+	_, err := v.client.Do("DELETE", v.path("metadata", credential.Scope, credential.ID), nil, nil)
+	return err
+}
+
+func (v *Vault) write(credential *database.Credential) error {
+	data := map[string]interface{}{"data": k8s.CredentialData(credential, v.config.Get().Secrets)}
+	if _, err := v.client.Do("POST", v.path("data", credential.Scope, credential.ID), data, nil); err != nil {
+		return err
+	}
+	metadata := map[string]interface{}{"custom_metadata": tags(credential)}
+	_, err := v.client.Do("POST", v.path("metadata", credential.Scope, credential.ID), metadata, nil)
+	return err
+}
+
+// path is the data or metadata endpoint of the secret of a credential
+func (v *Vault) path(endpoint string, credScope database.Scope, id database.CredentialID) string {
+	cfg := v.config.Get().CredentialStores.Vault
+	return path.Join(cfg.Mount, endpoint, cfg.Prefix, string(credScope), string(id))
+}
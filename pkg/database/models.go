@@ -1,9 +1,24 @@
 package database
 
+import "fmt"
+
 const (
 	StatusAvailable Status = iota
 	StatusUnavailable
+	// StatusErrored is an error of the operator, eg. an invalid spec or a failed api call
 	StatusErrored
+	StatusCreating
+	StatusModifying
+	StatusBackingUp
+	StatusMaintenance
+	StatusRebooting
+	StatusStarting
+	StatusStopping
+	StatusStopped
+	StatusStorageFull
+	// StatusFailed is an instance RDS reports as failed or unusable
+	StatusFailed
+	StatusDeleting
 )
 
 const (
@@ -14,10 +29,32 @@ const (
 	CredTypeMonitoring
 )
 
+const (
+	// CredentialStoreSecretsManager copies credentials to AWS Secrets Manager
+	CredentialStoreSecretsManager = "secretsmanager"
+	// CredentialStoreVault copies credentials to a HashiCorp Vault KV engine
+	CredentialStoreVault = "vault"
+)
+
+// GetCredentialStores returns the external stores app credentials can be copied to
+func GetCredentialStores() []string {
+	return []string{CredentialStoreSecretsManager, CredentialStoreVault}
+}
+
 func GetAllCredentialTypes() []CredentialType {
 	return []CredentialType{CredTypeAdmin, CredTypeAppUser, CredTypeAppAdmin, CredTypeAppReadOnly, CredTypeMonitoring}
 }
 
+// GetAppCredentialTypes returns the credential types stored in the namespace of a postgresdb for its apps
+func GetAppCredentialTypes() []CredentialType {
+	return []CredentialType{CredTypeAppUser, CredTypeAppAdmin, CredTypeAppReadOnly}
+}
+
+// GetCredentialID returns the name of the secret of a credential type of a postgresdb
+func GetCredentialID(owner, name string, t CredentialType) CredentialID {
+	return CredentialID(fmt.Sprintf("%s-%s-%s", owner, name, GetUserNameForType(t)))
+}
+
 func GetUserNameForType(t CredentialType) string {
 	switch t {
 	case CredTypeAdmin:
@@ -59,21 +96,76 @@ type DatabaseID string
 
 type Scope string
 
+// Location is the AWS region and account of a database, empty fields are the
+// operator's own region and account
+type Location struct {
+	Region  string
+	Account string
+}
+
 type Request struct {
-	ID       DatabaseID
-	Name     string
-	Storage  int64
-	Size     Size
-	HA       bool
-	Metadata map[string]string
-	Owner    string
+	ID                  DatabaseID
+	Location            Location
+	Name                string
+	Storage             int64
+	StorageType         string
+	Iops                int64
+	InstanceClass       string
+	EngineVersion       string
+	ParameterGroup      string
+	HA                  bool
+	Metadata            map[string]string
+	Owner               string
+	OwnerUID            string
+	BackupRetentionDays *int64
+	BackupWindow        string
+	MaintenanceWindow   string
+	SubnetGroup         string
+	SecurityGroupIDs    []string
+	PubliclyAccessible  bool
+	// IAMAuthentication enables IAM database authentication for the app user
+	IAMAuthentication bool
+	// KMSKeyID is the customer managed key encrypting the database, the AWS
+	// managed key is used if it is empty
+	KMSKeyID string
+	// ManagedSecurityGroup is the security group the operator creates for the
+	// database, nil if it has none
+	ManagedSecurityGroup *SecurityGroupRequest
+	// CredentialStores are the external stores the app credentials are
+	// copied to next to their kubernetes secrets
+	CredentialStores []string
+	// VaultDynamicCredentials sets up a Vault database secrets engine for the database
+	VaultDynamicCredentials bool
+}
+
+// SecurityGroupRequest describes the security group managed for a database
+type SecurityGroupRequest struct {
+	Ingress []IngressRule
+}
+
+// IngressRule allows connections from a CIDR or from the namespaces matching a label selector
+type IngressRule struct {
+	CIDR              string
+	NamespaceSelector string
 }
 
 type StatusRequest struct {
 	Name string
 	Status
-	ID *DatabaseID
+	ID       *DatabaseID
+	Endpoint string
 	Scope
+	// TraceID is the trace of the reconcile updating the status, if traced
+	TraceID string
+	// TagKeys are the keys of spec.tags set on the instance, they are
+	// recorded unless nil
+	TagKeys []string
+	// Generation is the generation of the spec a failure was recorded for
+	Generation int64
+	// Reason and Message replace the reason and message of the status in its
+	// conditions when set, eg. with why the database could not be created
+	Reason  string
+	Message string
 }
 
 type Credential struct {
@@ -85,6 +177,11 @@ type Credential struct {
 	DatabaseName string
 	CredType     CredentialType
 	Scope
+	// Stores are the external stores the credential is copied to
+	Stores []string
+	// VaultCredsPath is where apps read short lived credentials of the same
+	// role from Vault, empty without dynamic credentials
+	VaultCredsPath string
 }
 
 type Database struct {
@@ -98,6 +195,57 @@ type Database struct {
 	Host        string
 	Port        int64
 	Owner       string
+	// OwnerUID is the uid of the postgresdb the instance is tagged with, empty
+	// for instances created before they were tagged
+	OwnerUID string
+}
+
+// Endpoint returns the host:port the database can be reached on, or an
+// empty string when the instance has no endpoint yet
+func (d *Database) Endpoint() string {
+	if d.Host == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", d.Host, d.Port)
+}
+
+// OwnedBy returns false if the database is tagged with the uid of another
+// postgresdb, untagged databases are owned by the postgresdb they are named after
+func (d *Database) OwnedBy(uid string) bool {
+	return d.OwnerUID == "" || uid == "" || d.OwnerUID == uid
+}
+
+// OwnershipError is the error of a request for a database whose identifier
+// is taken by the instance of another postgresdb
+type OwnershipError struct {
+	ID       DatabaseID
+	OwnerUID string
+}
+
+func (e *OwnershipError) Error() string {
+	return fmt.Sprintf("db instance %s is owned by postgresdb %s", e.ID, e.OwnerUID)
+}
+
+// InvalidSpecError is the error of a request for a postgresdb whose spec is
+// invalid, it fails until the spec is changed
+type InvalidSpecError struct {
+	Err error
+}
+
+func (e *InvalidSpecError) Error() string {
+	return e.Err.Error()
+}
+
+// QuotaExceededError is the error of a request for a postgresdb that would
+// take its namespace over a PostgresDBQuota, it goes away once the quota or
+// the usage of the namespace changes
+type QuotaExceededError struct {
+	Quota string
+	Limit string
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("postgresdb quota %s exceeded: %s", e.Quota, e.Limit)
 }
 
 func GetMessageForStatus(s Status) string {
@@ -108,8 +256,74 @@ func GetMessageForStatus(s Status) string {
 		return "db currently unavailable"
 	case StatusErrored:
 		return "unable to create db"
+	case StatusCreating:
+		return "db is being created"
+	case StatusModifying:
+		return "db is being modified"
+	case StatusBackingUp:
+		return "db is being backed up"
+	case StatusMaintenance:
+		return "db is under maintenance"
+	case StatusRebooting:
+		return "db is rebooting"
+	case StatusStarting:
+		return "db is starting"
+	case StatusStopping:
+		return "db is stopping"
+	case StatusStopped:
+		return "db is stopped"
+	case StatusStorageFull:
+		return "db storage is full"
+	case StatusFailed:
+		return "db has failed"
+	case StatusDeleting:
+		return "db is being deleted"
 	default:
 		return "db currently unavailable"
 	}
 
 }
+
+var statusNames = map[Status]string{
+	StatusAvailable:   "Available",
+	StatusUnavailable: "Unavailable",
+	StatusErrored:     "Errored",
+	StatusCreating:    "Creating",
+	StatusModifying:   "Modifying",
+	StatusBackingUp:   "BackingUp",
+	StatusMaintenance: "Maintenance",
+	StatusRebooting:   "Rebooting",
+	StatusStarting:    "Starting",
+	StatusStopping:    "Stopping",
+	StatusStopped:     "Stopped",
+	StatusStorageFull: "StorageFull",
+	StatusFailed:      "Failed",
+	StatusDeleting:    "Deleting",
+}
+
+func (s Status) String() string {
+	if n, ok := statusNames[s]; ok {
+		return n
+	}
+	return statusNames[StatusUnavailable]
+}
+
+// IsTransitional returns true for statuses RDS moves out of on its own,
+// waiting for the db is worth it
+func (s Status) IsTransitional() bool {
+	switch s {
+	case StatusUnavailable, StatusCreating, StatusModifying, StatusBackingUp,
+		StatusMaintenance, StatusRebooting, StatusStarting, StatusStopping:
+		return true
+	}
+	return false
+}
+
+// IsFailure returns true for statuses that need someone to intervene
+func (s Status) IsFailure() bool {
+	switch s {
+	case StatusErrored, StatusFailed, StatusStorageFull:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/golang/glog"
+)
+
+// Source delivers the ids of databases RDS published an event for
+type Source interface {
+	Run(stopCh <-chan struct{}, ids chan<- database.DatabaseID)
+}
+
+// SQSSource reads RDS event notifications from an SQS queue subscribed to the
+// SNS topic of an RDS event subscription
+type SQSSource struct {
+	client   sqsiface.SQSAPI
+	queueURL string
+	backoff  time.Duration
+}
+
+// NewSQSSource returns a Source long polling the given queue
+func NewSQSSource(client sqsiface.SQSAPI, queueURL string) *SQSSource {
+	return &SQSSource{
+		client:   client,
+		queueURL: queueURL,
+		backoff:  10 * time.Second,
+	}
+}
+
+// snsEnvelope is the SNS notification wrapping the RDS event
+type snsEnvelope struct {
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+type rdsEvent struct {
+	EventSource string `json:"Event Source"`
+	SourceID    string `json:"Source ID"`
+	EventID     string `json:"Event ID"`
+	Message     string `json:"Event Message"`
+}
+
+// Run polls the queue until stopCh is closed
+func (s *SQSSource) Run(stopCh <-chan struct{}, ids chan<- database.DatabaseID) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		out, err := s.client.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.queueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(20),
+		})
+		if err != nil {
+			glog.Errorf("unable to receive rds events from %s: %v", s.queueURL, err)
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(s.backoff):
+			}
+			continue
+		}
+
+		for _, m := range out.Messages {
+			if id, ok := parseMessage(aws.StringValue(m.Body)); ok {
+				select {
+				case ids <- id:
+				case <-stopCh:
+					return
+				}
+			}
+
+			// events are only hints, a lost one is picked up by the next availability check
+			_, err := s.client.DeleteMessage(&sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(s.queueURL),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+			if err != nil {
+				glog.Errorf("unable to delete rds event from %s: %v", s.queueURL, err)
+			}
+		}
+	}
+}
+
+// parseMessage returns the db instance an RDS event notification is about
+func parseMessage(body string) (database.DatabaseID, bool) {
+	raw := body
+	env := &snsEnvelope{}
+	if err := json.Unmarshal([]byte(body), env); err == nil && env.Type == "Notification" {
+		raw = env.Message
+	}
+
+	event := &rdsEvent{}
+	if err := json.Unmarshal([]byte(raw), event); err != nil {
+		glog.Warningf("ignoring malformed rds event: %v", err)
+		return "", false
+	}
+	if event.EventSource != "db-instance" || event.SourceID == "" {
+		return "", false
+	}
+	return database.DatabaseID(event.SourceID), true
+}
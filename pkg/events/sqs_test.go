@@ -0,0 +1,97 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+const snsNotification = `{"Type":"Notification","MessageId":"1",
+	"Message":"{\"Event Source\":\"db-instance\",\"Event Time\":\"2018-03-01 10:00:00.000\",\"Source ID\":\"test-db\",\"Event ID\":\"http://docs.amazonwebservices.com/AmazonRDS/latest/UserGuide/USER_Events.html#RDS-EVENT-0005\",\"Event Message\":\"DB instance created\"}"}`
+
+type fakeSQS struct {
+	sqsiface.SQSAPI
+	messages [][]*sqs.Message
+	deleted  []string
+	err      error
+}
+
+func (f *fakeSQS) ReceiveMessage(in *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if len(f.messages) == 0 {
+		time.Sleep(time.Millisecond)
+		return &sqs.ReceiveMessageOutput{}, nil
+	}
+	m := f.messages[0]
+	f.messages = f.messages[1:]
+	return &sqs.ReceiveMessageOutput{Messages: m}, nil
+}
+
+func (f *fakeSQS) DeleteMessage(in *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	f.deleted = append(f.deleted, aws.StringValue(in.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func TestParseMessage(t *testing.T) {
+	id, ok := parseMessage(snsNotification)
+	assert.True(t, ok)
+	assert.Equal(t, database.DatabaseID("test-db"), id)
+
+	id, ok = parseMessage(`{"Event Source":"db-instance","Source ID":"raw-db"}`)
+	assert.True(t, ok)
+	assert.Equal(t, database.DatabaseID("raw-db"), id)
+
+	_, ok = parseMessage(`{"Event Source":"db-snapshot","Source ID":"snap"}`)
+	assert.False(t, ok)
+
+	_, ok = parseMessage("banana")
+	assert.False(t, ok)
+}
+
+func TestSQSSource_Run(t *testing.T) {
+	client := &fakeSQS{messages: [][]*sqs.Message{{
+		{Body: aws.String(snsNotification), ReceiptHandle: aws.String("1")},
+		{Body: aws.String("banana"), ReceiptHandle: aws.String("2")},
+	}}}
+	s := NewSQSSource(client, "https://sqs/queue")
+
+	stopCh := make(chan struct{})
+	ids := make(chan database.DatabaseID)
+	go s.Run(stopCh, ids)
+
+	select {
+	case id := <-ids:
+		assert.Equal(t, database.DatabaseID("test-db"), id)
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	close(stopCh)
+}
+
+func TestSQSSource_ReceiveError(t *testing.T) {
+	client := &fakeSQS{err: fmt.Errorf("boom")}
+	s := NewSQSSource(client, "https://sqs/queue")
+	s.backoff = time.Millisecond
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.Run(stopCh, make(chan database.DatabaseID))
+		close(done)
+	}()
+	close(stopCh)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("source did not stop")
+	}
+}
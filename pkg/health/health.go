@@ -0,0 +1,104 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Checks serves the liveness and readiness probes of the operator
+type Checks struct {
+	mu    sync.Mutex
+	names []string
+	ready map[string]func() error
+}
+
+// New returns Checks without ready checks, the operator is ready until one is added
+func New() *Checks {
+	return &Checks{ready: map[string]func() error{}}
+}
+
+// AddReadyCheck adds a check /readyz fails while it returns an error
+func (c *Checks) AddReadyCheck(name string, check func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.ready[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.ready[name] = check
+}
+
+// Ready returns the errors of the failing ready checks by name
+func (c *Checks) Ready() map[string]error {
+	c.mu.Lock()
+	names := append([]string{}, c.names...)
+	checks := make([]func() error, len(names))
+	for i, name := range names {
+		checks[i] = c.ready[name]
+	}
+	c.mu.Unlock()
+
+	failing := map[string]error{}
+	for i, check := range checks {
+		if err := check(); err != nil {
+			failing[names[i]] = err
+		}
+	}
+	return failing
+}
+
+// Handler serves /healthz, which succeeds while the operator serves http at
+// all, and /readyz, which lists the failing ready checks
+func (c *Checks) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		failing := c.Ready()
+		if len(failing) == 0 {
+			fmt.Fprintln(w, "ok")
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		c.mu.Lock()
+		names := append([]string{}, c.names...)
+		c.mu.Unlock()
+		for _, name := range names {
+			if err, ok := failing[name]; ok {
+				fmt.Fprintf(w, "%s: %v\n", name, err)
+			}
+		}
+	})
+	return mux
+}
+
+// Flag is a ready check that fails until it is set
+type Flag struct {
+	mu  sync.Mutex
+	set bool
+	err error
+}
+
+// NewFlag returns a Flag failing with message until it is set
+func NewFlag(message string) *Flag {
+	return &Flag{err: errors.New(message)}
+}
+
+// Set makes the check succeed
+func (f *Flag) Set() {
+	f.mu.Lock()
+	f.set = true
+	f.mu.Unlock()
+}
+
+// Check is the ready check of the flag
+func (f *Flag) Check() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.set {
+		return nil
+	}
+	return f.err
+}
@@ -0,0 +1,41 @@
+package health
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestChecks_Readyz(t *testing.T) {
+	c := New()
+	synced := NewFlag("caches not synced yet")
+	c.AddReadyCheck("aws", func() error { return errors.New("access denied") })
+	c.AddReadyCheck("caches", synced.Check)
+
+	w := get(c.Handler(), "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "aws: access denied\ncaches: caches not synced yet\n", w.Body.String())
+
+	synced.Set()
+	c.AddReadyCheck("aws", func() error { return nil })
+	w = get(c.Handler(), "/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, c.Ready())
+}
+
+func TestChecks_Healthz(t *testing.T) {
+	c := New()
+	c.AddReadyCheck("caches", NewFlag("caches not synced yet").Check)
+
+	w := get(c.Handler(), "/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
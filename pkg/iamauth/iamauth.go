@@ -0,0 +1,114 @@
+package iamauth
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Granter grants a user of a database IAM database authentication
+type Granter interface {
+	GrantIAMRole(master *database.Credential, user string) error
+}
+
+// Signer signs IAM auth tokens for a user of the database at endpoint
+type Signer interface {
+	AuthToken(loc database.Location, endpoint, user string) (string, error)
+}
+
+// Authenticator grants IAM database authentication and signs auth tokens
+type Authenticator struct {
+	Granter
+	Signer
+}
+
+// New returns an Authenticator granting with g and signing with s
+func New(g Granter, s Signer) *Authenticator {
+	return &Authenticator{Granter: g, Signer: s}
+}
+
+// Refresher replaces the auth tokens in the app secrets of the available
+// postgresdbs with IAM authentication before they expire
+type Refresher struct {
+	dbs    listers.PostgresDBLister
+	creds  core.CredentialsStorer
+	signer Signer
+	config config.Getter
+}
+
+// NewRefresher returns a Refresher of the postgresdbs of the lister
+func NewRefresher(dbs listers.PostgresDBLister, c core.CredentialsStorer, s Signer, cfg config.Getter) *Refresher {
+	return &Refresher{dbs: dbs, creds: c, signer: s, config: cfg}
+}
+
+// Run refreshes the tokens every iamAuth.tokenRefreshInterval of the
+// configuration until stopCh is closed
+func (r *Refresher) Run(stopCh <-chan struct{}) {
+	for {
+		r.RefreshAll()
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(r.config.Get().IAMAuth.TokenRefreshInterval.Duration):
+		}
+	}
+}
+
+// RefreshAll refreshes the tokens of every postgresdb with IAM authentication
+func (r *Refresher) RefreshAll() {
+	dbs, err := r.dbs.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("unable to list postgresdbs: %v", err)
+		return
+	}
+	for _, db := range dbs {
+		if err := r.Refresh(db); err != nil {
+			glog.Errorf("unable to refresh auth token of postgresdb %s/%s: %v", db.Namespace, db.Name, err)
+		}
+	}
+}
+
+// Refresh stores a new token in the app secrets of a postgresdb, it does
+// nothing unless the database is available and uses IAM authentication
+func (r *Refresher) Refresh(db *v1beta1.PostgresDB) error {
+	if !db.Spec.IAMAuthentication || db.Status.Phase != database.StatusAvailable.String() {
+		return nil
+	}
+	host, portStr, err := net.SplitHostPort(db.Status.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint %q: %v", db.Status.Endpoint, err)
+	}
+	port, _ := strconv.ParseInt(portStr, 10, 64)
+
+	user := database.GetUserNameForType(database.CredTypeAppUser)
+	loc := database.Location{Region: db.Spec.Region, Account: db.Spec.AWSAccount}
+	token, err := r.signer.AuthToken(loc, db.Status.Endpoint, user)
+	if err != nil {
+		return err
+	}
+
+	creds := database.Credentials{}
+	for _, t := range database.GetAppCredentialTypes() {
+		creds[t] = &database.Credential{
+			ID:           database.GetCredentialID(db.Namespace, db.Name, t),
+			Scope:        database.Scope(db.Namespace),
+			CredType:     t,
+			Username:     user,
+			Password:     database.Password(token),
+			Host:         host,
+			Port:         port,
+			DatabaseName: "postgres",
+			Stores:       db.Spec.CredentialStores,
+		}
+	}
+	return core.StoreDBCredentials(r.creds, &creds)
+}
@@ -0,0 +1,69 @@
+package iamauth
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeSigner struct {
+	endpoints []string
+}
+
+func (f *fakeSigner) AuthToken(loc database.Location, endpoint, user string) (string, error) {
+	f.endpoints = append(f.endpoints, endpoint)
+	return "token-for-" + user, nil
+}
+
+func newDB(name string, iam bool, phase string) *v1beta1.PostgresDB {
+	db := &v1beta1.PostgresDB{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"}}
+	db.Spec.IAMAuthentication = iam
+	db.Status.Phase = phase
+	db.Status.Endpoint = name + ".rds.amazonaws.com:5432"
+	return db
+}
+
+func newRefresher(s Signer, f *fake.Clientset, dbs ...*v1beta1.PostgresDB) *Refresher {
+	i := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, db := range dbs {
+		i.Add(db)
+	}
+	return NewRefresher(listers.NewPostgresDBLister(i), k8s.NewStoreCreds(f), s, config.Fixed{Config: config.Default()})
+}
+
+func TestRefreshAll(t *testing.T) {
+	s := &fakeSigner{}
+	f := fake.NewSimpleClientset()
+
+	newRefresher(s, f,
+		newDB("orders", true, "Available"),
+		newDB("creating", true, "Creating"),
+		newDB("passwords", false, "Available"),
+	).RefreshAll()
+
+	assert.Equal(t, []string{"orders.rds.amazonaws.com:5432"}, s.endpoints)
+	for _, id := range []string{"team-orders-appuser", "team-orders-appadmin", "team-orders-appreadonly"} {
+		secret, err := f.CoreV1().Secrets("team").Get(id, metav1.GetOptions{})
+		assert.Nil(t, err, id)
+		assert.Equal(t, "appuser", secret.StringData[k8s.USER])
+		assert.Equal(t, "token-for-appuser", secret.StringData[k8s.PASSWORD])
+		assert.Equal(t, "orders.rds.amazonaws.com", secret.StringData[k8s.HOST])
+		assert.Equal(t, "5432", secret.StringData[k8s.PORT])
+	}
+}
+
+func TestRefresh_InvalidEndpoint(t *testing.T) {
+	db := newDB("orders", true, "Available")
+	db.Status.Endpoint = "nowhere"
+
+	err := newRefresher(&fakeSigner{}, fake.NewSimpleClientset()).Refresh(db)
+	assert.NotNil(t, err)
+}
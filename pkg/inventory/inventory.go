@@ -0,0 +1,258 @@
+package inventory
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Instance is an RDS instance of the inventory
+type Instance struct {
+	Region    string `json:"region,omitempty"`
+	Account   string `json:"account,omitempty"`
+	ID        string `json:"id"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	UID       string `json:"uid,omitempty"`
+}
+
+// Report is the outcome of an inventory
+type Report struct {
+	// Instances is the number of instances created by the operator
+	Instances int
+	// Orphaned are the instances whose postgresdb is gone
+	Orphaned []Instance
+	// Missing are the instances of postgresdbs that no longer exist
+	Missing []Instance
+}
+
+// Reconciler cross references the RDS instances created by the operator with
+// the postgresdbs, flagging instances without a postgresdb and postgresdbs
+// without an instance in metrics and a summary configmap
+type Reconciler struct {
+	lister    core.DBLister
+	dbs       listers.PostgresDBLister
+	client    kubernetes.Interface
+	config    config.Getter
+	namespace string
+	name      string
+	interval  time.Duration
+	logger    log.Logger
+	now       func() time.Time
+}
+
+// New returns a Reconciler writing its summary to the configmap name in
+// namespace every interval
+func New(l core.DBLister, dbs listers.PostgresDBLister, client kubernetes.Interface, cfg config.Getter, namespace, name string, interval time.Duration) *Reconciler {
+	return &Reconciler{
+		lister:    l,
+		dbs:       dbs,
+		client:    client,
+		config:    cfg,
+		namespace: namespace,
+		name:      name,
+		interval:  interval,
+		logger:    log.Default().With("component", "inventory"),
+		now:       time.Now,
+	}
+}
+
+// Run takes an inventory every interval until stopCh is closed
+func (r *Reconciler) Run(stopCh <-chan struct{}) {
+	wait.Until(func() {
+		if _, err := r.Reconcile(); err != nil {
+			r.logger.Error("unable to take inventory", "err", err)
+		}
+	}, r.interval, stopCh)
+}
+
+// Reconcile takes an inventory of the instances of every region and account
+// with postgresdbs, publishes it and returns it. Postgresdbs of regions and
+// accounts whose instances could not be listed are not reported missing.
+func (r *Reconciler) Reconcile() (*Report, error) {
+	crds, err := r.dbs.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := r.config.Get()
+	locations := map[database.Location]bool{{}: true}
+	for _, t := range cfg.AWS.Targets {
+		locations[normalise(cfg, database.Location{Region: t.Region, Account: t.Account})] = true
+	}
+	for _, crd := range crds {
+		locations[locationOf(cfg, crd)] = true
+	}
+
+	report := &Report{}
+	listed := map[database.Location]map[string]bool{}
+	byUID := map[string]*v1beta1.PostgresDB{}
+	for _, crd := range crds {
+		byUID[string(crd.UID)] = crd
+	}
+	for loc := range locations {
+		dbs, err := r.lister.ListDBs(loc)
+		if err != nil {
+			r.logger.Error("unable to list db instances", "region", loc.Region, "account", loc.Account, "err", err)
+			continue
+		}
+		ids := map[string]bool{}
+		for _, db := range dbs {
+			ids[string(db.ID)] = true
+			report.Instances++
+			if db.Status == database.StatusDeleting || owned(db, loc, byUID, crds, cfg) {
+				continue
+			}
+			report.Orphaned = append(report.Orphaned, Instance{
+				Region:    loc.Region,
+				Account:   loc.Account,
+				ID:        string(db.ID),
+				Namespace: db.Owner,
+				Name:      db.Name,
+				UID:       db.OwnerUID,
+			})
+		}
+		listed[loc] = ids
+	}
+
+	for _, crd := range crds {
+		// postgresdbs still to be created or being deleted have no instance
+		if crd.Status.ID == "" || crd.DeletionTimestamp != nil {
+			continue
+		}
+		loc := locationOf(cfg, crd)
+		ids, ok := listed[loc]
+		if !ok || ids[crd.Status.ID] {
+			continue
+		}
+		report.Missing = append(report.Missing, Instance{
+			Region:    loc.Region,
+			Account:   loc.Account,
+			ID:        crd.Status.ID,
+			Namespace: crd.Namespace,
+			Name:      crd.Name,
+			UID:       string(crd.UID),
+		})
+	}
+	sortInstances(report.Orphaned)
+	sortInstances(report.Missing)
+
+	r.publish(report)
+	if err := r.writeSummary(report); err != nil {
+		return report, err
+	}
+	r.logger.Info("took inventory", "instances", report.Instances, "orphaned", len(report.Orphaned), "missing", len(report.Missing))
+	return report, nil
+}
+
+// owned returns true if the postgresdb of an instance exists, instances are
+// matched by the uid they are tagged with or otherwise by their identifier
+func owned(db *database.Database, loc database.Location, byUID map[string]*v1beta1.PostgresDB, crds []*v1beta1.PostgresDB, cfg *config.Config) bool {
+	if db.OwnerUID != "" {
+		_, ok := byUID[db.OwnerUID]
+		return ok
+	}
+	for _, crd := range crds {
+		if crd.Status.ID == string(db.ID) && locationOf(cfg, crd) == loc {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reconciler) publish(report *Report) {
+	metrics.InventoryInstances.With().Set(float64(report.Instances))
+	orphaned := make([]metrics.Sample, len(report.Orphaned))
+	for i, o := range report.Orphaned {
+		orphaned[i] = metrics.Sample{Labels: []string{o.Region, o.Account, o.ID}, Value: 1}
+	}
+	metrics.OrphanedInstances.Collect(func() []metrics.Sample { return orphaned })
+	missing := make([]metrics.Sample, len(report.Missing))
+	for i, m := range report.Missing {
+		missing[i] = metrics.Sample{Labels: []string{m.Namespace, m.Name, m.ID}, Value: 1}
+	}
+	metrics.MissingInstances.Collect(func() []metrics.Sample { return missing })
+}
+
+// writeSummary creates or updates the summary configmap
+func (r *Reconciler) writeSummary(report *Report) error {
+	orphaned, err := json.Marshal(nonNil(report.Orphaned))
+	if err != nil {
+		return err
+	}
+	missing, err := json.Marshal(nonNil(report.Missing))
+	if err != nil {
+		return err
+	}
+	data := map[string]string{
+		"instances":   strconv.Itoa(report.Instances),
+		"orphaned":    string(orphaned),
+		"missing":     string(missing),
+		"lastUpdated": r.now().UTC().Format(time.RFC3339),
+	}
+
+	configMaps := r.client.CoreV1().ConfigMaps(r.namespace)
+	cm, err := configMaps.Get(r.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = configMaps.Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: r.name, Namespace: r.namespace},
+			Data:       data,
+		})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	cm = cm.DeepCopy()
+	cm.Data = data
+	_, err = configMaps.Update(cm)
+	return err
+}
+
+func locationOf(cfg *config.Config, crd *v1beta1.PostgresDB) database.Location {
+	return normalise(cfg, database.Location{Region: crd.Spec.Region, Account: crd.Spec.AWSAccount})
+}
+
+// normalise returns the empty location for the operator's own region and
+// account, so its instances are listed once
+func normalise(cfg *config.Config, loc database.Location) database.Location {
+	if loc.Region == cfg.AWS.Region && loc.Account == "" {
+		return database.Location{}
+	}
+	return loc
+}
+
+func sortInstances(instances []Instance) {
+	sort.Slice(instances, func(i, j int) bool {
+		a, b := instances[i], instances[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		return a.ID < b.ID
+	})
+}
+
+func nonNil(instances []Instance) []Instance {
+	if instances == nil {
+		return []Instance{}
+	}
+	return instances
+}
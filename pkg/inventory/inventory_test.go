@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeLister struct {
+	dbs    map[database.Location][]*database.Database
+	failed map[database.Location]bool
+}
+
+func (f *fakeLister) ListDBs(loc database.Location) ([]*database.Database, error) {
+	if f.failed[loc] {
+		return nil, errors.New("Throttling: Rate exceeded")
+	}
+	return f.dbs[loc], nil
+}
+
+func newPostgresDB(name, uid, id, region string) *v1beta1.PostgresDB {
+	db := &v1beta1.PostgresDB{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team", UID: types.UID(uid)},
+		Status:     v1beta1.PostgresDBStatus{ID: id},
+	}
+	db.Spec.Region = region
+	return db
+}
+
+func newReconciler(l *fakeLister, dbs ...*v1beta1.PostgresDB) (*Reconciler, *k8sfake.Clientset) {
+	factory := externalversions.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	informer := factory.Postgresdb().V1beta1().PostgresDBs()
+	for _, db := range dbs {
+		informer.Informer().GetIndexer().Add(db)
+	}
+	cfg := config.Default()
+	cfg.AWS.Region = "ap-southeast-2"
+	client := k8sfake.NewSimpleClientset()
+	r := New(l, informer.Lister(), client, config.Fixed{Config: cfg}, "kube-system", "postgresdb-inventory", time.Hour)
+	r.now = func() time.Time { return time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC) }
+	return r, client
+}
+
+func TestReconcile_OrphanedAndMissing(t *testing.T) {
+	own := database.Location{}
+	virginia := database.Location{Region: "us-east-1"}
+	l := &fakeLister{dbs: map[database.Location][]*database.Database{
+		own: {
+			{ID: "orders-8e60dae354", OwnerUID: "1234", Owner: "team", Name: "orders"},
+			{ID: "legacy-5678", Owner: "team", Name: "legacy"},
+			{ID: "billing-0f1e2d3c4b", OwnerUID: "9999", Owner: "team", Name: "billing"},
+			{ID: "gone-abcdef0123", OwnerUID: "8888", Status: database.StatusDeleting},
+		},
+		virginia: {},
+	}}
+	r, client := newReconciler(l,
+		newPostgresDB("orders", "1234", "orders-8e60dae354", "ap-southeast-2"),
+		newPostgresDB("legacy", "5678", "legacy-5678", ""),
+		newPostgresDB("reports", "4321", "reports-1a2b3c4d5e", "us-east-1"),
+		newPostgresDB("pending", "7777", "", ""),
+	)
+
+	report, err := r.Reconcile()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, report.Instances)
+	assert.Equal(t, []Instance{{ID: "billing-0f1e2d3c4b", Namespace: "team", Name: "billing", UID: "9999"}}, report.Orphaned)
+	assert.Equal(t, []Instance{{Region: "us-east-1", ID: "reports-1a2b3c4d5e", Namespace: "team", Name: "reports", UID: "4321"}}, report.Missing)
+
+	cm, err := client.CoreV1().ConfigMaps("kube-system").Get("postgresdb-inventory", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "4", cm.Data["instances"])
+	assert.Equal(t, "2018-03-01T10:00:00Z", cm.Data["lastUpdated"])
+	var orphaned []Instance
+	assert.Nil(t, json.Unmarshal([]byte(cm.Data["orphaned"]), &orphaned))
+	assert.Equal(t, report.Orphaned, orphaned)
+
+	assert.Equal(t, float64(4), metrics.InventoryInstances.With().Value())
+
+	// the configmap is updated once the orphan is removed
+	l.dbs[own] = l.dbs[own][:2]
+	_, err = r.Reconcile()
+	assert.Nil(t, err)
+	cm, err = client.CoreV1().ConfigMaps("kube-system").Get("postgresdb-inventory", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", cm.Data["instances"])
+	assert.Equal(t, "[]", cm.Data["orphaned"])
+}
+
+func TestReconcile_ListFailed(t *testing.T) {
+	l := &fakeLister{
+		dbs:    map[database.Location][]*database.Database{},
+		failed: map[database.Location]bool{{Region: "us-east-1"}: true},
+	}
+	r, _ := newReconciler(l, newPostgresDB("reports", "4321", "reports-1a2b3c4d5e", "us-east-1"))
+
+	report, err := r.Reconcile()
+	assert.Nil(t, err)
+	assert.Empty(t, report.Missing)
+}
@@ -0,0 +1,49 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ClassResolver looks up PostgresDBClasses in the informer cache
+type ClassResolver struct {
+	lister listers.PostgresDBClassLister
+}
+
+// NewClassResolver returns a ClassResolver reading from the given lister
+func NewClassResolver(l listers.PostgresDBClassLister) *ClassResolver {
+	return &ClassResolver{lister: l}
+}
+
+// Resolve returns the named class, or the class annotated as default if name
+// is empty. It returns nil without an error when there is no default class.
+func (r *ClassResolver) Resolve(name string) (*v1beta1.PostgresDBClass, error) {
+	if name != "" {
+		class, err := r.lister.Get(name)
+		if errors.IsNotFound(err) {
+			return nil, fmt.Errorf("postgresdb class %s does not exist", name)
+		}
+		return class, err
+	}
+
+	classes, err := r.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var def *v1beta1.PostgresDBClass
+	for _, c := range classes {
+		if c.Annotations[v1beta1.DefaultClassAnnotation] != "true" {
+			continue
+		}
+		if def != nil {
+			return nil, fmt.Errorf("postgresdb classes %s and %s are both marked as default", def.Name, c.Name)
+		}
+		def = c
+	}
+	return def, nil
+}
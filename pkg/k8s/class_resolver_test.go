@@ -0,0 +1,61 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newClassResolver(classes ...*v1beta1.PostgresDBClass) *ClassResolver {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, c := range classes {
+		indexer.Add(c)
+	}
+	return NewClassResolver(listers.NewPostgresDBClassLister(indexer))
+}
+
+func newClass(name string, isDefault bool) *v1beta1.PostgresDBClass {
+	c := &v1beta1.PostgresDBClass{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if isDefault {
+		c.Annotations = map[string]string{v1beta1.DefaultClassAnnotation: "true"}
+	}
+	return c
+}
+
+func TestClassResolver_ByName(t *testing.T) {
+	r := newClassResolver(newClass("production", false), newClass("standard", true))
+
+	c, err := r.Resolve("production")
+	assert.Nil(t, err)
+	assert.Equal(t, "production", c.Name)
+
+	_, err = r.Resolve("missing")
+	assert.NotNil(t, err)
+}
+
+func TestClassResolver_Default(t *testing.T) {
+	r := newClassResolver(newClass("production", false), newClass("standard", true))
+
+	c, err := r.Resolve("")
+	assert.Nil(t, err)
+	assert.Equal(t, "standard", c.Name)
+}
+
+func TestClassResolver_NoDefault(t *testing.T) {
+	r := newClassResolver(newClass("production", false))
+
+	c, err := r.Resolve("")
+	assert.Nil(t, err)
+	assert.Nil(t, c)
+}
+
+func TestClassResolver_MultipleDefaults(t *testing.T) {
+	r := newClassResolver(newClass("a", true), newClass("b", true))
+
+	_, err := r.Resolve("")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,381 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+)
+
+const crdPath = "/apis/apiextensions.k8s.io/v1beta1/customresourcedefinitions"
+
+// CRDFetcher returns the raw json of an installed CustomResourceDefinition
+type CRDFetcher interface {
+	FetchCRD(name string) ([]byte, error)
+}
+
+// CRDVersionSetter replaces the storedVersions in the status of a CustomResourceDefinition
+type CRDVersionSetter interface {
+	SetStoredVersions(name string, versions []string) error
+}
+
+type crdRESTClient struct {
+	client rest.Interface
+}
+
+// NewCRDFetcher returns a CRDFetcher reading CRDs through the given rest client,
+// usually the discovery client of the kubernetes clientset
+func NewCRDFetcher(client rest.Interface) CRDFetcher {
+	return &crdRESTClient{client: client}
+}
+
+// NewCRDVersionSetter returns a CRDVersionSetter patching CRDs through the given rest client
+func NewCRDVersionSetter(client rest.Interface) CRDVersionSetter {
+	return &crdRESTClient{client: client}
+}
+
+func (c *crdRESTClient) FetchCRD(name string) ([]byte, error) {
+	return c.client.Get().AbsPath(crdPath, name).DoRaw()
+}
+
+func (c *crdRESTClient) SetStoredVersions(name string, versions []string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"storedVersions": versions},
+	})
+	if err != nil {
+		return err
+	}
+	return c.client.Patch(types.MergePatchType).AbsPath(crdPath, name, "status").Body(patch).Do().Error()
+}
+
+// ExpectedCRD describes the parts of a CRD the operator relies on
+type ExpectedCRD struct {
+	Name              string
+	Group             string
+	Kind              string
+	Plural            string
+	ShortNames        []string
+	Scope             string
+	StatusSubres      bool
+	StorageVersion    string
+	ConversionWebhook bool
+	Versions          []ExpectedVersion
+}
+
+// ExpectedVersion is a served version of the CRD and the go types backing it,
+// Status is nil for kinds without a status
+type ExpectedVersion struct {
+	Name   string
+	Spec   interface{}
+	Status interface{}
+}
+
+// PostgresDBCRD returns the CRD definition this binary was built against
+func PostgresDBCRD() *ExpectedCRD {
+	return &ExpectedCRD{
+		Name:              "postgresdbs." + postgresdb.GroupName,
+		Group:             postgresdb.GroupName,
+		Kind:              "PostgresDB",
+		Plural:            "postgresdbs",
+		ShortNames:        []string{"pgdb"},
+		Scope:             "Namespaced",
+		StatusSubres:      true,
+		StorageVersion:    v1beta1.SchemeGroupVersion.Version,
+		ConversionWebhook: true,
+		Versions: []ExpectedVersion{
+			{
+				Name:   v1beta1.SchemeGroupVersion.Version,
+				Spec:   v1beta1.PostgresDBSpec{},
+				Status: v1beta1.PostgresDBStatus{},
+			},
+			{
+				Name:   v1alpha1.SchemeGroupVersion.Version,
+				Spec:   v1alpha1.PostgresDBSpec{},
+				Status: v1alpha1.PostgresDBStatus{},
+			},
+		},
+	}
+}
+
+// PostgresDBClassCRD returns the PostgresDBClass CRD definition this binary was built against
+func PostgresDBClassCRD() *ExpectedCRD {
+	return &ExpectedCRD{
+		Name:           "postgresdbclasses." + postgresdb.GroupName,
+		Group:          postgresdb.GroupName,
+		Kind:           "PostgresDBClass",
+		Plural:         "postgresdbclasses",
+		ShortNames:     []string{"pgdbclass"},
+		Scope:          "Cluster",
+		StorageVersion: v1beta1.SchemeGroupVersion.Version,
+		Versions: []ExpectedVersion{
+			{
+				Name: v1beta1.SchemeGroupVersion.Version,
+				Spec: v1beta1.PostgresDBClassSpec{},
+			},
+		},
+	}
+}
+
+// PostgresDBQuotaCRD returns the PostgresDBQuota CRD definition this binary was built against
+func PostgresDBQuotaCRD() *ExpectedCRD {
+	return &ExpectedCRD{
+		Name:           "postgresdbquotas." + postgresdb.GroupName,
+		Group:          postgresdb.GroupName,
+		Kind:           "PostgresDBQuota",
+		Plural:         "postgresdbquotas",
+		ShortNames:     []string{"pgdbquota"},
+		Scope:          "Namespaced",
+		StatusSubres:   true,
+		StorageVersion: v1beta1.SchemeGroupVersion.Version,
+		Versions: []ExpectedVersion{
+			{
+				Name:   v1beta1.SchemeGroupVersion.Version,
+				Spec:   v1beta1.PostgresDBQuotaSpec{},
+				Status: v1beta1.PostgresDBQuotaStatus{},
+			},
+		},
+	}
+}
+
+// PostgresDBSnapshotCRD returns the PostgresDBSnapshot CRD definition this binary was built against
+func PostgresDBSnapshotCRD() *ExpectedCRD {
+	return &ExpectedCRD{
+		Name:           "postgresdbsnapshots." + postgresdb.GroupName,
+		Group:          postgresdb.GroupName,
+		Kind:           "PostgresDBSnapshot",
+		Plural:         "postgresdbsnapshots",
+		ShortNames:     []string{"pgdbsnap"},
+		Scope:          "Namespaced",
+		StatusSubres:   true,
+		StorageVersion: v1beta1.SchemeGroupVersion.Version,
+		Versions: []ExpectedVersion{
+			{
+				Name:   v1beta1.SchemeGroupVersion.Version,
+				Spec:   v1beta1.PostgresDBSnapshotSpec{},
+				Status: v1beta1.PostgresDBSnapshotStatus{},
+			},
+		},
+	}
+}
+
+// PostgresDBAccessRequestCRD returns the PostgresDBAccessRequest CRD definition this binary was built against
+func PostgresDBAccessRequestCRD() *ExpectedCRD {
+	return &ExpectedCRD{
+		Name:           "postgresdbaccessrequests." + postgresdb.GroupName,
+		Group:          postgresdb.GroupName,
+		Kind:           "PostgresDBAccessRequest",
+		Plural:         "postgresdbaccessrequests",
+		ShortNames:     []string{"pgdbaccess"},
+		Scope:          "Namespaced",
+		StatusSubres:   true,
+		StorageVersion: v1beta1.SchemeGroupVersion.Version,
+		Versions: []ExpectedVersion{
+			{
+				Name:   v1beta1.SchemeGroupVersion.Version,
+				Spec:   v1beta1.PostgresDBAccessRequestSpec{},
+				Status: v1beta1.PostgresDBAccessRequestStatus{},
+			},
+		},
+	}
+}
+
+// CRDChecker verifies the CRD installed in the cluster matches what the operator expects
+type CRDChecker struct {
+	fetcher CRDFetcher
+}
+
+// NewCRDChecker returns a CRDChecker using the given fetcher
+func NewCRDChecker(f CRDFetcher) *CRDChecker {
+	return &CRDChecker{fetcher: f}
+}
+
+type installedCRD struct {
+	Spec struct {
+		Group    string             `json:"group"`
+		Version  string             `json:"version"`
+		Versions []installedVersion `json:"versions"`
+		Scope    string             `json:"scope"`
+		Names    struct {
+			Kind       string   `json:"kind"`
+			Plural     string   `json:"plural"`
+			ShortNames []string `json:"shortNames"`
+		} `json:"names"`
+		Subresources *struct {
+			Status *struct{} `json:"status"`
+		} `json:"subresources"`
+		Validation *validation `json:"validation"`
+		Conversion *struct {
+			Strategy string `json:"strategy"`
+		} `json:"conversion"`
+	} `json:"spec"`
+}
+
+type installedVersion struct {
+	Name    string      `json:"name"`
+	Served  bool        `json:"served"`
+	Storage bool        `json:"storage"`
+	Schema  *validation `json:"schema"`
+}
+
+type validation struct {
+	OpenAPIV3Schema *schemaProps `json:"openAPIV3Schema"`
+}
+
+type schemaProps struct {
+	Properties map[string]*schemaProps `json:"properties"`
+}
+
+// Check fetches the installed CRD and returns an error describing every mismatch
+func (c *CRDChecker) Check(e *ExpectedCRD) error {
+	raw, err := c.fetcher.FetchCRD(e.Name)
+	if err != nil {
+		return fmt.Errorf("unable to fetch crd %s: %v", e.Name, err)
+	}
+
+	crd := &installedCRD{}
+	if err := json.Unmarshal(raw, crd); err != nil {
+		return fmt.Errorf("unable to decode crd %s: %v", e.Name, err)
+	}
+
+	var problems []string
+	s := crd.Spec
+	if s.Group != e.Group {
+		problems = append(problems, fmt.Sprintf("group is %q, expected %q", s.Group, e.Group))
+	}
+	if s.Scope != e.Scope {
+		problems = append(problems, fmt.Sprintf("scope is %q, expected %q", s.Scope, e.Scope))
+	}
+	if s.Names.Kind != e.Kind || s.Names.Plural != e.Plural {
+		problems = append(problems, fmt.Sprintf("names are %s/%s, expected %s/%s", s.Names.Kind, s.Names.Plural, e.Kind, e.Plural))
+	}
+	for _, n := range e.ShortNames {
+		if !contains(s.Names.ShortNames, n) {
+			problems = append(problems, fmt.Sprintf("short name %q missing", n))
+		}
+	}
+	if e.StatusSubres && (s.Subresources == nil || s.Subresources.Status == nil) {
+		problems = append(problems, "status subresource is not enabled")
+	}
+
+	if sv := storageVersion(crd); sv != e.StorageVersion {
+		problems = append(problems, fmt.Sprintf("storage version is %q, expected %q", sv, e.StorageVersion))
+	}
+	if e.ConversionWebhook && (s.Conversion == nil || s.Conversion.Strategy != "Webhook") {
+		problems = append(problems, "conversion webhook is not enabled")
+	}
+
+	for _, v := range e.Versions {
+		if !servesVersion(crd, v.Name) {
+			problems = append(problems, fmt.Sprintf("version %q is not served", v.Name))
+			continue
+		}
+		schema := versionSchema(crd, v.Name)
+		if schema == nil {
+			problems = append(problems, fmt.Sprintf("%s: openAPIV3Schema validation is missing", v.Name))
+			continue
+		}
+		for _, p := range compareFields("spec", schema.Properties["spec"], v.Spec) {
+			problems = append(problems, fmt.Sprintf("%s: %s", v.Name, p))
+		}
+		if v.Status == nil {
+			continue
+		}
+		for _, p := range compareFields("status", schema.Properties["status"], v.Status) {
+			problems = append(problems, fmt.Sprintf("%s: %s", v.Name, p))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("installed crd %s does not match operator: %s", e.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func servesVersion(crd *installedCRD, version string) bool {
+	if len(crd.Spec.Versions) == 0 {
+		return crd.Spec.Version == version
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Served {
+			return true
+		}
+	}
+	return false
+}
+
+func storageVersion(crd *installedCRD) string {
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return v.Name
+		}
+	}
+	return crd.Spec.Version
+}
+
+// versionSchema returns the per version schema, falling back to the top level validation
+func versionSchema(crd *installedCRD, version string) *schemaProps {
+	for _, v := range crd.Spec.Versions {
+		if v.Name == version && v.Schema != nil && v.Schema.OpenAPIV3Schema != nil {
+			return v.Schema.OpenAPIV3Schema
+		}
+	}
+	if crd.Spec.Validation != nil {
+		return crd.Spec.Validation.OpenAPIV3Schema
+	}
+	return nil
+}
+
+// compareFields checks the schema properties against the json fields of the go type
+func compareFields(path string, schema *schemaProps, obj interface{}) []string {
+	if schema == nil {
+		return []string{fmt.Sprintf("%s is missing from the schema", path)}
+	}
+
+	expected := jsonFields(reflect.TypeOf(obj))
+	var problems []string
+	for _, f := range expected {
+		if _, ok := schema.Properties[f]; !ok {
+			problems = append(problems, fmt.Sprintf("%s.%s is missing from the schema", path, f))
+		}
+	}
+
+	var unknown []string
+	for f := range schema.Properties {
+		if !contains(expected, f) {
+			unknown = append(unknown, f)
+		}
+	}
+	sort.Strings(unknown)
+	for _, f := range unknown {
+		problems = append(problems, fmt.Sprintf("%s.%s is not known to the operator", path, f))
+	}
+	return problems
+}
+
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fields = append(fields, tag)
+	}
+	return fields
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
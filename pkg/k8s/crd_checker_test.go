@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCRDFetcher struct {
+	raw []byte
+	err error
+}
+
+func (f *fakeCRDFetcher) FetchCRD(name string) ([]byte, error) {
+	return f.raw, f.err
+}
+
+func TestCRDChecker_ManifestMatchesTypes(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{raw: withConversion(t, readCRDManifest(t, "crds/myob.com_postgresdbs.yaml"))})
+
+	err := c.Check(PostgresDBCRD())
+	assert.Nil(t, err)
+}
+
+func TestCRDChecker_ClassManifestMatchesTypes(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{raw: readCRDManifest(t, "crds/myob.com_postgresdbclasses.yaml")})
+
+	err := c.Check(PostgresDBClassCRD())
+	assert.Nil(t, err)
+}
+
+func TestCRDChecker_QuotaManifestMatchesTypes(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{raw: readCRDManifest(t, "crds/myob.com_postgresdbquotas.yaml")})
+
+	err := c.Check(PostgresDBQuotaCRD())
+	assert.Nil(t, err)
+}
+
+func TestCRDChecker_SnapshotManifestMatchesTypes(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{raw: readCRDManifest(t, "crds/myob.com_postgresdbsnapshots.yaml")})
+
+	err := c.Check(PostgresDBSnapshotCRD())
+	assert.Nil(t, err)
+}
+
+func TestCRDChecker_AccessRequestManifestMatchesTypes(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{raw: readCRDManifest(t, "crds/myob.com_postgresdbaccessrequests.yaml")})
+
+	err := c.Check(PostgresDBAccessRequestCRD())
+	assert.Nil(t, err)
+}
+
+func TestCRDChecker_FetchError(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{err: fmt.Errorf("not found")})
+
+	err := c.Check(PostgresDBCRD())
+	assert.NotNil(t, err)
+}
+
+func TestCRDChecker_NoSchema(t *testing.T) {
+	raw := []byte(`{"spec":{"group":"myob.com","version":"v1beta1","scope":"Namespaced",
+		"versions":[{"name":"v1beta1","served":true,"storage":true},{"name":"v1alpha1","served":true}],
+		"names":{"kind":"PostgresDB","plural":"postgresdbs"}}}`)
+	c := NewCRDChecker(&fakeCRDFetcher{raw: raw})
+
+	err := c.Check(PostgresDBCRD())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "status subresource is not enabled")
+	assert.Contains(t, err.Error(), "v1beta1: openAPIV3Schema validation is missing")
+	assert.Contains(t, err.Error(), "conversion webhook is not enabled")
+	assert.Contains(t, err.Error(), `short name "pgdb" missing`)
+}
+
+func TestCRDChecker_FieldMismatch(t *testing.T) {
+	raw := []byte(`{"spec":{"group":"myob.com","version":"v1beta1","scope":"Namespaced",
+		"names":{"kind":"PostgresDB","plural":"postgresdbs","shortNames":["pgdb"]},
+		"subresources":{"status":{}},
+		"conversion":{"strategy":"Webhook"},
+		"versions":[
+			{"name":"v1beta1","served":true,"storage":true,"schema":{"openAPIV3Schema":{"properties":{
+				"spec":{"properties":{"className":{},"size":{},"instanceClass":{},"storage":{},"storageType":{},"iops":{},"ha":{},"tags":{},"backup":{},"network":{},"encryption":{},"region":{},"awsAccount":{},"iamAuthentication":{},"credentialStores":{},"vaultDynamicCredentials":{}}},
+				"status":{"properties":{"ready":{},"arn":{},"id":{},"endpoint":{},"phase":{},"conditions":{},"observedGeneration":{}}}}}}},
+			{"name":"v1alpha1","served":true,"schema":{"openAPIV3Schema":{"properties":{
+				"spec":{"properties":{"size":{},"storage":{},"storageType":{},"iops":{},"ha":{},"tags":{},"banana":{}}},
+				"status":{"properties":{"ready":{},"arn":{},"id":{}}}}}}}]}}`)
+	c := NewCRDChecker(&fakeCRDFetcher{raw: raw})
+
+	err := c.Check(PostgresDBCRD())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "v1alpha1: spec.banana is not known to the operator")
+	assert.Contains(t, err.Error(), "v1alpha1: status.endpoint is missing from the schema")
+	assert.NotContains(t, err.Error(), "v1beta1:")
+}
+
+func TestCRDChecker_StorageVersion(t *testing.T) {
+	raw := []byte(`{"spec":{"group":"myob.com","version":"v1alpha1","scope":"Namespaced",
+		"versions":[{"name":"v1alpha1","served":true,"storage":true},{"name":"v1beta1","served":true}],
+		"names":{"kind":"PostgresDB","plural":"postgresdbs"}}}`)
+	c := NewCRDChecker(&fakeCRDFetcher{raw: raw})
+
+	err := c.Check(PostgresDBCRD())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), `storage version is "v1alpha1", expected "v1beta1"`)
+}
+
+func TestCRDChecker_GeneratedManifestNeedsConversion(t *testing.T) {
+	c := NewCRDChecker(&fakeCRDFetcher{raw: readCRDManifest(t, "crds/myob.com_postgresdbs.yaml")})
+
+	err := c.Check(PostgresDBCRD())
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "conversion webhook is not enabled")
+}
+
+// withConversion merges the conversion patch into a crd like kubectl patch does
+func withConversion(t *testing.T, raw []byte) []byte {
+	crd := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &crd); err != nil {
+		t.Fatal(err)
+	}
+	patch := map[string]interface{}{}
+	if err := json.Unmarshal(readCRDManifest(t, "crd-conversion.yaml"), &patch); err != nil {
+		t.Fatal(err)
+	}
+	spec := crd["spec"].(map[string]interface{})
+	for k, v := range patch["spec"].(map[string]interface{}) {
+		spec[k] = v
+	}
+	merged, err := json.Marshal(crd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return merged
+}
+
+func readCRDManifest(t *testing.T, name string) []byte {
+	b, err := ioutil.ReadFile("../../yaml/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
@@ -1,9 +1,12 @@
 package k8s
 
 import (
-	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"encoding/json"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -18,21 +21,122 @@ func NewCRDClient(c versioned.Interface) *CRDClient {
 }
 
 func (u *CRDClient) StatusUpdate(sReq *database.StatusRequest) error {
-	crd, err := u.client.PostgresdbV1alpha1().PostgresDBs(string(sReq.Scope)).Get(sReq.Name, v1.GetOptions{})
+	crd, err := u.client.PostgresdbV1beta1().PostgresDBs(string(sReq.Scope)).Get(sReq.Name, v1.GetOptions{})
 	if err != nil {
 		return err
 	}
 
-	status := &v1alpha1.PostgresDBStatus{Ready: database.GetMessageForStatus(sReq.Status)}
+	// the status subresource ignores annotations, they are updated on their own
+	annotations := map[string]string{}
+	if sReq.TraceID != "" {
+		annotations[v1beta1.TraceIDAnnotation] = sReq.TraceID
+	}
+	if sReq.TagKeys != nil {
+		annotations[v1beta1.TagKeysAnnotation] = ""
+		if len(sReq.TagKeys) > 0 {
+			keys, err := json.Marshal(sReq.TagKeys)
+			if err != nil {
+				return err
+			}
+			annotations[v1beta1.TagKeysAnnotation] = string(keys)
+		}
+	}
+	if updated, changed := annotate(crd, annotations); changed {
+		crd, err = u.client.PostgresdbV1beta1().PostgresDBs(string(sReq.Scope)).Update(updated)
+		if err != nil {
+			return err
+		}
+	}
+
+	message := database.GetMessageForStatus(sReq.Status)
+	if sReq.Message != "" {
+		message = sReq.Message
+	}
+	// requests of failures have no ID, the recorded one stays the identifier
+	// of the instance
+	status := &v1beta1.PostgresDBStatus{
+		ID:                 crd.Status.ID,
+		Ready:              message,
+		Endpoint:           sReq.Endpoint,
+		Phase:              sReq.Status.String(),
+		Conditions:         crd.Status.Conditions,
+		ObservedGeneration: crd.Status.ObservedGeneration,
+	}
 	if sReq.ID != nil {
 		status.ID = string(*sReq.ID)
 	}
+	if sReq.Generation != 0 {
+		status.ObservedGeneration = sReq.Generation
+	}
+
+	now := v1.Now()
+	reason := sReq.Status.String()
+	if sReq.Reason != "" {
+		reason = sReq.Reason
+	}
+	setCondition(status, v1beta1.ConditionReady, sReq.Status == database.StatusAvailable, reason, message, now)
+	setCondition(status, v1beta1.ConditionProgressing, sReq.Status.IsTransitional(), reason, message, now)
+	setCondition(status, v1beta1.ConditionFailed, sReq.Status.IsFailure(), reason, message, now)
 
 	crd.Status = *status
 
-	_, err = u.client.PostgresdbV1alpha1().PostgresDBs(string(sReq.Scope)).Update(crd)
+	_, err = u.client.PostgresdbV1beta1().PostgresDBs(string(sReq.Scope)).UpdateStatus(crd)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// annotate returns a copy of crd with the annotations set, empty values
+// remove them, and whether any of them changed
+func annotate(crd *v1beta1.PostgresDB, annotations map[string]string) (*v1beta1.PostgresDB, bool) {
+	var updated *v1beta1.PostgresDB
+	for k, v := range annotations {
+		if current, ok := crd.Annotations[k]; current == v && (ok || v == "") {
+			continue
+		}
+		if updated == nil {
+			updated = crd.DeepCopy()
+			if updated.Annotations == nil {
+				updated.Annotations = map[string]string{}
+			}
+		}
+		if v == "" {
+			delete(updated.Annotations, k)
+		} else {
+			updated.Annotations[k] = v
+		}
+	}
+	return updated, updated != nil
+}
+
+// setCondition sets the condition of type t, the transition time only changes
+// when the condition status does
+func setCondition(s *v1beta1.PostgresDBStatus, t v1beta1.PostgresDBConditionType, value bool, reason, message string, now v1.Time) {
+	status := corev1.ConditionFalse
+	if value {
+		status = corev1.ConditionTrue
+	}
+
+	for i := range s.Conditions {
+		c := &s.Conditions[i]
+		if c.Type != t {
+			continue
+		}
+		if c.Status != status {
+			c.Status = status
+			c.LastTransitionTime = now
+		}
+		c.Reason = reason
+		c.Message = message
+		return
+	}
+
+	s.Conditions = append(s.Conditions, v1beta1.PostgresDBCondition{
+		Type:               t,
+		Status:             status,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -0,0 +1,134 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStatusUpdate_Conditions(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("test-ns", "test"))
+	u := NewCRDClient(client)
+
+	err := u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusCreating})
+	assert.Nil(t, err)
+
+	crd, _ := client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "Creating", crd.Status.Phase)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(crd, v1beta1.ConditionReady).Status)
+	assert.Equal(t, corev1.ConditionTrue, getCondition(crd, v1beta1.ConditionProgressing).Status)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(crd, v1beta1.ConditionFailed).Status)
+	created := getCondition(crd, v1beta1.ConditionFailed).LastTransitionTime
+
+	id := database.DatabaseID("db-id")
+	err = u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusAvailable, ID: &id, Endpoint: "host:5432"})
+	assert.Nil(t, err)
+
+	crd, _ = client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "Available", crd.Status.Phase)
+	assert.Equal(t, "db-id", crd.Status.ID)
+	assert.Equal(t, "host:5432", crd.Status.Endpoint)
+	assert.Equal(t, 3, len(crd.Status.Conditions))
+	assert.Equal(t, corev1.ConditionTrue, getCondition(crd, v1beta1.ConditionReady).Status)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(crd, v1beta1.ConditionProgressing).Status)
+	assert.Equal(t, "Available", getCondition(crd, v1beta1.ConditionFailed).Reason)
+	assert.Equal(t, created, getCondition(crd, v1beta1.ConditionFailed).LastTransitionTime)
+}
+
+func TestStatusUpdate_KeepsID(t *testing.T) {
+	db := newPostgresDB("test-ns", "test")
+	db.Status.ID = "test-2098284b-1daf-11e8-b83f-028cde27f28a"
+	client := fake.NewSimpleClientset(db)
+	u := NewCRDClient(client)
+
+	err := u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusErrored, Reason: "TransientError"})
+	assert.Nil(t, err)
+
+	crd, _ := client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "Errored", crd.Status.Phase)
+	assert.Equal(t, "test-2098284b-1daf-11e8-b83f-028cde27f28a", crd.Status.ID)
+}
+
+func TestStatusUpdate_Failed(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("test-ns", "test"))
+	u := NewCRDClient(client)
+
+	err := u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusStorageFull})
+	assert.Nil(t, err)
+
+	crd, _ := client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "db storage is full", crd.Status.Ready)
+	assert.Equal(t, corev1.ConditionTrue, getCondition(crd, v1beta1.ConditionFailed).Status)
+	assert.Equal(t, "StorageFull", getCondition(crd, v1beta1.ConditionFailed).Reason)
+}
+
+func TestStatusUpdate_Reason(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("test-ns", "test"))
+	u := NewCRDClient(client)
+
+	err := u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusErrored, Reason: "QuotaExceeded", Message: "unable to create db: InstanceQuotaExceeded"})
+	assert.Nil(t, err)
+
+	crd, _ := client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "Errored", crd.Status.Phase)
+	assert.Equal(t, "unable to create db: InstanceQuotaExceeded", crd.Status.Ready)
+	failed := getCondition(crd, v1beta1.ConditionFailed)
+	assert.Equal(t, corev1.ConditionTrue, failed.Status)
+	assert.Equal(t, "QuotaExceeded", failed.Reason)
+	assert.Equal(t, "unable to create db: InstanceQuotaExceeded", failed.Message)
+}
+
+func TestStatusUpdate_TraceID(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("test-ns", "test"))
+	u := NewCRDClient(client)
+
+	err := u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusCreating, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	assert.Nil(t, err)
+
+	crd, _ := client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", crd.Annotations[v1beta1.TraceIDAnnotation])
+	assert.Equal(t, "Creating", crd.Status.Phase)
+
+	// untraced updates leave the trace of the last traced reconcile
+	err = u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusAvailable})
+	assert.Nil(t, err)
+	crd, _ = client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", crd.Annotations[v1beta1.TraceIDAnnotation])
+	assert.Equal(t, "Available", crd.Status.Phase)
+}
+
+func TestStatusUpdate_TagKeys(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("test-ns", "test"))
+	u := NewCRDClient(client)
+
+	err := u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusAvailable, TagKeys: []string{"billing", "team"}})
+	assert.Nil(t, err)
+	crd, _ := client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, `["billing","team"]`, crd.Annotations[v1beta1.TagKeysAnnotation])
+
+	// requests without keys leave the recorded ones
+	err = u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusAvailable})
+	assert.Nil(t, err)
+	crd, _ = client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	assert.Equal(t, `["billing","team"]`, crd.Annotations[v1beta1.TagKeysAnnotation])
+
+	err = u.StatusUpdate(&database.StatusRequest{Name: "test", Scope: "test-ns", Status: database.StatusAvailable, TagKeys: []string{}})
+	assert.Nil(t, err)
+	crd, _ = client.PostgresdbV1beta1().PostgresDBs("test-ns").Get("test", v1.GetOptions{})
+	_, ok := crd.Annotations[v1beta1.TagKeysAnnotation]
+	assert.False(t, ok)
+}
+
+func getCondition(crd *v1beta1.PostgresDB, t v1beta1.PostgresDBConditionType) v1beta1.PostgresDBCondition {
+	for _, c := range crd.Status.Conditions {
+		if c.Type == t {
+			return c
+		}
+	}
+	return v1beta1.PostgresDBCondition{}
+}
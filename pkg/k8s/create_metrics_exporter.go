@@ -3,7 +3,9 @@ package k8s
 import (
 	"fmt"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
 	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -16,6 +18,7 @@ import (
 // MetricsExporter provides an abstraction for deploying k8s MetricsExporter deployment
 type MetricsExporter struct {
 	clientset kubernetes.Interface
+	config    config.Getter
 }
 
 // NewMetricsExporter returns new NewMetricsExporter for managing k8s MetricsExporter deployment
@@ -25,6 +28,21 @@ func NewMetricsExporter(clientset kubernetes.Interface) *MetricsExporter {
 	}
 }
 
+// NewMetricsExporterWithConfig returns a MetricsExporter deploying the exporter image of the current configuration
+func NewMetricsExporterWithConfig(clientset kubernetes.Interface, c config.Getter) *MetricsExporter {
+	return &MetricsExporter{
+		clientset: clientset,
+		config:    c,
+	}
+}
+
+func (e *MetricsExporter) image() string {
+	if e.config == nil {
+		return config.Default().Exporter.Image
+	}
+	return e.config.Get().Exporter.Image
+}
+
 // Deploy MetricsExporter k8s deployment
 func (e *MetricsExporter) CreateMetricsExporter(s database.Scope, name string, id database.CredentialID) error {
 
@@ -32,6 +50,7 @@ func (e *MetricsExporter) CreateMetricsExporter(s database.Scope, name string, i
 	labels := getLabels(name)
 
 	namespace := string(s)
+	log.Default().Debug("applying metrics exporter", "component", "k8s", "namespace", namespace, "name", serviceName)
 	if err := e.applyConfigMap(labels, namespace, serviceName); nil != err {
 		return err
 	}
@@ -98,20 +117,20 @@ func (e *MetricsExporter) applyDeployment(labels map[string]string, namespace, n
 
 	if err == nil {
 		// Already exists so updating
-		deployment := updateDeployment(obj, labels, namespace, name, port, id)
+		deployment := updateDeployment(obj, labels, namespace, name, port, id, e.image())
 		_, err = e.clientset.ExtensionsV1beta1().Deployments(namespace).Update(deployment)
 		return err
 	}
 
 	if errors.IsNotFound(err) {
 		// Doesn't exist so creating
-		_, err = e.clientset.ExtensionsV1beta1().Deployments(namespace).Create(updateDeployment(&v1beta1.Deployment{}, labels, namespace, name, port, id))
+		_, err = e.clientset.ExtensionsV1beta1().Deployments(namespace).Create(updateDeployment(&v1beta1.Deployment{}, labels, namespace, name, port, id, e.image()))
 		return err
 	}
 	return err
 }
 
-func updateDeployment(deployment *v1beta1.Deployment, labels map[string]string, namespace, name string, port int, id string) *v1beta1.Deployment {
+func updateDeployment(deployment *v1beta1.Deployment, labels map[string]string, namespace, name string, port int, id, image string) *v1beta1.Deployment {
 	probe := &v1.Probe{
 		Handler: v1.Handler{
 			HTTPGet: &v1.HTTPGetAction{
@@ -129,7 +148,7 @@ func updateDeployment(deployment *v1beta1.Deployment, labels map[string]string,
 			Spec: v1.PodSpec{
 				Containers: []v1.Container{{
 					Name:            "metrics",
-					Image:           "wrouesnel/postgres_exporter:v0.4.1",
+					Image:           image,
 					ImagePullPolicy: "Always",
 					Args:            []string{"--extend.query-path=/etc/config/queries.yaml"},
 					Env: []v1.EnvVar{{
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 	k8sTesting "k8s.io/client-go/testing"
 )
@@ -33,6 +35,19 @@ func TestMetricsExporter_CreateMetricsExporter(t *testing.T) {
 
 }
 
+func TestMetricsExporter_ConfiguredImage(t *testing.T) {
+	cfg := config.Default()
+	cfg.Exporter.Image = "registry/exporter:v2"
+	f := fake.NewSimpleClientset()
+	c := NewMetricsExporterWithConfig(f, config.Fixed{Config: cfg})
+
+	err := c.CreateMetricsExporter(database.Scope("test-shadow"), "test", database.CredentialID("test-shadow-test-monitoring"))
+	assert.Nil(t, err)
+
+	d, _ := f.ExtensionsV1beta1().Deployments("test-shadow").Get("test-metrics-exporter", metav1.GetOptions{})
+	assert.Equal(t, "registry/exporter:v2", d.Spec.Template.Spec.Containers[0].Image)
+}
+
 func TestMetricsExporter_CreateMetricsExporterActions(t *testing.T) {
 
 	e := []expectedActions{
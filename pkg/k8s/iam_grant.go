@@ -0,0 +1,140 @@
+package k8s
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// IAMRoleGranter runs the job granting rds_iam to the app user of a database
+type IAMRoleGranter struct {
+	clientset kubernetes.Interface
+	config    config.Getter
+}
+
+// NewIAMRoleGranter returns an IAMRoleGranter running the grant image of the current configuration
+func NewIAMRoleGranter(clientset kubernetes.Interface, c config.Getter) *IAMRoleGranter {
+	return &IAMRoleGranter{
+		clientset: clientset,
+		config:    c,
+	}
+}
+
+// GrantIAMRole starts a job connecting with the master credentials that
+// creates user, grants it rds_iam and the privileges of the master user. The
+// job is only started once, it retries on its own until the database accepts
+// it. The master credentials are handed to the job in a secret of its own,
+// owned by the job, as their home may not be a kubernetes secret.
+func (g *IAMRoleGranter) GrantIAMRole(master *database.Credential, user string) error {
+	ns := string(master.Scope)
+	name := iamGrantJobName(master.ID)
+
+	_, err := g.clientset.BatchV1().Jobs(ns).Get(name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	backoff := int32(10)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels: map[string]string{
+				"deployed-with": "ops-kube-db-operator",
+				"app":           "iam-grant",
+			},
+			Annotations: map[string]string{
+				"postgresdb.myob.com/credential": string(master.ID),
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoff,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy: v1.RestartPolicyOnFailure,
+					Containers: []v1.Container{{
+						Name:    "psql",
+						Image:   g.config.Get().IAMAuth.GrantImage,
+						Command: []string{"psql", "-v", "ON_ERROR_STOP=1", "-c", iamGrantSQL(master.Username, user)},
+						Env: []v1.EnvVar{
+							secretEnv("PGHOST", name, HOST),
+							secretEnv("PGPORT", name, PORT),
+							secretEnv("PGUSER", name, USER),
+							secretEnv("PGPASSWORD", name, PASSWORD),
+							secretEnv("PGDATABASE", name, NAME),
+							{Name: "PGSSLMODE", Value: "require"},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	created, err := g.clientset.BatchV1().Jobs(ns).Create(job)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// the secret goes with the job once it is cleaned up
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    job.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(created, batchv1.SchemeGroupVersion.WithKind("Job")),
+			},
+		},
+		StringData: CredentialData(master, g.config.Get().Secrets),
+	}
+	_, err = g.clientset.CoreV1().Secrets(ns).Create(secret)
+	if errors.IsAlreadyExists(err) {
+		_, err = g.clientset.CoreV1().Secrets(ns).Update(secret)
+	}
+	return err
+}
+
+// iamGrantJobName is unique to the credential and short enough for a job name
+func iamGrantJobName(id database.CredentialID) string {
+	return fmt.Sprintf("iam-grant-%x", sha1.Sum([]byte(id)))[:22]
+}
+
+// iamGrantSQL can run more than once, the role is only created if missing
+func iamGrantSQL(master, user string) string {
+	return fmt.Sprintf(`DO $$ BEGIN IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = %s) THEN CREATE ROLE %s LOGIN; END IF; END $$; GRANT rds_iam TO %s; GRANT %s TO %s;`,
+		quoteLiteral(user), quoteIdent(user), quoteIdent(user), quoteIdent(master), quoteIdent(user))
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
+
+func quoteLiteral(s string) string {
+	return `'` + strings.Replace(s, `'`, `''`, -1) + `'`
+}
+
+func secretEnv(name, secret, key string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: name,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
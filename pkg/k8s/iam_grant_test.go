@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIAMRoleGranter_GrantIAMRole(t *testing.T) {
+	f := fake.NewSimpleClientset()
+	g := NewIAMRoleGranter(f, config.Fixed{Config: config.Default()})
+	master := &database.Credential{ID: "team-orders-master", Scope: "postgresdb-operator", Username: "master", Password: "secret"}
+
+	assert.Nil(t, g.GrantIAMRole(master, "appuser"))
+	// a second call leaves the job alone
+	assert.Nil(t, g.GrantIAMRole(master, "appuser"))
+
+	jobs, _ := f.BatchV1().Jobs("postgresdb-operator").List(metav1.ListOptions{})
+	assert.Len(t, jobs.Items, 1)
+	c := jobs.Items[0].Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "postgres:10-alpine", c.Image)
+	assert.Contains(t, c.Command[len(c.Command)-1], `GRANT rds_iam TO "appuser"; GRANT "master" TO "appuser";`)
+	assert.Equal(t, jobs.Items[0].Name, c.Env[3].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, PASSWORD, c.Env[3].ValueFrom.SecretKeyRef.Key)
+
+	// the job gets the master credentials in a secret it owns
+	secret, err := f.CoreV1().Secrets("postgresdb-operator").Get(jobs.Items[0].Name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", secret.StringData[PASSWORD])
+	assert.Equal(t, "Job", secret.OwnerReferences[0].Kind)
+}
+
+func TestIAMGrantSQL_Quotes(t *testing.T) {
+	assert.Equal(t,
+		`DO $$ BEGIN IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'a''b') THEN CREATE ROLE "a'b" LOGIN; END IF; END $$; GRANT rds_iam TO "a'b"; GRANT "m""x" TO "a'b";`,
+		iamGrantSQL(`m"x`, "a'b"))
+}
@@ -0,0 +1,50 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StorageMigrator rewrites every PostgresDB in the current storage version so
+// older versions can be dropped from the CRD storedVersions
+type StorageMigrator struct {
+	client versioned.Interface
+	setter CRDVersionSetter
+}
+
+// NewStorageMigrator returns a StorageMigrator
+func NewStorageMigrator(c versioned.Interface, s CRDVersionSetter) *StorageMigrator {
+	return &StorageMigrator{
+		client: c,
+		setter: s,
+	}
+}
+
+// Migrate updates all PostgresDBs then marks v1beta1 as the only stored version
+func (m *StorageMigrator) Migrate(crdName string) error {
+	dbs, err := m.client.PostgresdbV1beta1().PostgresDBs(v1.NamespaceAll).List(v1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to list postgresdbs: %v", err)
+	}
+
+	for i := range dbs.Items {
+		db := &dbs.Items[i]
+		// an update without changes is enough for the apiserver to write the object in the storage version
+		_, err := m.client.PostgresdbV1beta1().PostgresDBs(db.Namespace).Update(db)
+		if errors.IsConflict(err) || errors.IsNotFound(err) {
+			// somebody else wrote or removed it in the meantime, which has the same effect
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("unable to migrate postgresdb %s/%s: %v", db.Namespace, db.Name, err)
+		}
+	}
+
+	log.Default().Info("migrated postgresdbs", "count", len(dbs.Items), "version", v1beta1.SchemeGroupVersion.Version)
+	return m.setter.SetStoredVersions(crdName, []string{v1beta1.SchemeGroupVersion.Version})
+}
@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+type fakeVersionSetter struct {
+	name     string
+	versions []string
+}
+
+func (f *fakeVersionSetter) SetStoredVersions(name string, versions []string) error {
+	f.name = name
+	f.versions = versions
+	return nil
+}
+
+func newPostgresDB(ns, name string) *v1beta1.PostgresDB {
+	return &v1beta1.PostgresDB{ObjectMeta: v1.ObjectMeta{Namespace: ns, Name: name}}
+}
+
+func TestStorageMigrator_Migrate(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("ns1", "a"), newPostgresDB("ns2", "b"))
+	setter := &fakeVersionSetter{}
+
+	err := NewStorageMigrator(client, setter).Migrate("postgresdbs.myob.com")
+
+	assert.Nil(t, err)
+	updates := 0
+	for _, a := range client.Actions() {
+		if a.GetVerb() == "update" {
+			updates++
+		}
+	}
+	assert.Equal(t, 2, updates)
+	assert.Equal(t, "postgresdbs.myob.com", setter.name)
+	assert.Equal(t, []string{"v1beta1"}, setter.versions)
+}
+
+func TestStorageMigrator_IgnoresConflicts(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("ns1", "a"))
+	client.PrependReactor("update", "postgresdbs", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.NewConflict(schema.GroupResource{Resource: "postgresdbs"}, "a", fmt.Errorf("conflict"))
+	})
+	setter := &fakeVersionSetter{}
+
+	err := NewStorageMigrator(client, setter).Migrate("postgresdbs.myob.com")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v1beta1"}, setter.versions)
+}
+
+func TestStorageMigrator_UpdateError(t *testing.T) {
+	client := fake.NewSimpleClientset(newPostgresDB("ns1", "a"))
+	client.PrependReactor("update", "postgresdbs", func(action k8sTesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("boom")
+	})
+	setter := &fakeVersionSetter{}
+
+	err := NewStorageMigrator(client, setter).Migrate("postgresdbs.myob.com")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, setter.versions)
+}
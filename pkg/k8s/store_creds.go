@@ -5,8 +5,11 @@ import (
 	"strconv"
 
 	"fmt"
+	"net/url"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -20,10 +23,16 @@ const (
 	PASSWORD = "DB_PASSWORD"
 	NAME     = "DB_NAME"
 	URL      = "DATABASE_URL"
+	// VAULT_CREDS_PATH is only set for databases with vault dynamic credentials
+	VAULT_CREDS_PATH = "VAULT_CREDS_PATH"
+
+	// VaultCredsPathAnnotation holds the VAULT_CREDS_PATH of a secret
+	VaultCredsPathAnnotation = "postgresdb.myob.com/vault-creds-path"
 )
 
 type StoreCreds struct {
 	client kubernetes.Interface
+	config config.Getter
 }
 
 func NewStoreCreds(client kubernetes.Interface) *StoreCreds {
@@ -31,6 +40,21 @@ func NewStoreCreds(client kubernetes.Interface) *StoreCreds {
 		client: client,
 	}
 }
+
+// NewStoreCredsWithConfig returns a StoreCreds writing connection urls in the format of the current configuration
+func NewStoreCredsWithConfig(client kubernetes.Interface, c config.Getter) *StoreCreds {
+	return &StoreCreds{
+		client: client,
+		config: c,
+	}
+}
+
+func (k *StoreCreds) secrets() config.Secrets {
+	if k.config == nil {
+		return config.Default().Secrets
+	}
+	return k.config.Get().Secrets
+}
 func (k *StoreCreds) GetCred(credScope database.Scope, id database.CredentialID) (*database.Credential, error) {
 	ns := string(credScope)
 	secret, err := k.client.CoreV1().Secrets(ns).Get(string(id), metav1.GetOptions{})
@@ -46,19 +70,28 @@ func (k *StoreCreds) GetCred(credScope database.Scope, id database.CredentialID)
 
 func (k *StoreCreds) UpdateCred(credential *database.Credential) error {
 	ns := string(credential.Scope)
-	_, err := k.client.CoreV1().Secrets(ns).Update(transformCredentialToSecret(credential, ns))
+	log.Default().Debug("updating credential secret", "component", "k8s", "namespace", ns, "name", credential.ID)
+	_, err := k.client.CoreV1().Secrets(ns).Update(transformCredentialToSecret(credential, ns, k.secrets()))
 	return err
 }
 
 func (k *StoreCreds) CreateCred(credential *database.Credential) error {
 	ns := string(credential.Scope)
-	_, err := k.client.CoreV1().Secrets(ns).Create(transformCredentialToSecret(credential, ns))
+	log.Default().Debug("creating credential secret", "component", "k8s", "namespace", ns, "name", credential.ID)
+	_, err := k.client.CoreV1().Secrets(ns).Create(transformCredentialToSecret(credential, ns, k.secrets()))
 	return err
 }
 
 func transformSecretToCredential(secret v1.Secret) *database.Credential {
 
 	data := secret.Data
+	// secrets that have not been through the api server yet only carry StringData
+	if len(data) == 0 && len(secret.StringData) > 0 {
+		data = make(map[string][]byte, len(secret.StringData))
+		for k, v := range secret.StringData {
+			data[k] = []byte(v)
+		}
+	}
 	port, _ := binary.Varint(data[PORT])
 	cred := &database.Credential{
 		Port:         port,
@@ -70,17 +103,11 @@ func transformSecretToCredential(secret v1.Secret) *database.Credential {
 	return cred
 }
 
-func transformCredentialToSecret(cred *database.Credential, ns string) *v1.Secret {
-
-	var secret = map[string]string{
-		HOST:     cred.Host,
-		PASSWORD: string(cred.Password),
-		USER:     cred.Username,
-		PORT:     strconv.FormatInt(cred.Port, 10),
-		NAME:     cred.DatabaseName,
-		URL:      fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=require", cred.Username, cred.Password, cred.Host, strconv.FormatInt(cred.Port, 10), cred.DatabaseName),
+func transformCredentialToSecret(cred *database.Credential, ns string, f config.Secrets) *v1.Secret {
+	var annotations map[string]string
+	if cred.VaultCredsPath != "" {
+		annotations = map[string]string{VaultCredsPathAnnotation: cred.VaultCredsPath}
 	}
-
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            string(cred.ID),
@@ -90,7 +117,39 @@ func transformCredentialToSecret(cred *database.Credential, ns string) *v1.Secre
 				"deployed-with": "ops-kube-db-operator",
 				"db-name":       cred.DatabaseName,
 			},
+			Annotations: annotations,
 		},
-		StringData: secret,
+		StringData: CredentialData(cred, f),
+	}
+}
+
+// CredentialData returns the keys and values of the secret of a credential,
+// the external credential stores write the same payload
+func CredentialData(cred *database.Credential, f config.Secrets) map[string]string {
+	data := map[string]string{
+		HOST:     cred.Host,
+		PASSWORD: string(cred.Password),
+		USER:     cred.Username,
+		PORT:     strconv.FormatInt(cred.Port, 10),
+		NAME:     cred.DatabaseName,
+		// auth tokens are full of characters that have to be escaped in a url
+		URL: fmt.Sprintf("%s://%s@%s:%s/%s?sslmode=%s", f.URLScheme, url.UserPassword(cred.Username, string(cred.Password)).String(), cred.Host, strconv.FormatInt(cred.Port, 10), cred.DatabaseName, f.SSLMode),
+	}
+	if cred.VaultCredsPath != "" {
+		data[VAULT_CREDS_PATH] = cred.VaultCredsPath
+	}
+	return data
+}
+
+// CredentialFromData is the inverse of CredentialData
+func CredentialFromData(data map[string]string) *database.Credential {
+	port, _ := strconv.ParseInt(data[PORT], 10, 64)
+	return &database.Credential{
+		Port:           port,
+		Password:       database.Password(data[PASSWORD]),
+		Username:       data[USER],
+		Host:           data[HOST],
+		DatabaseName:   data[NAME],
+		VaultCredsPath: data[VAULT_CREDS_PATH],
 	}
 }
@@ -3,6 +3,7 @@ package k8s
 import (
 	"testing"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/core/v1"
@@ -37,6 +38,57 @@ func TestGetCreds_NotFound(t *testing.T) {
 
 }
 
+func TestGetCreds_StringData(t *testing.T) {
+
+	fakeClient := fake.NewSimpleClientset()
+	k := &StoreCreds{client: fakeClient}
+
+	err := k.CreateCred(&database.Credential{ID: "test", Scope: "test", Username: "master", Password: "banana"})
+	assert.Nil(t, err)
+
+	cred, err := k.GetCred(database.Scope("test"), "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "master", cred.Username)
+	assert.Equal(t, database.Password("banana"), cred.Password)
+}
+
+func TestCreateCreds_URLFormat(t *testing.T) {
+
+	c := config.Default()
+	c.Secrets = config.Secrets{URLScheme: "postgres", SSLMode: "verify-full"}
+	fakeClient := fake.NewSimpleClientset()
+	k := NewStoreCredsWithConfig(fakeClient, config.Fixed{Config: c})
+
+	err := k.CreateCred(&database.Credential{ID: "test", Scope: "test", Username: "u", Password: "p", Host: "h", Port: 5432, DatabaseName: "d"})
+	assert.Nil(t, err)
+
+	secret, _ := fakeClient.CoreV1().Secrets("test").Get("test", v12.GetOptions{})
+	assert.Equal(t, "postgres://u:p@h:5432/d?sslmode=verify-full", secret.StringData[URL])
+}
+
+func TestCreateCreds_URLEscapesToken(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	k := NewStoreCreds(fakeClient)
+
+	err := k.CreateCred(&database.Credential{ID: "test", Scope: "test", Username: "appuser", Password: "h:5432?Action=connect&X-Amz-Credential=AKID/x", Host: "h", Port: 5432, DatabaseName: "d"})
+	assert.Nil(t, err)
+
+	secret, _ := fakeClient.CoreV1().Secrets("test").Get("test", v12.GetOptions{})
+	assert.Equal(t, "postgresql://appuser:h%3A5432%3FAction=connect&X-Amz-Credential=AKID%2Fx@h:5432/d?sslmode=require", secret.StringData[URL])
+}
+
+func TestCreateCreds_VaultCredsPath(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	k := NewStoreCreds(fakeClient)
+
+	err := k.CreateCred(&database.Credential{ID: "test", Scope: "test", Username: "u", VaultCredsPath: "postgresdb/test/d/creds/readonly"})
+	assert.Nil(t, err)
+
+	secret, _ := fakeClient.CoreV1().Secrets("test").Get("test", v12.GetOptions{})
+	assert.Equal(t, "postgresdb/test/d/creds/readonly", secret.StringData[VAULT_CREDS_PATH])
+	assert.Equal(t, "postgresdb/test/d/creds/readonly", secret.Annotations[VaultCredsPathAnnotation])
+}
+
 // TODO implement this test
 //func TestGetCreds_Error() {
 //
@@ -0,0 +1,155 @@
+package kms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// DataKeys generates the data keys of envelope encryption and decrypts them again
+type DataKeys interface {
+	// GenerateDataKey returns a 32 byte key and the key encrypted under the master key
+	GenerateDataKey() (plaintext, ciphertext []byte, err error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// KMSDataKeys generates data keys under a KMS key
+type KMSDataKeys struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+// NewKMSDataKeys returns KMSDataKeys generating data keys with keyID
+func NewKMSDataKeys(client kmsiface.KMSAPI, keyID string) *KMSDataKeys {
+	return &KMSDataKeys{client: client, keyID: keyID}
+}
+
+// GenerateDataKey returns a new AES-256 data key
+func (k *KMSDataKeys) GenerateDataKey() ([]byte, []byte, error) {
+	out, err := k.client.GenerateDataKey(&awskms.GenerateDataKeyInput{
+		KeyId:   aws.String(k.keyID),
+		KeySpec: aws.String(awskms.DataKeySpecAes256),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate data key with kms key %s: %v", k.keyID, err)
+	}
+	return out.Plaintext, out.CiphertextBlob, nil
+}
+
+// Decrypt returns the plaintext of a data key, KMS knows the key it was encrypted with
+func (k *KMSDataKeys) Decrypt(ciphertext []byte) ([]byte, error) {
+	out, err := k.client.Decrypt(&awskms.DecryptInput{CiphertextBlob: ciphertext})
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt data key: %v", err)
+	}
+	return out.Plaintext, nil
+}
+
+// LocalDataKeys stands in for KMS where it is not available, data keys are
+// encrypted with a local 32 byte key
+type LocalDataKeys struct {
+	key []byte
+}
+
+// NewLocalDataKeys reads the key of LocalDataKeys from a file holding 32 raw
+// or base64 encoded bytes
+func NewLocalDataKeys(file string) (*LocalDataKeys, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read key file: %v", err)
+	}
+	key := data
+	if len(key) != 32 {
+		key, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("key file %s does not hold a 32 byte key", file)
+		}
+	}
+	return &LocalDataKeys{key: key}, nil
+}
+
+// GenerateDataKey returns a new random data key
+func (l *LocalDataKeys) GenerateDataKey() ([]byte, []byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := seal(l.key, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, ciphertext, nil
+}
+
+// Decrypt returns the plaintext of a data key
+func (l *LocalDataKeys) Decrypt(ciphertext []byte) ([]byte, error) {
+	return open(l.key, ciphertext)
+}
+
+// Seal encrypts plaintext with a new data key, it returns the ciphertext and
+// the encrypted data key to store along with it
+func Seal(keys DataKeys, plaintext []byte) ([]byte, []byte, error) {
+	key, encryptedKey, err := keys.GenerateDataKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := seal(key, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, encryptedKey, nil
+}
+
+// Open decrypts the ciphertext of Seal
+func Open(keys DataKeys, ciphertext, encryptedKey []byte) ([]byte, error) {
+	key, err := keys.Decrypt(encryptedKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, ciphertext)
+}
+
+// seal encrypts with AES-256-GCM, the nonce prefixes the ciphertext
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decrypt: %v", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
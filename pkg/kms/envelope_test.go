@@ -0,0 +1,78 @@
+package kms
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeDataKeyKMS wraps data keys by xoring them, it only has to round trip
+type fakeDataKeyKMS struct {
+	kmsiface.KMSAPI
+}
+
+func (f *fakeDataKeyKMS) GenerateDataKey(in *awskms.GenerateDataKeyInput) (*awskms.GenerateDataKeyOutput, error) {
+	key := bytes.Repeat([]byte{7}, 32)
+	return &awskms.GenerateDataKeyOutput{Plaintext: key, CiphertextBlob: xor(key)}, nil
+}
+
+func (f *fakeDataKeyKMS) Decrypt(in *awskms.DecryptInput) (*awskms.DecryptOutput, error) {
+	return &awskms.DecryptOutput{Plaintext: xor(in.CiphertextBlob)}, nil
+}
+
+func xor(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[i] = b[i] ^ 0xff
+	}
+	return out
+}
+
+func TestSeal_KMS(t *testing.T) {
+	keys := NewKMSDataKeys(&fakeDataKeyKMS{}, "alias/databases")
+
+	ciphertext, encryptedKey, err := Seal(keys, []byte("master password"))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(ciphertext), "master password")
+
+	plaintext, err := Open(keys, ciphertext, encryptedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "master password", string(plaintext))
+}
+
+func TestSeal_LocalKey(t *testing.T) {
+	f, _ := ioutil.TempFile("", "key")
+	defer os.Remove(f.Name())
+	f.WriteString(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)) + "\n")
+	f.Close()
+
+	keys, err := NewLocalDataKeys(f.Name())
+	assert.Nil(t, err)
+	ciphertext, encryptedKey, err := Seal(keys, []byte("master password"))
+	assert.Nil(t, err)
+
+	plaintext, err := Open(keys, ciphertext, encryptedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, "master password", string(plaintext))
+
+	// a tampered ciphertext does not decrypt
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = Open(keys, ciphertext, encryptedKey)
+	assert.NotNil(t, err)
+}
+
+func TestNewLocalDataKeys_ShortKey(t *testing.T) {
+	f, _ := ioutil.TempFile("", "key")
+	defer os.Remove(f.Name())
+	f.WriteString("too short")
+	f.Close()
+
+	_, err := NewLocalDataKeys(f.Name())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,69 @@
+package kms
+
+import (
+	"fmt"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// KeyResolver checks customer managed KMS keys before they are used to encrypt
+type KeyResolver struct {
+	clients Clients
+}
+
+// Clients returns the KMS client of the region and account of a database
+type Clients interface {
+	KMS(loc database.Location) (kmsiface.KMSAPI, error)
+}
+
+// NewKeyResolver returns a KeyResolver using the clients of each location
+func NewKeyResolver(c Clients) *KeyResolver {
+	return &KeyResolver{clients: c}
+}
+
+// ResolveKey returns the ARN of a key id, ARN or alias, or an error if the key
+// does not exist in the location or cannot encrypt
+func (r *KeyResolver) ResolveKey(loc database.Location, keyID string) (string, error) {
+	client, err := r.clients.KMS(loc)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.DescribeKey(&awskms.DescribeKeyInput{
+		KeyId: aws.String(keyID),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awskms.ErrCodeNotFoundException {
+			return "", fmt.Errorf("kms key %s does not exist", keyID)
+		}
+		return "", fmt.Errorf("unable to describe kms key %s: %v", keyID, err)
+	}
+
+	key := out.KeyMetadata
+	if state := aws.StringValue(key.KeyState); state != awskms.KeyStateEnabled {
+		return "", fmt.Errorf("kms key %s is %s", keyID, state)
+	}
+	if !aws.BoolValue(key.Enabled) {
+		return "", fmt.Errorf("kms key %s is disabled", keyID)
+	}
+	return aws.StringValue(key.Arn), nil
+}
+
+// CustomerManaged returns true if a key id, ARN or alias is a customer managed
+// key, the AWS managed keys cannot be used by other accounts
+func (r *KeyResolver) CustomerManaged(loc database.Location, keyID string) (bool, error) {
+	client, err := r.clients.KMS(loc)
+	if err != nil {
+		return false, err
+	}
+	out, err := client.DescribeKey(&awskms.DescribeKeyInput{
+		KeyId: aws.String(keyID),
+	})
+	if err != nil {
+		return false, fmt.Errorf("unable to describe kms key %s: %v", keyID, err)
+	}
+	return aws.StringValue(out.KeyMetadata.KeyManager) == awskms.KeyManagerTypeCustomer, nil
+}
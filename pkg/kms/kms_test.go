@@ -0,0 +1,64 @@
+package kms
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/awsclient"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awskms "github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	keys map[string]*awskms.KeyMetadata
+}
+
+func (f *fakeKMS) DescribeKey(in *awskms.DescribeKeyInput) (*awskms.DescribeKeyOutput, error) {
+	key, ok := f.keys[aws.StringValue(in.KeyId)]
+	if !ok {
+		return nil, awserr.New(awskms.ErrCodeNotFoundException, "not found", nil)
+	}
+	return &awskms.DescribeKeyOutput{KeyMetadata: key}, nil
+}
+
+const keyARN = "arn:aws:kms:ap-southeast-2:123456789012:key/1234abcd-12ab-34cd-56ef-1234567890ab"
+
+func newResolver() *KeyResolver {
+	return NewKeyResolver(awsclient.Fixed{KMSAPI: &fakeKMS{keys: map[string]*awskms.KeyMetadata{
+		"alias/databases": {Arn: aws.String(keyARN), Enabled: aws.Bool(true), KeyState: aws.String(awskms.KeyStateEnabled), KeyManager: aws.String(awskms.KeyManagerTypeCustomer)},
+		"alias/aws/rds":   {Arn: aws.String(keyARN), Enabled: aws.Bool(true), KeyState: aws.String(awskms.KeyStateEnabled), KeyManager: aws.String(awskms.KeyManagerTypeAws)},
+		"alias/retired":   {Arn: aws.String(keyARN), Enabled: aws.Bool(false), KeyState: aws.String(awskms.KeyStatePendingDeletion)},
+	}}})
+}
+
+func TestResolveKey_Alias(t *testing.T) {
+	arn, err := newResolver().ResolveKey(database.Location{}, "alias/databases")
+	assert.Nil(t, err)
+	assert.Equal(t, keyARN, arn)
+}
+
+func TestResolveKey_Missing(t *testing.T) {
+	_, err := newResolver().ResolveKey(database.Location{}, "alias/missing")
+	assert.NotNil(t, err)
+	assert.Equal(t, "kms key alias/missing does not exist", err.Error())
+}
+
+func TestResolveKey_NotEnabled(t *testing.T) {
+	_, err := newResolver().ResolveKey(database.Location{}, "alias/retired")
+	assert.NotNil(t, err)
+	assert.Equal(t, "kms key alias/retired is PendingDeletion", err.Error())
+}
+
+func TestCustomerManaged(t *testing.T) {
+	managed, err := newResolver().CustomerManaged(database.Location{}, "alias/databases")
+	assert.Nil(t, err)
+	assert.True(t, managed)
+
+	managed, err = newResolver().CustomerManaged(database.Location{}, "alias/aws/rds")
+	assert.Nil(t, err)
+	assert.False(t, managed)
+}
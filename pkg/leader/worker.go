@@ -0,0 +1,17 @@
+package leader
+
+// RunWorker calls processNextItem until stopCh is closed or the queue shuts
+// down. The item in flight is finished and the queued ones are left to the
+// next leader.
+func RunWorker(stopCh <-chan struct{}, processNextItem func() bool) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		if !processNextItem() {
+			return
+		}
+	}
+}
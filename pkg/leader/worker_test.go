@@ -0,0 +1,28 @@
+package leader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWorker_StopsWithQueue(t *testing.T) {
+	items := 3
+	RunWorker(make(chan struct{}), func() bool {
+		items--
+		return items > 0
+	})
+	assert.Equal(t, 0, items)
+}
+
+func TestRunWorker_LeavesQueuedItems(t *testing.T) {
+	stopCh := make(chan struct{})
+	processed := 0
+	RunWorker(stopCh, func() bool {
+		processed++
+		// leadership is lost while the first item is in flight
+		close(stopCh)
+		return true
+	})
+	assert.Equal(t, 1, processed)
+}
@@ -0,0 +1,178 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a message
+type Level int
+
+// Levels of messages, a logger drops messages below its level
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < DebugLevel || l > ErrorLevel {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level of debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", s)
+}
+
+// Logger logs messages with fields given as alternating keys and values, eg.
+// l.Error("unable to create database", "err", err)
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+	// With returns a Logger adding the fields to every message
+	With(keysAndValues ...interface{}) Logger
+}
+
+// JSONLogger writes every message as a line of JSON with its time, level,
+// message and fields in order. Passwords and DATABASE_URLs are redacted.
+type JSONLogger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  Level
+	fields []interface{}
+	now    func() time.Time
+}
+
+// New returns a JSONLogger writing messages of level and above to out
+func New(out io.Writer, level Level) *JSONLogger {
+	return &JSONLogger{mu: &sync.Mutex{}, out: out, level: level, now: time.Now}
+}
+
+var defaultLogger Logger = New(os.Stderr, InfoLevel)
+var defaultMu sync.Mutex
+
+// Default returns the logger of components not handed one, it writes JSON to
+// stderr unless SetDefault replaced it
+func Default() Logger {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultLogger
+}
+
+// SetDefault replaces the logger Default returns
+func SetDefault(l Logger) {
+	defaultMu.Lock()
+	defaultLogger = l
+	defaultMu.Unlock()
+}
+
+func (l *JSONLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(DebugLevel, msg, keysAndValues)
+}
+
+func (l *JSONLogger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(InfoLevel, msg, keysAndValues)
+}
+
+func (l *JSONLogger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(WarnLevel, msg, keysAndValues)
+}
+
+func (l *JSONLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.log(ErrorLevel, msg, keysAndValues)
+}
+
+func (l *JSONLogger) With(keysAndValues ...interface{}) Logger {
+	c := *l
+	c.fields = append(append([]interface{}{}, l.fields...), keysAndValues...)
+	return &c
+}
+
+func (l *JSONLogger) log(level Level, msg string, keysAndValues []interface{}) {
+	if level < l.level {
+		return
+	}
+	var b bytes.Buffer
+	b.WriteString("{")
+	writePair(&b, "ts", l.now().UTC().Format(time.RFC3339Nano))
+	b.WriteString(",")
+	writePair(&b, "level", level.String())
+	b.WriteString(",")
+	writePair(&b, "msg", Redact(msg))
+	for _, f := range Fields(append(append([]interface{}{}, l.fields...), keysAndValues...)) {
+		b.WriteString(",")
+		writePair(&b, f.Key, f.Value)
+	}
+	b.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(b.Bytes())
+}
+
+func writePair(b *bytes.Buffer, key string, value interface{}) {
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	b.Write(k)
+	b.WriteString(":")
+	b.Write(v)
+}
+
+// Field is a key and its redacted value
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields pairs up alternating keys and values and redacts the values. A key
+// without a value gets an empty one, keys that are not strings are formatted.
+func Fields(keysAndValues []interface{}) []Field {
+	fields := make([]Field, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		var value interface{}
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+		fields = append(fields, Field{Key: key, Value: redactField(key, value)})
+	}
+	return fields
+}
+
+// Nop returns a Logger dropping every message
+func Nop() Logger {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Debug(string, ...interface{}) {}
+func (nop) Info(string, ...interface{})  {}
+func (nop) Warn(string, ...interface{})  {}
+func (nop) Error(string, ...interface{}) {}
+func (n nop) With(...interface{}) Logger { return n }
@@ -0,0 +1,73 @@
+package log
+
+import "sync"
+
+// Entry is a message kept by a Recorder, its fields are redacted
+type Entry struct {
+	Level   Level
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Recorder keeps the messages logged to it, tests check them with Entries
+type Recorder struct {
+	mu      *sync.Mutex
+	entries *[]Entry
+	fields  []interface{}
+}
+
+// NewRecorder returns an empty Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{mu: &sync.Mutex{}, entries: &[]Entry{}}
+}
+
+// Entries returns the messages logged so far, including those of the loggers
+// returned by With
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry{}, *r.entries...)
+}
+
+// Messages returns the messages of a level logged so far
+func (r *Recorder) Messages(level Level) []string {
+	var messages []string
+	for _, e := range r.Entries() {
+		if e.Level == level {
+			messages = append(messages, e.Message)
+		}
+	}
+	return messages
+}
+
+func (r *Recorder) Debug(msg string, keysAndValues ...interface{}) {
+	r.record(DebugLevel, msg, keysAndValues)
+}
+
+func (r *Recorder) Info(msg string, keysAndValues ...interface{}) {
+	r.record(InfoLevel, msg, keysAndValues)
+}
+
+func (r *Recorder) Warn(msg string, keysAndValues ...interface{}) {
+	r.record(WarnLevel, msg, keysAndValues)
+}
+
+func (r *Recorder) Error(msg string, keysAndValues ...interface{}) {
+	r.record(ErrorLevel, msg, keysAndValues)
+}
+
+func (r *Recorder) With(keysAndValues ...interface{}) Logger {
+	c := *r
+	c.fields = append(append([]interface{}{}, r.fields...), keysAndValues...)
+	return &c
+}
+
+func (r *Recorder) record(level Level, msg string, keysAndValues []interface{}) {
+	fields := map[string]interface{}{}
+	for _, f := range Fields(append(append([]interface{}{}, r.fields...), keysAndValues...)) {
+		fields[f.Key] = f.Value
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.entries = append(*r.entries, Entry{Level: level, Message: Redact(msg), Fields: fields})
+}
@@ -0,0 +1,215 @@
+package master
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/kms"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// LegacyNamespace held the plain master secrets of earlier versions
+	LegacyNamespace = "kube-system"
+
+	// CIPHERTEXT and DATA_KEY are the keys of encrypted master secrets
+	CIPHERTEXT = "ciphertext"
+	DATA_KEY   = "dataKey"
+
+	// EncryptionAnnotation marks encrypted master secrets
+	EncryptionAnnotation = "postgresdb.myob.com/encryption"
+)
+
+// Store keeps master credentials in the home of the masterCredentials
+// configuration: a plain or encrypted secret in the namespace of their scope,
+// or an external credential store. Credentials found in another home, such
+// as the kube-system secrets of earlier versions, are read as well and
+// removed once the credential is stored again.
+type Store struct {
+	client   kubernetes.Interface
+	config   config.Getter
+	keys     kms.DataKeys
+	external core.CredentialsStorer
+}
+
+// NewStore returns a Store encrypting secrets with keys and keeping external
+// credentials in external, either can be nil if its mode is not used
+func NewStore(client kubernetes.Interface, c config.Getter, keys kms.DataKeys, external core.CredentialsStorer) *Store {
+	return &Store{
+		client:   client,
+		config:   c,
+		keys:     keys,
+		external: external,
+	}
+}
+
+// GetCred returns a master credential from its home, or nil if it is not stored anywhere
+func (s *Store) GetCred(credScope database.Scope, id database.CredentialID) (*database.Credential, error) {
+	if s.mode() == config.MasterCredentialsExternal {
+		if s.external == nil {
+			return nil, fmt.Errorf("no external store configured for master credentials")
+		}
+		cred, err := s.external.GetCred(credScope, id)
+		if err != nil || cred != nil {
+			return cred, err
+		}
+	}
+
+	for _, ns := range s.namespaces(credScope) {
+		secret, err := s.client.CoreV1().Secrets(ns).Get(string(id), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cred, err := s.decode(secret)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read master secret %s/%s: %v", ns, id, err)
+		}
+		cred.ID = id
+		cred.Scope = credScope
+		return cred, nil
+	}
+	return nil, nil
+}
+
+// CreateCred stores a master credential in its home
+func (s *Store) CreateCred(credential *database.Credential) error {
+	return s.put(credential)
+}
+
+// UpdateCred stores a master credential in its home, the credential may have
+// been read from another home
+func (s *Store) UpdateCred(credential *database.Credential) error {
+	return s.put(credential)
+}
+
+func (s *Store) put(cred *database.Credential) error {
+	ns := string(cred.Scope)
+	mode := s.mode()
+
+	switch mode {
+	case config.MasterCredentialsExternal:
+		if s.external == nil {
+			return fmt.Errorf("no external store configured for master credentials")
+		}
+		if err := core.StoreDBCredentials(s.external, &database.Credentials{cred.CredType: cred}); err != nil {
+			return err
+		}
+	default:
+		secret, err := s.encode(cred, mode)
+		if err != nil {
+			return err
+		}
+		if err := s.apply(secret); err != nil {
+			return err
+		}
+	}
+
+	// the credential only lives in its home from now on
+	for _, other := range s.namespaces(cred.Scope) {
+		if other == ns && mode != config.MasterCredentialsExternal {
+			continue
+		}
+		err := s.client.CoreV1().Secrets(other).Delete(string(cred.ID), &metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("unable to remove master secret %s/%s: %v", other, cred.ID, err)
+		}
+	}
+	return nil
+}
+
+// namespaces are where master secrets of a scope are looked for
+func (s *Store) namespaces(credScope database.Scope) []string {
+	if credScope == LegacyNamespace {
+		return []string{LegacyNamespace}
+	}
+	return []string{string(credScope), LegacyNamespace}
+}
+
+func (s *Store) mode() string {
+	return s.config.Get().MasterCredentials.Mode
+}
+
+func (s *Store) apply(secret *v1.Secret) error {
+	secrets := s.client.CoreV1().Secrets(secret.Namespace)
+	_, err := secrets.Update(secret)
+	if errors.IsNotFound(err) {
+		_, err = secrets.Create(secret)
+	}
+	return err
+}
+
+// encode returns the secret of a credential, encrypted with a new data key in the encrypted mode
+func (s *Store) encode(cred *database.Credential, mode string) (*v1.Secret, error) {
+	payload := k8s.CredentialData(cred, s.config.Get().Secrets)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      string(cred.ID),
+			Namespace: string(cred.Scope),
+			Labels: map[string]string{
+				"deployed-with": "ops-kube-db-operator",
+				"db-name":       cred.DatabaseName,
+			},
+		},
+		Data: map[string][]byte{},
+	}
+
+	if mode != config.MasterCredentialsEncrypted {
+		for k, v := range payload {
+			secret.Data[k] = []byte(v)
+		}
+		return secret, nil
+	}
+
+	if s.keys == nil {
+		return nil, fmt.Errorf("no data keys configured for encrypted master credentials")
+	}
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, dataKey, err := kms.Seal(s.keys, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	secret.Annotations = map[string]string{EncryptionAnnotation: "aes-256-gcm"}
+	secret.Data[CIPHERTEXT] = ciphertext
+	secret.Data[DATA_KEY] = dataKey
+	return secret, nil
+}
+
+// decode reads plain and encrypted secrets whatever the current mode, so
+// changing the mode does not lose credentials
+func (s *Store) decode(secret *v1.Secret) (*database.Credential, error) {
+	payload := map[string]string{}
+	if _, ok := secret.Annotations[EncryptionAnnotation]; !ok {
+		for k, v := range secret.Data {
+			payload[k] = string(v)
+		}
+		for k, v := range secret.StringData {
+			payload[k] = v
+		}
+		return k8s.CredentialFromData(payload), nil
+	}
+
+	if s.keys == nil {
+		return nil, fmt.Errorf("secret is encrypted and no data keys are configured")
+	}
+	plaintext, err := kms.Open(s.keys, secret.Data[CIPHERTEXT], secret.Data[DATA_KEY])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return nil, err
+	}
+	return k8s.CredentialFromData(payload), nil
+}
@@ -0,0 +1,133 @@
+package master
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/kms"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type memoryStore map[database.CredentialID]*database.Credential
+
+func (m memoryStore) GetCred(s database.Scope, id database.CredentialID) (*database.Credential, error) {
+	return m[id], nil
+}
+
+func (m memoryStore) CreateCred(cred *database.Credential) error {
+	m[cred.ID] = cred
+	return nil
+}
+
+func (m memoryStore) UpdateCred(cred *database.Credential) error {
+	m[cred.ID] = cred
+	return nil
+}
+
+func newConfig(mode string) config.Getter {
+	c := config.Default()
+	c.MasterCredentials.Mode = mode
+	return config.Fixed{Config: c}
+}
+
+func newKeys(t *testing.T) kms.DataKeys {
+	f, _ := ioutil.TempFile("", "key")
+	defer os.Remove(f.Name())
+	f.Write(bytes.Repeat([]byte{1}, 32))
+	f.Close()
+	keys, err := kms.NewLocalDataKeys(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func masterCred(pw database.Password) *database.Credential {
+	return &database.Credential{
+		ID:       "team-orders-master",
+		Scope:    "postgresdb-operator",
+		Username: "master",
+		Password: pw,
+		Host:     "orders.rds.amazonaws.com",
+		Port:     5432,
+	}
+}
+
+func storeLegacy(t *testing.T, f *fake.Clientset) {
+	legacy := masterCred("legacy-password")
+	legacy.Scope = LegacyNamespace
+	if err := k8s.NewStoreCreds(f).CreateCred(legacy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStore_Secret(t *testing.T) {
+	f := fake.NewSimpleClientset()
+	s := NewStore(f, newConfig(config.MasterCredentialsSecret), nil, nil)
+
+	assert.Nil(t, s.CreateCred(masterCred("secret-password")))
+
+	cred, err := s.GetCred("postgresdb-operator", "team-orders-master")
+	assert.Nil(t, err)
+	assert.Equal(t, database.Password("secret-password"), cred.Password)
+	assert.Equal(t, int64(5432), cred.Port)
+}
+
+func TestStore_Encrypted(t *testing.T) {
+	f := fake.NewSimpleClientset()
+	s := NewStore(f, newConfig(config.MasterCredentialsEncrypted), newKeys(t), nil)
+
+	assert.Nil(t, s.CreateCred(masterCred("secret-password")))
+
+	secret, _ := f.CoreV1().Secrets("postgresdb-operator").Get("team-orders-master", metav1.GetOptions{})
+	assert.NotContains(t, string(secret.Data[CIPHERTEXT]), "secret-password")
+	assert.Empty(t, secret.Data[k8s.PASSWORD])
+
+	cred, err := s.GetCred("postgresdb-operator", "team-orders-master")
+	assert.Nil(t, err)
+	assert.Equal(t, database.Password("secret-password"), cred.Password)
+	assert.Equal(t, "orders.rds.amazonaws.com", cred.Host)
+}
+
+func TestStore_External(t *testing.T) {
+	f := fake.NewSimpleClientset()
+	external := memoryStore{}
+	s := NewStore(f, newConfig(config.MasterCredentialsExternal), nil, external)
+
+	assert.Nil(t, s.CreateCred(masterCred("secret-password")))
+
+	assert.Equal(t, database.Password("secret-password"), external["team-orders-master"].Password)
+	secrets, _ := f.CoreV1().Secrets("postgresdb-operator").List(metav1.ListOptions{})
+	assert.Empty(t, secrets.Items)
+}
+
+func TestStore_MigratesLegacySecret(t *testing.T) {
+	f := fake.NewSimpleClientset()
+	storeLegacy(t, f)
+	s := NewStore(f, newConfig(config.MasterCredentialsEncrypted), newKeys(t), nil)
+
+	// the kube-system secret is read until the credential is stored again
+	cred, err := s.GetCred("postgresdb-operator", "team-orders-master")
+	assert.Nil(t, err)
+	assert.Equal(t, database.Password("legacy-password"), cred.Password)
+
+	assert.Nil(t, s.UpdateCred(cred))
+
+	_, err = f.CoreV1().Secrets(LegacyNamespace).Get("team-orders-master", metav1.GetOptions{})
+	assert.NotNil(t, err)
+	cred, err = s.GetCred("postgresdb-operator", "team-orders-master")
+	assert.Nil(t, err)
+	assert.Equal(t, database.Password("legacy-password"), cred.Password)
+}
+
+func TestStore_MissingKeys(t *testing.T) {
+	s := NewStore(fake.NewSimpleClientset(), newConfig(config.MasterCredentialsEncrypted), nil, nil)
+	assert.NotNil(t, s.CreateCred(masterCred("secret-password")))
+}
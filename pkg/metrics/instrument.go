@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// RDS is the part of rds.RDSClient the operator calls
+type RDS interface {
+	core.DBCreateGetter
+	core.TagReconciler
+	RotateMasterPassword(loc database.Location, dbID database.DatabaseID, pw database.Password) error
+}
+
+// InstrumentedRDS records the latency, outcome and throttling of the RDS API
+// calls behind every method
+type InstrumentedRDS struct {
+	rds RDS
+}
+
+// InstrumentRDS wraps an rds.RDSClient
+func InstrumentRDS(r RDS) *InstrumentedRDS {
+	return &InstrumentedRDS{rds: r}
+}
+
+func (i *InstrumentedRDS) CreateDB(req *database.Request, masterCreds *database.Credential) (*database.Database, error) {
+	start := time.Now()
+	db, err := i.rds.CreateDB(req, masterCreds)
+	recordRDS("CreateDBInstance", start, err)
+	return db, err
+}
+
+func (i *InstrumentedRDS) GetDB(loc database.Location, dbID database.DatabaseID) (*database.Database, error) {
+	start := time.Now()
+	db, err := i.rds.GetDB(loc, dbID)
+	recordRDS("DescribeDBInstances", start, err)
+	return db, err
+}
+
+func (i *InstrumentedRDS) RotateMasterPassword(loc database.Location, dbID database.DatabaseID, pw database.Password) error {
+	start := time.Now()
+	err := i.rds.RotateMasterPassword(loc, dbID, pw)
+	recordRDS("ModifyDBInstance", start, err)
+	return err
+}
+
+// ReconcileTags is recorded as AddTagsToResource, the call that changes tags
+// most of the time
+func (i *InstrumentedRDS) ReconcileTags(loc database.Location, dbID database.DatabaseID, tags map[string]string, removed []string) error {
+	start := time.Now()
+	err := i.rds.ReconcileTags(loc, dbID, tags, removed)
+	recordRDS("AddTagsToResource", start, err)
+	return err
+}
+
+func recordRDS(operation string, start time.Time, err error) {
+	RDSRequestDuration.With(operation).Observe(time.Since(start).Seconds())
+	RDSRequestsTotal.With(operation, outcome(err)).Inc()
+	if err != nil && request.IsErrorThrottle(err) {
+		RDSThrottledTotal.With(operation).Inc()
+	}
+}
+
+// InstrumentedCredentials records the latency and outcome of reads and writes
+// of credential secrets
+type InstrumentedCredentials struct {
+	store core.CredentialsStorer
+}
+
+// InstrumentCredentials wraps a k8s.StoreCreds
+func InstrumentCredentials(s core.CredentialsStorer) *InstrumentedCredentials {
+	return &InstrumentedCredentials{store: s}
+}
+
+func (i *InstrumentedCredentials) GetCred(credScope database.Scope, id database.CredentialID) (*database.Credential, error) {
+	start := time.Now()
+	cred, err := i.store.GetCred(credScope, id)
+	recordCredentials("get", start, err)
+	return cred, err
+}
+
+func (i *InstrumentedCredentials) CreateCred(credential *database.Credential) error {
+	start := time.Now()
+	err := i.store.CreateCred(credential)
+	recordCredentials("create", start, err)
+	return err
+}
+
+func (i *InstrumentedCredentials) UpdateCred(credential *database.Credential) error {
+	start := time.Now()
+	err := i.store.UpdateCred(credential)
+	recordCredentials("update", start, err)
+	return err
+}
+
+func recordCredentials(operation string, start time.Time, err error) {
+	CredentialStoreRequestDuration.With(operation).Observe(time.Since(start).Seconds())
+	CredentialStoreRequestsTotal.With(operation, outcome(err)).Inc()
+}
+
+// InstrumentedMetricsExporter counts the postgres exporters created by outcome
+type InstrumentedMetricsExporter struct {
+	exporter core.MetricsExporterCreator
+}
+
+// InstrumentMetricsExporter wraps a k8s.MetricsExporter
+func InstrumentMetricsExporter(e core.MetricsExporterCreator) *InstrumentedMetricsExporter {
+	return &InstrumentedMetricsExporter{exporter: e}
+}
+
+func (i *InstrumentedMetricsExporter) CreateMetricsExporter(s database.Scope, name string, id database.CredentialID) error {
+	err := i.exporter.CreateMetricsExporter(s, name, id)
+	MetricsExporterTotal.With(outcome(err)).Inc()
+	return err
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeSuccess
+}
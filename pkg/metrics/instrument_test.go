@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRDS struct {
+	err error
+}
+
+func (f fakeRDS) CreateDB(req *database.Request, masterCreds *database.Credential) (*database.Database, error) {
+	return &database.Database{}, f.err
+}
+
+func (f fakeRDS) GetDB(loc database.Location, dbID database.DatabaseID) (*database.Database, error) {
+	return &database.Database{}, f.err
+}
+
+func (f fakeRDS) ReconcileTags(loc database.Location, dbID database.DatabaseID, tags map[string]string, removed []string) error {
+	return f.err
+}
+
+func (f fakeRDS) RotateMasterPassword(loc database.Location, dbID database.DatabaseID, pw database.Password) error {
+	return f.err
+}
+
+func TestInstrumentRDS(t *testing.T) {
+	success := RDSRequestsTotal.With("DescribeDBInstances", OutcomeSuccess).Value()
+	failed := RDSRequestsTotal.With("ModifyDBInstance", OutcomeError).Value()
+	throttled := RDSThrottledTotal.With("ModifyDBInstance").Value()
+
+	_, err := InstrumentRDS(fakeRDS{}).GetDB(database.Location{}, "db")
+	assert.Nil(t, err)
+	err = InstrumentRDS(fakeRDS{err: awserr.New("Throttling", "Rate exceeded", nil)}).RotateMasterPassword(database.Location{}, "db", "pw")
+	assert.NotNil(t, err)
+	err = InstrumentRDS(fakeRDS{err: errors.New("boom")}).RotateMasterPassword(database.Location{}, "db", "pw")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, success+1, RDSRequestsTotal.With("DescribeDBInstances", OutcomeSuccess).Value())
+	assert.Equal(t, failed+2, RDSRequestsTotal.With("ModifyDBInstance", OutcomeError).Value())
+	assert.Equal(t, throttled+1, RDSThrottledTotal.With("ModifyDBInstance").Value())
+}
+
+func TestObserveReconcile(t *testing.T) {
+	requeued := ReconcileTotal.With("test", OutcomeRequeue).Value()
+	failed := ReconcileTotal.With("test", OutcomeError).Value()
+
+	ObserveReconcile("test", time.Now(), true, nil)
+	ObserveReconcile("test", time.Now(), true, errors.New("boom"))
+
+	assert.Equal(t, requeued+1, ReconcileTotal.With("test", OutcomeRequeue).Value())
+	assert.Equal(t, failed+1, ReconcileTotal.With("test", OutcomeError).Value())
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Outcomes of reconciles and calls
+const (
+	OutcomeSuccess = "success"
+	OutcomeRequeue = "requeue"
+	OutcomeError   = "error"
+)
+
+// ReconcileTotal counts reconciles of the controllers by outcome
+var ReconcileTotal = NewCounterVec("postgresdb_operator_reconcile_total",
+	"Reconciles by controller and outcome.", "controller", "outcome")
+
+// ReconcileDuration is the time reconciles took by outcome
+var ReconcileDuration = NewHistogramVec("postgresdb_operator_reconcile_duration_seconds",
+	"Time reconciles took in seconds by controller and outcome.", DefBuckets, "controller", "outcome")
+
+// RDSRequestsTotal counts RDS API calls by operation and outcome
+var RDSRequestsTotal = NewCounterVec("postgresdb_operator_rds_requests_total",
+	"RDS API calls by operation and outcome.", "operation", "outcome")
+
+// RDSRequestDuration is the latency of RDS API calls
+var RDSRequestDuration = NewHistogramVec("postgresdb_operator_rds_request_duration_seconds",
+	"Latency of RDS API calls in seconds by operation.", DefBuckets, "operation")
+
+// RDSThrottledTotal counts RDS API calls still throttled after the SDK's retries
+var RDSThrottledTotal = NewCounterVec("postgresdb_operator_rds_throttled_total",
+	"RDS API calls that failed throttled by operation.", "operation")
+
+// CredentialStoreRequestsTotal counts reads and writes of credential secrets
+var CredentialStoreRequestsTotal = NewCounterVec("postgresdb_operator_credential_store_requests_total",
+	"Credential secret reads and writes by operation and outcome.", "operation", "outcome")
+
+// CredentialStoreRequestDuration is the latency of reads and writes of credential secrets
+var CredentialStoreRequestDuration = NewHistogramVec("postgresdb_operator_credential_store_request_duration_seconds",
+	"Latency of credential secret reads and writes in seconds by operation.", DefBuckets, "operation")
+
+// MetricsExporterTotal counts creations of postgres exporters by outcome
+var MetricsExporterTotal = NewCounterVec("postgresdb_operator_metrics_exporter_total",
+	"Postgres exporter creations by outcome.", "outcome")
+
+// TimeToAvailable is the time from creating a PostgresDB to its database being available
+var TimeToAvailable = NewHistogramVec("postgresdb_operator_time_to_available_seconds",
+	"Time from creating a PostgresDB to its database being available in seconds.",
+	[]float64{60, 180, 300, 420, 600, 900, 1200, 1800, 2700, 3600}, "namespace")
+
+// PostgresDBs is the number of PostgresDBs per phase, collected from the controller's cache
+var PostgresDBs = NewGaugeFunc("postgresdb_operator_postgresdbs",
+	"PostgresDBs by status phase.", "phase")
+
+// MasterPasswordAge is the age of the master password of every PostgresDB,
+// counted from its last rotation or otherwise its creation
+var MasterPasswordAge = NewGaugeFunc("postgresdb_operator_master_password_age_seconds",
+	"Seconds since the master password of a PostgresDB was set.", "namespace", "name")
+
+// InventoryInstances is the number of RDS instances created by the operator
+// at the last inventory
+var InventoryInstances = NewGaugeVec("postgresdb_operator_inventory_instances",
+	"RDS instances created by the operator at the last inventory.")
+
+// OrphanedInstances is 1 for every RDS instance created by the operator whose
+// PostgresDB is gone
+var OrphanedInstances = NewGaugeFunc("postgresdb_operator_orphaned_instances",
+	"RDS instances created by the operator without a PostgresDB.", "region", "account", "db_id")
+
+// MissingInstances is 1 for every PostgresDB whose RDS instance is gone
+var MissingInstances = NewGaugeFunc("postgresdb_operator_missing_instances",
+	"PostgresDBs whose RDS instance no longer exists.", "namespace", "name", "db_id")
+
+var (
+	workqueueDepth = NewGaugeVec("postgresdb_operator_workqueue_depth",
+		"Current depth of a workqueue.", "name")
+	workqueueAdds = NewCounterVec("postgresdb_operator_workqueue_adds_total",
+		"Items added to a workqueue.", "name")
+	workqueueLatency = NewHistogramVec("postgresdb_operator_workqueue_queue_duration_seconds",
+		"Time items waited in a workqueue in seconds.", DefBuckets, "name")
+	workqueueWorkDuration = NewHistogramVec("postgresdb_operator_workqueue_work_duration_seconds",
+		"Time processing an item of a workqueue took in seconds.", DefBuckets, "name")
+	workqueueRetries = NewCounterVec("postgresdb_operator_workqueue_retries_total",
+		"Items of a workqueue added back rate limited.", "name")
+)
+
+// ObserveReconcile records a reconcile of a controller that started at start
+func ObserveReconcile(controller string, start time.Time, requeue bool, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	} else if requeue {
+		outcome = OutcomeRequeue
+	}
+	ReconcileTotal.With(controller, outcome).Inc()
+	ReconcileDuration.With(controller, outcome).Observe(time.Since(start).Seconds())
+}
+
+// WorkqueueProvider publishes the metrics of named workqueues, it is set with
+// workqueue.SetProvider before the queues are created
+type WorkqueueProvider struct{}
+
+var _ workqueue.MetricsProvider = WorkqueueProvider{}
+
+func (WorkqueueProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.With(name)
+}
+
+func (WorkqueueProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.With(name)
+}
+
+// NewLatencyMetric converts the microseconds workqueues observe into seconds
+func (WorkqueueProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return microseconds{workqueueLatency.With(name)}
+}
+
+func (WorkqueueProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return microseconds{workqueueWorkDuration.With(name)}
+}
+
+func (WorkqueueProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.With(name)
+}
+
+type microseconds struct {
+	*Histogram
+}
+
+func (m microseconds) Observe(v float64) {
+	m.Histogram.Observe(v / 1e6)
+}
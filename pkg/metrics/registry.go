@@ -0,0 +1,409 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the upper bounds in seconds of histograms of API calls and reconciles
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector is a metric family written out in the Prometheus text format
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds the metrics served on /metrics
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: map[string]collector{}}
+}
+
+// DefaultRegistry holds the operator's metrics
+var DefaultRegistry = NewRegistry()
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.collectors[c.name()]; ok {
+		panic(fmt.Sprintf("metric %s is already registered", c.name()))
+	}
+	r.collectors[c.name()] = c
+}
+
+// Write writes all metrics in the Prometheus text format, sorted by name
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler serves the metrics of the DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+// Handler serves the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		r.Write(w)
+	})
+}
+
+// family holds the children of a metric by their label values
+type family struct {
+	mu       sync.Mutex
+	fullName string
+	help     string
+	kind     string
+	labels   []string
+	children map[string]interface{}
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{fullName: name, help: help, kind: kind, labels: labels, children: map[string]interface{}{}}
+}
+
+func (f *family) name() string {
+	return f.fullName
+}
+
+// child returns the child of label values, creating it with create
+func (f *family) child(values []string, create func() interface{}) interface{} {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s has %d labels, got %d values", f.fullName, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.children[key]
+	if !ok {
+		c = create()
+		f.children[key] = c
+	}
+	return c
+}
+
+// sorted returns the label values and children sorted by label values
+func (f *family) sorted() ([][]string, []interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.children))
+	for key := range f.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := make([][]string, len(keys))
+	children := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if len(f.labels) > 0 {
+			values[i] = strings.Split(key, "\xff")
+		}
+		children[i] = f.children[key]
+	}
+	return values, children
+}
+
+func (f *family) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.fullName, escapeHelp(f.help), f.fullName, f.kind)
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Inc adds one to the counter
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds a non negative value to the counter
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+// Value returns the count so far
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	family
+}
+
+// NewCounterVec registers a counter with the DefaultRegistry
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec registers a counter with the registry
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{newFamily(name, help, "counter", labels)}
+	r.register(v)
+	return v
+}
+
+// With returns the counter of label values
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.child(values, func() interface{} { return &Counter{} }).(*Counter)
+}
+
+func (v *CounterVec) write(w io.Writer) {
+	v.header(w)
+	values, children := v.sorted()
+	for i, c := range children {
+		writeSample(w, v.fullName, v.labels, values[i], c.(*Counter).Value())
+	}
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// Set sets the gauge
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	g.value = v
+	g.mu.Unlock()
+}
+
+// Add adds a value, which may be negative, to the gauge
+func (g *Gauge) Add(v float64) {
+	g.mu.Lock()
+	g.value += v
+	g.mu.Unlock()
+}
+
+// Inc adds one to the gauge
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec takes one off the gauge
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value returns the current value of the gauge
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	family
+}
+
+// NewGaugeVec registers a gauge with the DefaultRegistry
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labels...)
+}
+
+// NewGaugeVec registers a gauge with the registry
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{newFamily(name, help, "gauge", labels)}
+	r.register(v)
+	return v
+}
+
+// With returns the gauge of label values
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.child(values, func() interface{} { return &Gauge{} }).(*Gauge)
+}
+
+func (v *GaugeVec) write(w io.Writer) {
+	v.header(w)
+	values, children := v.sorted()
+	for i, c := range children {
+		writeSample(w, v.fullName, v.labels, values[i], c.(*Gauge).Value())
+	}
+}
+
+// Sample is a value of a GaugeFunc with its label values
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// GaugeFunc is a gauge computed when the metrics are scraped
+type GaugeFunc struct {
+	family
+	collectMu sync.Mutex
+	collect   func() []Sample
+}
+
+// NewGaugeFunc registers a computed gauge with the DefaultRegistry, the
+// samples are collected by Collect
+func NewGaugeFunc(name, help string, labels ...string) *GaugeFunc {
+	return DefaultRegistry.NewGaugeFunc(name, help, labels...)
+}
+
+// NewGaugeFunc registers a computed gauge with the registry
+func (r *Registry) NewGaugeFunc(name, help string, labels ...string) *GaugeFunc {
+	v := &GaugeFunc{family: newFamily(name, help, "gauge", labels)}
+	r.register(v)
+	return v
+}
+
+// Collect sets the function returning the samples of the gauge, the gauge has
+// no samples until it is set
+func (v *GaugeFunc) Collect(f func() []Sample) {
+	v.collectMu.Lock()
+	v.collect = f
+	v.collectMu.Unlock()
+}
+
+func (v *GaugeFunc) write(w io.Writer) {
+	v.collectMu.Lock()
+	collect := v.collect
+	v.collectMu.Unlock()
+
+	v.header(w)
+	if collect == nil {
+		return
+	}
+	samples := collect()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].Labels, "\xff") < strings.Join(samples[j].Labels, "\xff")
+	})
+	for _, s := range samples {
+		writeSample(w, v.fullName, v.labels, s.Labels, s.Value)
+	}
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Observe adds an observation to the histogram
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	family
+	buckets []float64
+}
+
+// NewHistogramVec registers a histogram with the DefaultRegistry
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec registers a histogram with the registry, buckets are upper bounds in increasing order
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	v := &HistogramVec{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(v)
+	return v
+}
+
+// With returns the histogram of label values
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.child(values, func() interface{} {
+		return &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets))}
+	}).(*Histogram)
+}
+
+func (v *HistogramVec) write(w io.Writer) {
+	v.header(w)
+	values, children := v.sorted()
+	labels := append(append([]string{}, v.labels...), "le")
+	for i, c := range children {
+		h := c.(*Histogram)
+		h.mu.Lock()
+		for j, upper := range h.buckets {
+			writeSample(w, v.fullName+"_bucket", labels, append(append([]string{}, values[i]...), formatFloat(upper)), float64(h.counts[j]))
+		}
+		writeSample(w, v.fullName+"_bucket", labels, append(append([]string{}, values[i]...), "+Inf"), float64(h.count))
+		writeSample(w, v.fullName+"_sum", v.labels, values[i], h.sum)
+		writeSample(w, v.fullName+"_count", v.labels, values[i], float64(h.count))
+		h.mu.Unlock()
+	}
+}
+
+func writeSample(w io.Writer, name string, labels, values []string, v float64) {
+	if len(labels) == 0 {
+		fmt.Fprintf(w, "%s %s\n", name, formatFloat(v))
+		return
+	}
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", label, escapeLabel(values[i]))
+	}
+	fmt.Fprintf(w, "%s{%s} %s\n", name, strings.Join(pairs, ","), formatFloat(v))
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
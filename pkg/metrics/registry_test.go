@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	calls := r.NewCounterVec("calls_total", "Calls by outcome.", "outcome")
+	calls.With("success").Add(2)
+	calls.With("error").Inc()
+	calls.With("error").Add(-1)
+	depth := r.NewGaugeVec("depth", "Queue depth.")
+	depth.With().Inc()
+	depth.With().Inc()
+	depth.With().Dec()
+	latency := r.NewHistogramVec("latency_seconds", "Latency.", []float64{.1, 1}, "op")
+	latency.With("get").Observe(.05)
+	latency.With("get").Observe(.5)
+	latency.With("get").Observe(3)
+	phases := r.NewGaugeFunc("dbs", "DBs by phase.", "phase")
+	phases.Collect(func() []Sample {
+		return []Sample{{Labels: []string{"Creating"}, Value: 1}, {Labels: []string{`Av"ail`}, Value: 2}}
+	})
+
+	var b bytes.Buffer
+	r.Write(&b)
+	assert.Equal(t, `# HELP calls_total Calls by outcome.
+# TYPE calls_total counter
+calls_total{outcome="error"} 1
+calls_total{outcome="success"} 2
+# HELP dbs DBs by phase.
+# TYPE dbs gauge
+dbs{phase="Av\"ail"} 2
+dbs{phase="Creating"} 1
+# HELP depth Queue depth.
+# TYPE depth gauge
+depth 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="get",le="0.1"} 1
+latency_seconds_bucket{op="get",le="1"} 2
+latency_seconds_bucket{op="get",le="+Inf"} 3
+latency_seconds_sum{op="get"} 3.55
+latency_seconds_count{op="get"} 3
+`, b.String())
+}
+
+// TestRegistry_RegisterWhileWriting finds races of Write with go test -race
+func TestRegistry_RegisterWhileWriting(t *testing.T) {
+	r := NewRegistry()
+	for i := 0; i < 10; i++ {
+		r.NewCounterVec(fmt.Sprintf("calls_%d_total", i), "Calls.").With().Inc()
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 10; i < 1000; i++ {
+			r.NewCounterVec(fmt.Sprintf("calls_%d_total", i), "Calls.")
+		}
+	}()
+	for {
+		r.Write(ioutil.Discard)
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+func TestRegistry_RegisterTwice(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("calls_total", "Calls.")
+	assert.Panics(t, func() { r.NewGaugeVec("calls_total", "Calls.") })
+}
+
+func TestRegistry_WrongLabelCount(t *testing.T) {
+	r := NewRegistry()
+	calls := r.NewCounterVec("calls_total", "Calls.", "outcome")
+	assert.Panics(t, func() { calls.With("success", "extra") })
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("calls_total", "Calls.").With().Inc()
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "calls_total 1\n")
+}
+
+func TestWorkqueueProvider_Seconds(t *testing.T) {
+	h := workqueueLatency.With("test")
+	sum := h.sum
+	WorkqueueProvider{}.NewLatencyMetric("test").Observe(2e6)
+	assert.Equal(t, sum+2, h.sum)
+}
@@ -70,16 +70,52 @@ func (m *MockDBGetter) EXPECT() *MockDBGetterMockRecorder {
 }
 
 // GetDB mocks base method
-func (m *MockDBGetter) GetDB(arg0 database.DatabaseID) (*database.Database, error) {
-	ret := m.ctrl.Call(m, "GetDB", arg0)
+func (m *MockDBGetter) GetDB(arg0 database.Location, arg1 database.DatabaseID) (*database.Database, error) {
+	ret := m.ctrl.Call(m, "GetDB", arg0, arg1)
 	ret0, _ := ret[0].(*database.Database)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDB indicates an expected call of GetDB
-func (mr *MockDBGetterMockRecorder) GetDB(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockDBGetter)(nil).GetDB), arg0)
+func (mr *MockDBGetterMockRecorder) GetDB(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockDBGetter)(nil).GetDB), arg0, arg1)
+}
+
+// MockDBLister is a mock of DBLister interface
+type MockDBLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockDBListerMockRecorder
+}
+
+// MockDBListerMockRecorder is the mock recorder for MockDBLister
+type MockDBListerMockRecorder struct {
+	mock *MockDBLister
+}
+
+// NewMockDBLister creates a new mock instance
+func NewMockDBLister(ctrl *gomock.Controller) *MockDBLister {
+	mock := &MockDBLister{ctrl: ctrl}
+	mock.recorder = &MockDBListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDBLister) EXPECT() *MockDBListerMockRecorder {
+	return m.recorder
+}
+
+// ListDBs mocks base method
+func (m *MockDBLister) ListDBs(loc database.Location) ([]*database.Database, error) {
+	ret := m.ctrl.Call(m, "ListDBs", loc)
+	ret0, _ := ret[0].([]*database.Database)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDBs indicates an expected call of ListDBs
+func (mr *MockDBListerMockRecorder) ListDBs(loc interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDBs", reflect.TypeOf((*MockDBLister)(nil).ListDBs), loc)
 }
 
 // MockCredsGetter is a mock of CredsGetter interface
@@ -188,6 +224,41 @@ func (mr *MockCredsCreatorMockRecorder) CreateCred(credential interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCred", reflect.TypeOf((*MockCredsCreator)(nil).CreateCred), credential)
 }
 
+// MockCredsDeleter is a mock of CredsDeleter interface
+type MockCredsDeleter struct {
+	ctrl     *gomock.Controller
+	recorder *MockCredsDeleterMockRecorder
+}
+
+// MockCredsDeleterMockRecorder is the mock recorder for MockCredsDeleter
+type MockCredsDeleterMockRecorder struct {
+	mock *MockCredsDeleter
+}
+
+// NewMockCredsDeleter creates a new mock instance
+func NewMockCredsDeleter(ctrl *gomock.Controller) *MockCredsDeleter {
+	mock := &MockCredsDeleter{ctrl: ctrl}
+	mock.recorder = &MockCredsDeleterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCredsDeleter) EXPECT() *MockCredsDeleterMockRecorder {
+	return m.recorder
+}
+
+// DeleteCred mocks base method
+func (m *MockCredsDeleter) DeleteCred(credential *database.Credential) error {
+	ret := m.ctrl.Call(m, "DeleteCred", credential)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCred indicates an expected call of DeleteCred
+func (mr *MockCredsDeleterMockRecorder) DeleteCred(credential interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCred", reflect.TypeOf((*MockCredsDeleter)(nil).DeleteCred), credential)
+}
+
 // MockMetricsExporterCreator is a mock of MetricsExporterCreator interface
 type MockMetricsExporterCreator struct {
 	ctrl     *gomock.Controller
@@ -223,6 +294,173 @@ func (mr *MockMetricsExporterCreatorMockRecorder) CreateMetricsExporter(s, name,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMetricsExporter", reflect.TypeOf((*MockMetricsExporterCreator)(nil).CreateMetricsExporter), s, name, id)
 }
 
+// MockNetworkPreparer is a mock of NetworkPreparer interface
+type MockNetworkPreparer struct {
+	ctrl     *gomock.Controller
+	recorder *MockNetworkPreparerMockRecorder
+}
+
+// MockNetworkPreparerMockRecorder is the mock recorder for MockNetworkPreparer
+type MockNetworkPreparerMockRecorder struct {
+	mock *MockNetworkPreparer
+}
+
+// NewMockNetworkPreparer creates a new mock instance
+func NewMockNetworkPreparer(ctrl *gomock.Controller) *MockNetworkPreparer {
+	mock := &MockNetworkPreparer{ctrl: ctrl}
+	mock.recorder = &MockNetworkPreparerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockNetworkPreparer) EXPECT() *MockNetworkPreparerMockRecorder {
+	return m.recorder
+}
+
+// PrepareNetwork mocks base method
+func (m *MockNetworkPreparer) PrepareNetwork(req *database.Request) error {
+	ret := m.ctrl.Call(m, "PrepareNetwork", req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PrepareNetwork indicates an expected call of PrepareNetwork
+func (mr *MockNetworkPreparerMockRecorder) PrepareNetwork(req interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrepareNetwork", reflect.TypeOf((*MockNetworkPreparer)(nil).PrepareNetwork), req)
+}
+
+// MockKeyResolver is a mock of KeyResolver interface
+type MockKeyResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyResolverMockRecorder
+}
+
+// MockKeyResolverMockRecorder is the mock recorder for MockKeyResolver
+type MockKeyResolverMockRecorder struct {
+	mock *MockKeyResolver
+}
+
+// NewMockKeyResolver creates a new mock instance
+func NewMockKeyResolver(ctrl *gomock.Controller) *MockKeyResolver {
+	mock := &MockKeyResolver{ctrl: ctrl}
+	mock.recorder = &MockKeyResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockKeyResolver) EXPECT() *MockKeyResolverMockRecorder {
+	return m.recorder
+}
+
+// ResolveKey mocks base method
+func (m *MockKeyResolver) ResolveKey(loc database.Location, keyID string) (string, error) {
+	ret := m.ctrl.Call(m, "ResolveKey", loc, keyID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveKey indicates an expected call of ResolveKey
+func (mr *MockKeyResolverMockRecorder) ResolveKey(loc, keyID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveKey", reflect.TypeOf((*MockKeyResolver)(nil).ResolveKey), loc, keyID)
+}
+
+// MockIAMAuthenticator is a mock of IAMAuthenticator interface
+type MockIAMAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockIAMAuthenticatorMockRecorder
+}
+
+// MockIAMAuthenticatorMockRecorder is the mock recorder for MockIAMAuthenticator
+type MockIAMAuthenticatorMockRecorder struct {
+	mock *MockIAMAuthenticator
+}
+
+// NewMockIAMAuthenticator creates a new mock instance
+func NewMockIAMAuthenticator(ctrl *gomock.Controller) *MockIAMAuthenticator {
+	mock := &MockIAMAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockIAMAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockIAMAuthenticator) EXPECT() *MockIAMAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// GrantIAMRole mocks base method
+func (m *MockIAMAuthenticator) GrantIAMRole(master *database.Credential, user string) error {
+	ret := m.ctrl.Call(m, "GrantIAMRole", master, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GrantIAMRole indicates an expected call of GrantIAMRole
+func (mr *MockIAMAuthenticatorMockRecorder) GrantIAMRole(master, user interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantIAMRole", reflect.TypeOf((*MockIAMAuthenticator)(nil).GrantIAMRole), master, user)
+}
+
+// AuthToken mocks base method
+func (m *MockIAMAuthenticator) AuthToken(loc database.Location, endpoint, user string) (string, error) {
+	ret := m.ctrl.Call(m, "AuthToken", loc, endpoint, user)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthToken indicates an expected call of AuthToken
+func (mr *MockIAMAuthenticatorMockRecorder) AuthToken(loc, endpoint, user interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthToken", reflect.TypeOf((*MockIAMAuthenticator)(nil).AuthToken), loc, endpoint, user)
+}
+
+// MockDynamicCredentials is a mock of DynamicCredentials interface
+type MockDynamicCredentials struct {
+	ctrl     *gomock.Controller
+	recorder *MockDynamicCredentialsMockRecorder
+}
+
+// MockDynamicCredentialsMockRecorder is the mock recorder for MockDynamicCredentials
+type MockDynamicCredentialsMockRecorder struct {
+	mock *MockDynamicCredentials
+}
+
+// NewMockDynamicCredentials creates a new mock instance
+func NewMockDynamicCredentials(ctrl *gomock.Controller) *MockDynamicCredentials {
+	mock := &MockDynamicCredentials{ctrl: ctrl}
+	mock.recorder = &MockDynamicCredentialsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDynamicCredentials) EXPECT() *MockDynamicCredentialsMockRecorder {
+	return m.recorder
+}
+
+// ConfigureDynamicCredentials mocks base method
+func (m *MockDynamicCredentials) ConfigureDynamicCredentials(s database.Scope, name string, master *database.Credential) (map[database.CredentialType]string, error) {
+	ret := m.ctrl.Call(m, "ConfigureDynamicCredentials", s, name, master)
+	ret0, _ := ret[0].(map[database.CredentialType]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfigureDynamicCredentials indicates an expected call of ConfigureDynamicCredentials
+func (mr *MockDynamicCredentialsMockRecorder) ConfigureDynamicCredentials(s, name, master interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureDynamicCredentials", reflect.TypeOf((*MockDynamicCredentials)(nil).ConfigureDynamicCredentials), s, name, master)
+}
+
+// RemoveDynamicCredentials mocks base method
+func (m *MockDynamicCredentials) RemoveDynamicCredentials(s database.Scope, name string) error {
+	ret := m.ctrl.Call(m, "RemoveDynamicCredentials", s, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveDynamicCredentials indicates an expected call of RemoveDynamicCredentials
+func (mr *MockDynamicCredentialsMockRecorder) RemoveDynamicCredentials(s, name interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDynamicCredentials", reflect.TypeOf((*MockDynamicCredentials)(nil).RemoveDynamicCredentials), s, name)
+}
+
 // MockStatusUpdater is a mock of StatusUpdater interface
 type MockStatusUpdater struct {
 	ctrl     *gomock.Controller
@@ -355,16 +593,16 @@ func (mr *MockDBCreateGetterMockRecorder) CreateDB(req, adminCred interface{}) *
 }
 
 // GetDB mocks base method
-func (m *MockDBCreateGetter) GetDB(arg0 database.DatabaseID) (*database.Database, error) {
-	ret := m.ctrl.Call(m, "GetDB", arg0)
+func (m *MockDBCreateGetter) GetDB(arg0 database.Location, arg1 database.DatabaseID) (*database.Database, error) {
+	ret := m.ctrl.Call(m, "GetDB", arg0, arg1)
 	ret0, _ := ret[0].(*database.Database)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDB indicates an expected call of GetDB
-func (mr *MockDBCreateGetterMockRecorder) GetDB(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockDBCreateGetter)(nil).GetDB), arg0)
+func (mr *MockDBCreateGetterMockRecorder) GetDB(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockDBCreateGetter)(nil).GetDB), arg0, arg1)
 }
 
 // MockCreateDatabase is a mock of CreateDatabase interface
@@ -441,14 +679,14 @@ func (mr *MockCreateDatabaseMockRecorder) CreateDB(req, adminCred interface{}) *
 }
 
 // GetDB mocks base method
-func (m *MockCreateDatabase) GetDB(arg0 database.DatabaseID) (*database.Database, error) {
-	ret := m.ctrl.Call(m, "GetDB", arg0)
+func (m *MockCreateDatabase) GetDB(arg0 database.Location, arg1 database.DatabaseID) (*database.Database, error) {
+	ret := m.ctrl.Call(m, "GetDB", arg0, arg1)
 	ret0, _ := ret[0].(*database.Database)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDB indicates an expected call of GetDB
-func (mr *MockCreateDatabaseMockRecorder) GetDB(arg0 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockCreateDatabase)(nil).GetDB), arg0)
+func (mr *MockCreateDatabaseMockRecorder) GetDB(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockCreateDatabase)(nil).GetDB), arg0, arg1)
 }
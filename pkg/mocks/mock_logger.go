@@ -1,63 +0,0 @@
-// Code generated by MockGen. DO NOT EDIT.
-// Source: logger.go
-
-// Package mocks is a generated GoMock package.
-package mocks
-
-import (
-	gomock "github.com/golang/mock/gomock"
-	reflect "reflect"
-)
-
-// MockLogger is a mock of Logger interface
-type MockLogger struct {
-	ctrl     *gomock.Controller
-	recorder *MockLoggerMockRecorder
-}
-
-// MockLoggerMockRecorder is the mock recorder for MockLogger
-type MockLoggerMockRecorder struct {
-	mock *MockLogger
-}
-
-// NewMockLogger creates a new mock instance
-func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
-	mock := &MockLogger{ctrl: ctrl}
-	mock.recorder = &MockLoggerMockRecorder{mock}
-	return mock
-}
-
-// EXPECT returns an object that allows the caller to indicate expected use
-func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
-	return m.recorder
-}
-
-// Error mocks base method
-func (m *MockLogger) Error(message string) {
-	m.ctrl.Call(m, "Error", message)
-}
-
-// Error indicates an expected call of Error
-func (mr *MockLoggerMockRecorder) Error(message interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockLogger)(nil).Error), message)
-}
-
-// Info mocks base method
-func (m *MockLogger) Info(message string) {
-	m.ctrl.Call(m, "Info", message)
-}
-
-// Info indicates an expected call of Info
-func (mr *MockLoggerMockRecorder) Info(message interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogger)(nil).Info), message)
-}
-
-// Fatal mocks base method
-func (m *MockLogger) Fatal(message string) {
-	m.ctrl.Call(m, "Fatal", message)
-}
-
-// Fatal indicates an expected call of Fatal
-func (mr *MockLoggerMockRecorder) Fatal(message interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Fatal", reflect.TypeOf((*MockLogger)(nil).Fatal), message)
-}
@@ -5,7 +5,9 @@
 package mocks
 
 import (
-	v1alpha1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	v1beta1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	catalogue "github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
+	config "github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	database "github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -35,7 +37,7 @@ func (m *MockTransformer) EXPECT() *MockTransformerMockRecorder {
 }
 
 // CRDToRequest mocks base method
-func (m *MockTransformer) CRDToRequest(crd *v1alpha1.PostgresDB) *database.Request {
+func (m *MockTransformer) CRDToRequest(crd *v1beta1.PostgresDB) *database.Request {
 	ret := m.ctrl.Call(m, "CRDToRequest", crd)
 	ret0, _ := ret[0].(*database.Request)
 	return ret0
@@ -45,3 +47,110 @@ func (m *MockTransformer) CRDToRequest(crd *v1alpha1.PostgresDB) *database.Reque
 func (mr *MockTransformerMockRecorder) CRDToRequest(crd interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CRDToRequest", reflect.TypeOf((*MockTransformer)(nil).CRDToRequest), crd)
 }
+
+// MockSizeResolver is a mock of SizeResolver interface
+type MockSizeResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockSizeResolverMockRecorder
+}
+
+// MockSizeResolverMockRecorder is the mock recorder for MockSizeResolver
+type MockSizeResolverMockRecorder struct {
+	mock *MockSizeResolver
+}
+
+// NewMockSizeResolver creates a new mock instance
+func NewMockSizeResolver(ctrl *gomock.Controller) *MockSizeResolver {
+	mock := &MockSizeResolver{ctrl: ctrl}
+	mock.recorder = &MockSizeResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSizeResolver) EXPECT() *MockSizeResolverMockRecorder {
+	return m.recorder
+}
+
+// Resolve mocks base method
+func (m *MockSizeResolver) Resolve(size, namespace string) (*catalogue.Selection, error) {
+	ret := m.ctrl.Call(m, "Resolve", size, namespace)
+	ret0, _ := ret[0].(*catalogue.Selection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve
+func (mr *MockSizeResolverMockRecorder) Resolve(size, namespace interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockSizeResolver)(nil).Resolve), size, namespace)
+}
+
+// MockNamespaceConfig is a mock of NamespaceConfig interface
+type MockNamespaceConfig struct {
+	ctrl     *gomock.Controller
+	recorder *MockNamespaceConfigMockRecorder
+}
+
+// MockNamespaceConfigMockRecorder is the mock recorder for MockNamespaceConfig
+type MockNamespaceConfigMockRecorder struct {
+	mock *MockNamespaceConfig
+}
+
+// NewMockNamespaceConfig creates a new mock instance
+func NewMockNamespaceConfig(ctrl *gomock.Controller) *MockNamespaceConfig {
+	mock := &MockNamespaceConfig{ctrl: ctrl}
+	mock.recorder = &MockNamespaceConfigMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockNamespaceConfig) EXPECT() *MockNamespaceConfigMockRecorder {
+	return m.recorder
+}
+
+// ForNamespace mocks base method
+func (m *MockNamespaceConfig) ForNamespace(namespace string) *config.Config {
+	ret := m.ctrl.Call(m, "ForNamespace", namespace)
+	ret0, _ := ret[0].(*config.Config)
+	return ret0
+}
+
+// ForNamespace indicates an expected call of ForNamespace
+func (mr *MockNamespaceConfigMockRecorder) ForNamespace(namespace interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForNamespace", reflect.TypeOf((*MockNamespaceConfig)(nil).ForNamespace), namespace)
+}
+
+// MockClassResolver is a mock of ClassResolver interface
+type MockClassResolver struct {
+	ctrl     *gomock.Controller
+	recorder *MockClassResolverMockRecorder
+}
+
+// MockClassResolverMockRecorder is the mock recorder for MockClassResolver
+type MockClassResolverMockRecorder struct {
+	mock *MockClassResolver
+}
+
+// NewMockClassResolver creates a new mock instance
+func NewMockClassResolver(ctrl *gomock.Controller) *MockClassResolver {
+	mock := &MockClassResolver{ctrl: ctrl}
+	mock.recorder = &MockClassResolverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockClassResolver) EXPECT() *MockClassResolverMockRecorder {
+	return m.recorder
+}
+
+// Resolve mocks base method
+func (m *MockClassResolver) Resolve(className string) (*v1beta1.PostgresDBClass, error) {
+	ret := m.ctrl.Call(m, "Resolve", className)
+	ret0, _ := ret[0].(*v1beta1.PostgresDBClass)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve
+func (mr *MockClassResolverMockRecorder) Resolve(className interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockClassResolver)(nil).Resolve), className)
+}
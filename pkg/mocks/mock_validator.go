@@ -5,7 +5,7 @@
 package mocks
 
 import (
-	v1alpha1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	v1beta1 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
 )
@@ -34,7 +34,7 @@ func (m *MockPostgresDBValidator) EXPECT() *MockPostgresDBValidatorMockRecorder
 }
 
 // Validate mocks base method
-func (m *MockPostgresDBValidator) Validate(crd *v1alpha1.PostgresDB) error {
+func (m *MockPostgresDBValidator) Validate(crd *v1beta1.PostgresDB) error {
 	ret := m.ctrl.Call(m, "Validate", crd)
 	ret0, _ := ret[0].(error)
 	return ret0
@@ -44,3 +44,38 @@ func (m *MockPostgresDBValidator) Validate(crd *v1alpha1.PostgresDB) error {
 func (mr *MockPostgresDBValidatorMockRecorder) Validate(crd interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockPostgresDBValidator)(nil).Validate), crd)
 }
+
+// MockQuotaChecker is a mock of QuotaChecker interface
+type MockQuotaChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaCheckerMockRecorder
+}
+
+// MockQuotaCheckerMockRecorder is the mock recorder for MockQuotaChecker
+type MockQuotaCheckerMockRecorder struct {
+	mock *MockQuotaChecker
+}
+
+// NewMockQuotaChecker creates a new mock instance
+func NewMockQuotaChecker(ctrl *gomock.Controller) *MockQuotaChecker {
+	mock := &MockQuotaChecker{ctrl: ctrl}
+	mock.recorder = &MockQuotaCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockQuotaChecker) EXPECT() *MockQuotaCheckerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method
+func (m *MockQuotaChecker) Check(crd *v1beta1.PostgresDB) error {
+	ret := m.ctrl.Call(m, "Check", crd)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check
+func (mr *MockQuotaCheckerMockRecorder) Check(crd interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockQuotaChecker)(nil).Check), crd)
+}
@@ -0,0 +1,319 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/golang/glog"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// IngressCIDRsAnnotation lists the CIDRs connections from the pods of a
+	// namespace come from, comma separated
+	IngressCIDRsAnnotation = "postgresdb.myob.com/ingress-cidrs"
+
+	postgresPort = 5432
+)
+
+// NamespaceSelector returns the namespaces matching a label selector
+type NamespaceSelector interface {
+	Select(selector labels.Selector) []*v1.Namespace
+}
+
+// Preparer checks the subnet group and security groups of a database exist
+// before it is created and manages the security group of its own
+type Preparer struct {
+	clients    Clients
+	namespaces NamespaceSelector
+}
+
+// Clients returns the AWS clients of the region and account of a database
+type Clients interface {
+	EC2(loc database.Location) (ec2iface.EC2API, error)
+	RDS(loc database.Location) (rdsiface.RDSAPI, error)
+}
+
+// clients are the AWS clients of the location of one request
+type clients struct {
+	ec2        ec2iface.EC2API
+	rds        rdsiface.RDSAPI
+	namespaces NamespaceSelector
+}
+
+// NewPreparer returns a Preparer using the clients of each database's location
+func NewPreparer(c Clients, n NamespaceSelector) *Preparer {
+	return &Preparer{clients: c, namespaces: n}
+}
+
+// PrepareNetwork validates the network of the request and adds the managed
+// security group, created if needed and its ingress rules brought up to date,
+// to its security groups
+func (p *Preparer) PrepareNetwork(req *database.Request) error {
+	e, err := p.clients.EC2(req.Location)
+	if err != nil {
+		return err
+	}
+	r, err := p.clients.RDS(req.Location)
+	if err != nil {
+		return err
+	}
+	c := &clients{ec2: e, rds: r, namespaces: p.namespaces}
+
+	if req.SubnetGroup == "" {
+		if req.ManagedSecurityGroup != nil {
+			return fmt.Errorf("a managed security group needs a subnet group")
+		}
+		return c.checkSecurityGroups(req.SecurityGroupIDs, "", "")
+	}
+
+	vpc, err := c.subnetGroupVPC(req.SubnetGroup)
+	if err != nil {
+		return err
+	}
+	if err := c.checkSecurityGroups(req.SecurityGroupIDs, vpc, req.SubnetGroup); err != nil {
+		return err
+	}
+
+	if req.ManagedSecurityGroup == nil {
+		return nil
+	}
+	id, err := c.ensureSecurityGroup(req, vpc)
+	if err != nil {
+		return fmt.Errorf("unable to manage security group of %s: %v", req.ID, err)
+	}
+	if !contains(req.SecurityGroupIDs, id) {
+		req.SecurityGroupIDs = append(req.SecurityGroupIDs, id)
+	}
+	return nil
+}
+
+func (p *clients) subnetGroupVPC(name string) (string, error) {
+	out, err := p.rds.DescribeDBSubnetGroups(&awsrds.DescribeDBSubnetGroupsInput{
+		DBSubnetGroupName: aws.String(name),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == awsrds.ErrCodeDBSubnetGroupNotFoundFault {
+			return "", fmt.Errorf("db subnet group %s does not exist", name)
+		}
+		return "", err
+	}
+	if len(out.DBSubnetGroups) == 0 {
+		return "", fmt.Errorf("db subnet group %s does not exist", name)
+	}
+	return aws.StringValue(out.DBSubnetGroups[0].VpcId), nil
+}
+
+// checkSecurityGroups returns an error if a security group does not exist or
+// is not in the vpc of the subnet group, the vpc is not checked if empty
+func (p *clients) checkSecurityGroups(ids []string, vpc, subnetGroup string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	out, err := p.ec2.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		GroupIds: aws.StringSlice(ids),
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "InvalidGroup.NotFound" {
+			return fmt.Errorf("security group does not exist: %s", awsErr.Message())
+		}
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, g := range out.SecurityGroups {
+		id := aws.StringValue(g.GroupId)
+		found[id] = true
+		if vpc != "" && aws.StringValue(g.VpcId) != vpc {
+			return fmt.Errorf("security group %s is in %s, not in %s of db subnet group %s", id, aws.StringValue(g.VpcId), vpc, subnetGroup)
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return fmt.Errorf("security group %s does not exist", id)
+		}
+	}
+	return nil
+}
+
+// ensureSecurityGroup returns the id of the managed security group of the
+// request after creating it if missing and syncing its ingress rules
+func (p *clients) ensureSecurityGroup(req *database.Request, vpc string) (string, error) {
+	name := "postgresdb-" + string(req.ID)
+	out, err := p.ec2.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("group-name"), Values: aws.StringSlice([]string{name})},
+			{Name: aws.String("vpc-id"), Values: aws.StringSlice([]string{vpc})},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	var current []string
+	if len(out.SecurityGroups) > 0 {
+		g := out.SecurityGroups[0]
+		id = aws.StringValue(g.GroupId)
+		current = postgresCIDRs(g.IpPermissions)
+	} else {
+		created, err := p.ec2.CreateSecurityGroup(&ec2.CreateSecurityGroupInput{
+			GroupName:   aws.String(name),
+			Description: aws.String(fmt.Sprintf("postgresdb %s/%s", req.Owner, req.Name)),
+			VpcId:       aws.String(vpc),
+		})
+		if err != nil {
+			return "", err
+		}
+		id = aws.StringValue(created.GroupId)
+		glog.Infof("created security group %s for database %s", id, req.ID)
+
+		if len(req.Metadata) > 0 {
+			_, err = p.ec2.CreateTags(&ec2.CreateTagsInput{
+				Resources: aws.StringSlice([]string{id}),
+				Tags:      mapToEC2Tags(req.Metadata),
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	desired := p.ingressCIDRs(req.ManagedSecurityGroup.Ingress)
+	if add := difference(desired, current); len(add) > 0 {
+		_, err := p.ec2.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(id),
+			IpPermissions: postgresPermissions(add),
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if remove := difference(current, desired); len(remove) > 0 {
+		_, err := p.ec2.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(id),
+			IpPermissions: postgresPermissions(remove),
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// ingressCIDRs returns the sorted CIDRs the rules allow, namespaces with an
+// invalid annotation are skipped so they do not break the other namespaces
+func (p *clients) ingressCIDRs(rules []database.IngressRule) []string {
+	set := map[string]bool{}
+	for _, r := range rules {
+		if r.CIDR != "" {
+			set[r.CIDR] = true
+			continue
+		}
+
+		sel, err := labels.Parse(r.NamespaceSelector)
+		if err != nil {
+			glog.Errorf("ignoring invalid namespace selector %q: %v", r.NamespaceSelector, err)
+			continue
+		}
+		for _, ns := range p.namespaces.Select(sel) {
+			cidrs, err := namespaceCIDRs(ns)
+			if err != nil {
+				glog.Errorf("ignoring ingress cidrs of namespace %s: %v", ns.Name, err)
+				continue
+			}
+			for _, c := range cidrs {
+				set[c] = true
+			}
+		}
+	}
+
+	cidrs := make([]string, 0, len(set))
+	for c := range set {
+		cidrs = append(cidrs, c)
+	}
+	sort.Strings(cidrs)
+	return cidrs
+}
+
+func namespaceCIDRs(ns *v1.Namespace) ([]string, error) {
+	var cidrs []string
+	for _, c := range strings.Split(ns.Annotations[IngressCIDRsAnnotation], ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(c); err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, c)
+	}
+	return cidrs, nil
+}
+
+// postgresCIDRs returns the CIDRs allowed to the postgres port
+func postgresCIDRs(perms []*ec2.IpPermission) []string {
+	var cidrs []string
+	for _, perm := range perms {
+		if aws.StringValue(perm.IpProtocol) != "tcp" || aws.Int64Value(perm.FromPort) != postgresPort || aws.Int64Value(perm.ToPort) != postgresPort {
+			continue
+		}
+		for _, r := range perm.IpRanges {
+			cidrs = append(cidrs, aws.StringValue(r.CidrIp))
+		}
+	}
+	return cidrs
+}
+
+func postgresPermissions(cidrs []string) []*ec2.IpPermission {
+	perm := &ec2.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int64(postgresPort),
+		ToPort:     aws.Int64(postgresPort),
+	}
+	for _, c := range cidrs {
+		perm.IpRanges = append(perm.IpRanges, &ec2.IpRange{CidrIp: aws.String(c)})
+	}
+	return []*ec2.IpPermission{perm}
+}
+
+// difference returns the values of a that are not in b
+func difference(a, b []string) []string {
+	var diff []string
+	for _, v := range a {
+		if !contains(b, v) {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func mapToEC2Tags(m map[string]string) []*ec2.Tag {
+	var tags []*ec2.Tag
+	for k, v := range m {
+		tags = append(tags, &ec2.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(v),
+		})
+	}
+	return tags
+}
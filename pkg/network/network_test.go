@@ -0,0 +1,195 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/awsclient"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type fakeRDS struct {
+	rdsiface.RDSAPI
+	subnetGroups map[string]string
+}
+
+func (f *fakeRDS) DescribeDBSubnetGroups(in *awsrds.DescribeDBSubnetGroupsInput) (*awsrds.DescribeDBSubnetGroupsOutput, error) {
+	vpc, ok := f.subnetGroups[aws.StringValue(in.DBSubnetGroupName)]
+	if !ok {
+		return nil, awserr.New(awsrds.ErrCodeDBSubnetGroupNotFoundFault, "not found", nil)
+	}
+	return &awsrds.DescribeDBSubnetGroupsOutput{
+		DBSubnetGroups: []*awsrds.DBSubnetGroup{{DBSubnetGroupName: in.DBSubnetGroupName, VpcId: aws.String(vpc)}},
+	}, nil
+}
+
+type fakeEC2 struct {
+	ec2iface.EC2API
+	groups     []*ec2.SecurityGroup
+	created    int
+	tagged     int
+	authorized []string
+	revoked    []string
+}
+
+func (f *fakeEC2) DescribeSecurityGroups(in *ec2.DescribeSecurityGroupsInput) (*ec2.DescribeSecurityGroupsOutput, error) {
+	out := &ec2.DescribeSecurityGroupsOutput{}
+	for _, g := range f.groups {
+		if len(in.GroupIds) > 0 && contains(aws.StringValueSlice(in.GroupIds), aws.StringValue(g.GroupId)) {
+			out.SecurityGroups = append(out.SecurityGroups, g)
+		}
+		if len(in.Filters) > 0 && aws.StringValue(in.Filters[0].Values[0]) == aws.StringValue(g.GroupName) &&
+			aws.StringValue(in.Filters[1].Values[0]) == aws.StringValue(g.VpcId) {
+			out.SecurityGroups = append(out.SecurityGroups, g)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeEC2) CreateSecurityGroup(in *ec2.CreateSecurityGroupInput) (*ec2.CreateSecurityGroupOutput, error) {
+	f.created++
+	id := fmt.Sprintf("sg-managed%d", f.created)
+	f.groups = append(f.groups, &ec2.SecurityGroup{GroupId: aws.String(id), GroupName: in.GroupName, VpcId: in.VpcId})
+	return &ec2.CreateSecurityGroupOutput{GroupId: aws.String(id)}, nil
+}
+
+func (f *fakeEC2) CreateTags(in *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	f.tagged++
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *fakeEC2) AuthorizeSecurityGroupIngress(in *ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	f.authorized = append(f.authorized, postgresCIDRs(in.IpPermissions)...)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, nil
+}
+
+func (f *fakeEC2) RevokeSecurityGroupIngress(in *ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	f.revoked = append(f.revoked, postgresCIDRs(in.IpPermissions)...)
+	return &ec2.RevokeSecurityGroupIngressOutput{}, nil
+}
+
+type fakeNamespaces []*v1.Namespace
+
+func (f fakeNamespaces) Select(selector labels.Selector) []*v1.Namespace {
+	var matched []*v1.Namespace
+	for _, ns := range f {
+		if selector.Matches(labels.Set(ns.Labels)) {
+			matched = append(matched, ns)
+		}
+	}
+	return matched
+}
+
+func newPreparer(e *fakeEC2) *Preparer {
+	r := &fakeRDS{subnetGroups: map[string]string{"private": "vpc-1"}}
+	namespaces := fakeNamespaces{
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:        "payments",
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{IngressCIDRsAnnotation: "100.64.0.0/16, 100.65.0.0/16"},
+		}},
+		{ObjectMeta: metav1.ObjectMeta{
+			Name:        "broken",
+			Labels:      map[string]string{"team": "payments"},
+			Annotations: map[string]string{IngressCIDRsAnnotation: "everywhere"},
+		}},
+	}
+	return NewPreparer(awsclient.Fixed{EC2API: e, RDSAPI: r}, namespaces)
+}
+
+func newGroup(id, vpc string) *ec2.SecurityGroup {
+	return &ec2.SecurityGroup{GroupId: aws.String(id), GroupName: aws.String(id), VpcId: aws.String(vpc)}
+}
+
+func TestPrepareNetwork_Valid(t *testing.T) {
+	e := &fakeEC2{groups: []*ec2.SecurityGroup{newGroup("sg-1", "vpc-1")}}
+	req := &database.Request{ID: "db", SubnetGroup: "private", SecurityGroupIDs: []string{"sg-1"}}
+
+	assert.Nil(t, newPreparer(e).PrepareNetwork(req))
+	assert.Equal(t, []string{"sg-1"}, req.SecurityGroupIDs)
+	assert.Equal(t, 0, e.created)
+}
+
+func TestPrepareNetwork_MissingSubnetGroup(t *testing.T) {
+	req := &database.Request{ID: "db", SubnetGroup: "missing"}
+
+	err := newPreparer(&fakeEC2{}).PrepareNetwork(req)
+	assert.NotNil(t, err)
+	assert.Equal(t, "db subnet group missing does not exist", err.Error())
+}
+
+func TestPrepareNetwork_MissingSecurityGroup(t *testing.T) {
+	e := &fakeEC2{groups: []*ec2.SecurityGroup{newGroup("sg-1", "vpc-1")}}
+	req := &database.Request{ID: "db", SubnetGroup: "private", SecurityGroupIDs: []string{"sg-1", "sg-2"}}
+
+	err := newPreparer(e).PrepareNetwork(req)
+	assert.NotNil(t, err)
+	assert.Equal(t, "security group sg-2 does not exist", err.Error())
+}
+
+func TestPrepareNetwork_SecurityGroupInOtherVPC(t *testing.T) {
+	e := &fakeEC2{groups: []*ec2.SecurityGroup{newGroup("sg-1", "vpc-2")}}
+	req := &database.Request{ID: "db", SubnetGroup: "private", SecurityGroupIDs: []string{"sg-1"}}
+
+	err := newPreparer(e).PrepareNetwork(req)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "security group sg-1 is in vpc-2, not in vpc-1")
+}
+
+func TestPrepareNetwork_CreatesManagedGroup(t *testing.T) {
+	e := &fakeEC2{groups: []*ec2.SecurityGroup{newGroup("sg-1", "vpc-1")}}
+	req := &database.Request{
+		ID:               "db",
+		SubnetGroup:      "private",
+		SecurityGroupIDs: []string{"sg-1"},
+		Metadata:         map[string]string{"owner": "payments"},
+		ManagedSecurityGroup: &database.SecurityGroupRequest{Ingress: []database.IngressRule{
+			{CIDR: "10.0.0.0/16"},
+			{NamespaceSelector: "team=payments"},
+		}},
+	}
+
+	assert.Nil(t, newPreparer(e).PrepareNetwork(req))
+	assert.Equal(t, []string{"sg-1", "sg-managed1"}, req.SecurityGroupIDs)
+	assert.Equal(t, 1, e.created)
+	assert.Equal(t, 1, e.tagged)
+	assert.Equal(t, []string{"10.0.0.0/16", "100.64.0.0/16", "100.65.0.0/16"}, e.authorized)
+	assert.Nil(t, e.revoked)
+}
+
+func TestPrepareNetwork_SyncsManagedGroup(t *testing.T) {
+	managed := newGroup("sg-managed", "vpc-1")
+	managed.GroupName = aws.String("postgresdb-db")
+	managed.IpPermissions = postgresPermissions([]string{"10.0.0.0/16", "192.168.0.0/24"})
+	e := &fakeEC2{groups: []*ec2.SecurityGroup{managed}}
+	req := &database.Request{
+		ID:          "db",
+		SubnetGroup: "private",
+		ManagedSecurityGroup: &database.SecurityGroupRequest{Ingress: []database.IngressRule{
+			{CIDR: "10.0.0.0/16"},
+			{CIDR: "172.16.0.0/12"},
+		}},
+	}
+
+	assert.Nil(t, newPreparer(e).PrepareNetwork(req))
+	assert.Equal(t, []string{"sg-managed"}, req.SecurityGroupIDs)
+	assert.Equal(t, 0, e.created)
+	assert.Equal(t, []string{"172.16.0.0/12"}, e.authorized)
+	assert.Equal(t, []string{"192.168.0.0/24"}, e.revoked)
+}
+
+func TestPrepareNetwork_ManagedGroupNeedsSubnetGroup(t *testing.T) {
+	req := &database.Request{ID: "db", ManagedSecurityGroup: &database.SecurityGroupRequest{}}
+
+	assert.NotNil(t, newPreparer(&fakeEC2{}).PrepareNetwork(req))
+}
@@ -0,0 +1,189 @@
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const gib = 1 << 30
+
+// Transformer builds the request of a postgresdb, the quota counts the
+// request so class, configuration and catalogue defaults are included
+type Transformer interface {
+	CRDToRequest(crd *v1beta1.PostgresDB) *database.Request
+}
+
+// SizeResolver resolves the maxSize of a quota to an instance class
+type SizeResolver interface {
+	Resolve(size, namespace string) (*catalogue.Selection, error)
+}
+
+// Usage is what the databases of a namespace count against its quotas
+type Usage struct {
+	Databases  int64
+	StorageGiB int64
+	HA         int64
+}
+
+func (u *Usage) add(req *database.Request) {
+	u.Databases++
+	u.StorageGiB += req.Storage
+	if req.HA {
+		u.HA++
+	}
+}
+
+// Checker enforces the PostgresDBQuotas of a namespace
+type Checker struct {
+	quotas   listers.PostgresDBQuotaLister
+	dbs      listers.PostgresDBLister
+	requests Transformer
+	sizes    SizeResolver
+}
+
+// NewChecker returns a Checker reading quotas and postgresdbs from the given listers
+func NewChecker(q listers.PostgresDBQuotaLister, d listers.PostgresDBLister, t Transformer, s SizeResolver) *Checker {
+	return &Checker{
+		quotas:   q,
+		dbs:      d,
+		requests: t,
+		sizes:    s,
+	}
+}
+
+// Check returns an error naming the exceeded limit if creating crd would take
+// its namespace over any of its quotas
+func (c *Checker) Check(crd *v1beta1.PostgresDB) error {
+	quotas, err := c.quotas.PostgresDBQuotas(crd.Namespace).List(labels.Everything())
+	if err != nil || len(quotas) == 0 {
+		return err
+	}
+
+	used, err := c.Usage(crd.Namespace, crd.Name)
+	if err != nil {
+		return err
+	}
+	req := c.requests.CRDToRequest(crd)
+	used.add(req)
+
+	for _, q := range quotas {
+		if err := c.checkQuota(q, used, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkQuota(q *v1beta1.PostgresDBQuota, used *Usage, req *database.Request) error {
+	s := q.Spec
+	if s.MaxDatabases != nil && used.Databases > *s.MaxDatabases {
+		return exceeded(q, "%d databases, limited to %d", used.Databases, *s.MaxDatabases)
+	}
+	if s.MaxStorage != nil && used.StorageGiB > storageGiB(*s.MaxStorage) {
+		return exceeded(q, "%dGi storage, limited to %s", used.StorageGiB, s.MaxStorage.String())
+	}
+	if s.MaxHA != nil && req.HA && used.HA > *s.MaxHA {
+		return exceeded(q, "%d multi-AZ databases, limited to %d", used.HA, *s.MaxHA)
+	}
+	if s.MaxSize != "" {
+		max, err := c.sizes.Resolve(s.MaxSize, q.Namespace)
+		if err != nil {
+			return fmt.Errorf("postgresdb quota %s has an invalid maxSize: %v", q.Name, err)
+		}
+		larger, err := isLarger(req.InstanceClass, max.InstanceClass)
+		if err != nil {
+			return fmt.Errorf("postgresdb quota %s: %v", q.Name, err)
+		}
+		if larger {
+			return exceeded(q, "instance class %s is larger than %s", req.InstanceClass, s.MaxSize)
+		}
+	}
+	return nil
+}
+
+func exceeded(q *v1beta1.PostgresDBQuota, format string, args ...interface{}) error {
+	return &database.QuotaExceededError{Quota: q.Name, Limit: fmt.Sprintf(format, args...)}
+}
+
+// Usage adds up the databases of a namespace, leaving out the postgresdb
+// named exclude, postgresdbs being deleted and postgresdbs that were rejected
+// before their database was created
+func (c *Checker) Usage(namespace, exclude string) (*Usage, error) {
+	dbs, err := c.dbs.PostgresDBs(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	used := &Usage{}
+	for _, db := range dbs {
+		if db.Name == exclude || db.DeletionTimestamp != nil {
+			continue
+		}
+		if db.Status.ID == "" && (db.Status.Phase == database.StatusErrored.String() || overQuota(db)) {
+			continue
+		}
+		used.add(c.requests.CRDToRequest(db))
+	}
+	return used, nil
+}
+
+// overQuota returns true for postgresdbs whose database was last not created
+// because they exceeded a quota
+func overQuota(db *v1beta1.PostgresDB) bool {
+	for _, c := range db.Status.Conditions {
+		if c.Type == v1beta1.ConditionProgressing && c.Status == corev1.ConditionTrue {
+			return c.Reason == rds.ClassPostgresDBQuotaExceeded.Reason()
+		}
+	}
+	return false
+}
+
+func storageGiB(q resource.Quantity) int64 {
+	return (q.Value() + gib - 1) / gib
+}
+
+// isLarger compares the size of two instance classes, eg. db.m4.2xlarge is
+// larger than db.t2.xlarge whatever their family
+func isLarger(class, than string) (bool, error) {
+	a, err := sizeRank(class)
+	if err != nil {
+		return false, err
+	}
+	b, err := sizeRank(than)
+	if err != nil {
+		return false, err
+	}
+	return a > b, nil
+}
+
+var sizeRanks = map[string]int{
+	"nano":   0,
+	"micro":  1,
+	"small":  2,
+	"medium": 3,
+	"large":  4,
+	"xlarge": 5,
+}
+
+func sizeRank(class string) (int, error) {
+	size := class[strings.LastIndex(class, ".")+1:]
+	if r, ok := sizeRanks[size]; ok {
+		return r, nil
+	}
+	if strings.HasSuffix(size, "xlarge") {
+		if n, err := strconv.Atoi(strings.TrimSuffix(size, "xlarge")); err == nil && n > 0 {
+			return sizeRanks["xlarge"] + n - 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unable to compare the size of instance class %q", class)
+}
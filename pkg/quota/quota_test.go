@@ -0,0 +1,192 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// specRequests builds requests straight from the spec, sizes are instance classes
+type specRequests struct{}
+
+func (specRequests) CRDToRequest(crd *v1beta1.PostgresDB) *database.Request {
+	return &database.Request{
+		Name:          crd.Name,
+		InstanceClass: crd.Spec.InstanceClass,
+		Storage:       crd.Spec.StorageGiB(),
+		HA:            crd.Spec.HA,
+	}
+}
+
+type classSizes struct{}
+
+func (classSizes) Resolve(size, namespace string) (*catalogue.Selection, error) {
+	return &catalogue.Selection{InstanceClass: size}, nil
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func newDB(name, class string, storage int64, ha bool) *v1beta1.PostgresDB {
+	return &v1beta1.PostgresDB{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team"},
+		Spec: v1beta1.PostgresDBSpec{
+			InstanceClass: class,
+			Storage:       *resource.NewQuantity(storage*gib, resource.BinarySI),
+			HA:            ha,
+		},
+	}
+}
+
+func newQuota(spec v1beta1.PostgresDBQuotaSpec) *v1beta1.PostgresDBQuota {
+	return &v1beta1.PostgresDBQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "team"},
+		Spec:       spec,
+	}
+}
+
+func newChecker(quotas []*v1beta1.PostgresDBQuota, dbs ...*v1beta1.PostgresDB) *Checker {
+	qi := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, q := range quotas {
+		qi.Add(q)
+	}
+	di := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, db := range dbs {
+		di.Add(db)
+	}
+	return NewChecker(listers.NewPostgresDBQuotaLister(qi), listers.NewPostgresDBLister(di), specRequests{}, classSizes{})
+}
+
+func TestCheck_NoQuota(t *testing.T) {
+	c := newChecker(nil, newDB("a", "db.m4.large", 100, true))
+
+	assert.Nil(t, c.Check(newDB("b", "db.m4.4xlarge", 1000, true)))
+}
+
+func TestCheck_MaxDatabases(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxDatabases: int64Ptr(2)})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q}, newDB("a", "db.t2.small", 10, false), newDB("b", "db.t2.small", 10, false))
+
+	err := c.Check(newDB("c", "db.t2.small", 10, false))
+	assert.NotNil(t, err)
+	assert.Equal(t, "postgresdb quota limits exceeded: 3 databases, limited to 2", err.Error())
+
+	// an existing database is not counted twice
+	assert.Nil(t, c.Check(newDB("b", "db.t2.small", 10, false)))
+}
+
+func TestCheck_MaxStorage(t *testing.T) {
+	max := resource.MustParse("100Gi")
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxStorage: &max})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q}, newDB("a", "db.t2.small", 60, false))
+
+	assert.Nil(t, c.Check(newDB("b", "db.t2.small", 40, false)))
+
+	err := c.Check(newDB("b", "db.t2.small", 41, false))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "101Gi storage, limited to 100Gi")
+}
+
+func TestCheck_MaxHA(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxHA: int64Ptr(1)})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q}, newDB("a", "db.t2.small", 10, true))
+
+	assert.Nil(t, c.Check(newDB("b", "db.t2.small", 10, false)))
+
+	err := c.Check(newDB("b", "db.t2.small", 10, true))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "2 multi-AZ databases, limited to 1")
+}
+
+func TestCheck_MaxSize(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxSize: "db.m4.xlarge"})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q})
+
+	assert.Nil(t, c.Check(newDB("a", "db.r4.xlarge", 10, false)))
+	assert.Nil(t, c.Check(newDB("a", "db.t2.medium", 10, false)))
+
+	err := c.Check(newDB("a", "db.m4.2xlarge", 10, false))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "instance class db.m4.2xlarge is larger than db.m4.xlarge")
+}
+
+func TestCheck_OtherNamespace(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxDatabases: int64Ptr(0)})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q})
+
+	db := newDB("a", "db.t2.small", 10, false)
+	db.Namespace = "other"
+	assert.Nil(t, c.Check(db))
+}
+
+func TestUsage_SkipsDeleted(t *testing.T) {
+	deleted := newDB("b", "db.t2.small", 20, true)
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	c := newChecker(nil, newDB("a", "db.t2.small", 10, true), deleted)
+
+	used, err := c.Usage("team", "")
+	assert.Nil(t, err)
+	assert.Equal(t, &Usage{Databases: 1, StorageGiB: 10, HA: 1}, used)
+}
+
+func TestUsage_SkipsRejected(t *testing.T) {
+	rejected := newDB("b", "db.t2.small", 20, true)
+	rejected.Status.Phase = database.StatusErrored.String()
+	overQuota := newDB("d", "db.t2.small", 40, false)
+	overQuota.Status = overQuotaStatus()
+	failed := newDB("c", "db.t2.small", 30, false)
+	failed.Status = v1beta1.PostgresDBStatus{ID: "c-1234", Phase: database.StatusErrored.String()}
+	c := newChecker(nil, newDB("a", "db.t2.small", 10, true), rejected, overQuota, failed)
+
+	used, err := c.Usage("team", "")
+	assert.Nil(t, err)
+	assert.Equal(t, &Usage{Databases: 2, StorageGiB: 40, HA: 1}, used)
+}
+
+func TestCheck_RacingCreates(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxDatabases: int64Ptr(1)})
+	a, b := newDB("a", "db.t2.small", 10, false), newDB("b", "db.t2.small", 10, false)
+	c := newChecker([]*v1beta1.PostgresDBQuota{q}, a, b)
+
+	// both were created at once and rejected as each counted the other
+	err := c.Check(a)
+	assert.Equal(t, &database.QuotaExceededError{Quota: "limits", Limit: "2 databases, limited to 1"}, err)
+	assert.NotNil(t, c.Check(b))
+	a.Status = overQuotaStatus()
+	b.Status = overQuotaStatus()
+
+	// the next check of either gets its database created
+	assert.Nil(t, c.Check(a))
+}
+
+func overQuotaStatus() v1beta1.PostgresDBStatus {
+	return v1beta1.PostgresDBStatus{
+		Phase: database.StatusUnavailable.String(),
+		Conditions: []v1beta1.PostgresDBCondition{
+			{Type: v1beta1.ConditionFailed, Status: corev1.ConditionFalse},
+			{Type: v1beta1.ConditionProgressing, Status: corev1.ConditionTrue, Reason: "PostgresDBQuotaExceeded"},
+		},
+	}
+}
+
+func TestSizeRank(t *testing.T) {
+	ranks := []string{"db.t2.micro", "db.t2.small", "db.t2.medium", "db.m4.large", "db.m4.xlarge", "db.m4.2xlarge", "db.m4.10xlarge"}
+	for i := 1; i < len(ranks); i++ {
+		larger, err := isLarger(ranks[i], ranks[i-1])
+		assert.Nil(t, err)
+		assert.True(t, larger, ranks[i])
+	}
+
+	_, err := sizeRank("db.m4.huge")
+	assert.NotNil(t, err)
+}
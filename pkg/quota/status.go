@@ -0,0 +1,65 @@
+package quota
+
+import (
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// StatusReporter keeps the usage in the status of every PostgresDBQuota up to date
+type StatusReporter struct {
+	checker *Checker
+	client  versioned.Interface
+	period  time.Duration
+}
+
+// NewStatusReporter returns a StatusReporter counting usage with the given checker
+func NewStatusReporter(c *Checker, client versioned.Interface) *StatusReporter {
+	return &StatusReporter{
+		checker: c,
+		client:  client,
+		period:  30 * time.Second,
+	}
+}
+
+// Run reports the usage periodically until stopCh is closed
+func (r *StatusReporter) Run(stopCh <-chan struct{}) {
+	wait.Until(r.Report, r.period, stopCh)
+}
+
+// Report updates the status of the quotas whose usage changed
+func (r *StatusReporter) Report() {
+	quotas, err := r.checker.quotas.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("unable to list postgresdb quotas: %v", err)
+		return
+	}
+
+	for _, q := range quotas {
+		used, err := r.checker.Usage(q.Namespace, "")
+		if err != nil {
+			glog.Errorf("unable to count usage of postgresdb quota %s/%s: %v", q.Namespace, q.Name, err)
+			continue
+		}
+
+		storage := *resource.NewQuantity(used.StorageGiB*gib, resource.BinarySI)
+		current := q.Status.Used
+		if current.Databases == used.Databases && current.HA == used.HA && current.Storage.Cmp(storage) == 0 {
+			continue
+		}
+
+		updated := q.DeepCopy()
+		updated.Status.Used.Databases = used.Databases
+		updated.Status.Used.Storage = storage
+		updated.Status.Used.HA = used.HA
+		updated.Status.LastUpdated = metav1.Now()
+		if _, err := r.client.PostgresdbV1beta1().PostgresDBQuotas(q.Namespace).UpdateStatus(updated); err != nil {
+			glog.Errorf("unable to update status of postgresdb quota %s/%s: %v", q.Namespace, q.Name, err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReport_UpdatesUsage(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{MaxDatabases: int64Ptr(5)})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q}, newDB("a", "db.t2.small", 10, true), newDB("b", "db.t2.small", 20, false))
+	client := fake.NewSimpleClientset(q)
+
+	NewStatusReporter(c, client).Report()
+
+	updated, err := client.PostgresdbV1beta1().PostgresDBQuotas("team").Get("limits", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), updated.Status.Used.Databases)
+	assert.Equal(t, int64(1), updated.Status.Used.HA)
+	assert.Equal(t, "30Gi", updated.Status.Used.Storage.String())
+	assert.False(t, updated.Status.LastUpdated.IsZero())
+}
+
+func TestReport_SkipsUnchanged(t *testing.T) {
+	q := newQuota(v1beta1.PostgresDBQuotaSpec{})
+	c := newChecker([]*v1beta1.PostgresDBQuota{q})
+	client := fake.NewSimpleClientset(q)
+
+	NewStatusReporter(c, client).Report()
+
+	assert.Empty(t, client.Actions())
+}
@@ -2,6 +2,7 @@ package rds
 
 import (
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	awsrds "github.com/aws/aws-sdk-go/service/rds"
@@ -9,14 +10,23 @@ import (
 )
 
 type RDSClient struct {
-	client rdsiface.RDSAPI
+	clients Clients
 	RDSTransformer
+	// Logger logs the calls changing databases, it is the default logger
+	// unless replaced
+	Logger log.Logger
 }
 
-func NewRDSImpure(rdsclient rdsiface.RDSAPI, t RDSTransformer) *RDSClient {
+// Clients returns the RDS client of the region and account of a database
+type Clients interface {
+	RDS(loc database.Location) (rdsiface.RDSAPI, error)
+}
+
+func NewRDSImpure(c Clients, t RDSTransformer) *RDSClient {
 	return &RDSClient{
-		client:         rdsclient,
+		clients:        c,
 		RDSTransformer: t,
+		Logger:         log.Default().With("component", "rds"),
 	}
 }
 
@@ -27,7 +37,25 @@ func (r *RDSClient) CreateDB(req *database.Request, masterCreds *database.Creden
 		return nil, err
 	}
 
-	db, err := r.client.CreateDBInstance(i)
+	client, err := r.clients.RDS(req.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Logger.Info("creating db instance", "db-id", req.ID, "region", req.Location.Region, "account", req.Location.Account)
+	db, err := client.CreateDBInstance(i)
+	if Classify(err) == ClassAlreadyExists {
+		// an earlier reconcile created it, its describe call was throttled
+		// or the instance was not listed yet
+		r.Logger.Info("db instance already exists", "db-id", req.ID)
+		existing, gerr := r.GetDB(req.Location, req.ID)
+		if gerr == nil && existing != nil {
+			if !existing.OwnedBy(req.OwnerUID) {
+				return nil, &database.OwnershipError{ID: req.ID, OwnerUID: existing.OwnerUID}
+			}
+			return existing, nil
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -39,18 +67,90 @@ func (r *RDSClient) CreateDB(req *database.Request, masterCreds *database.Creden
 	return modelDB, nil
 }
 
-func (r *RDSClient) GetDB(dbID database.DatabaseID) (*database.Database, error) {
+func (r *RDSClient) GetDB(loc database.Location, dbID database.DatabaseID) (*database.Database, error) {
+	client, err := r.clients.RDS(loc)
+	if err != nil {
+		return nil, err
+	}
+
 	dbInput := &awsrds.DescribeDBInstancesInput{
 		DBInstanceIdentifier: aws.String(string(dbID)),
 	}
-	dbOutput, err := r.client.DescribeDBInstances(dbInput)
+	dbOutput, err := client.DescribeDBInstances(dbInput)
 	if err != nil {
 		awsError, ok := err.(awserr.Error)
 		if ok && awsError.Code() == awsrds.ErrCodeDBInstanceNotFoundFault {
+			r.Logger.Debug("db instance not found", "db-id", dbID)
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	return r.RDSToModel(dbOutput.DBInstances[0])
+	instance := dbOutput.DBInstances[0]
+	db, err := r.RDSToModel(instance)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := instanceTags(client, instance)
+	if err != nil {
+		return nil, err
+	}
+	db.OwnerUID = tags[UIDTag]
+	return db, nil
+}
+
+// ListDBs returns the databases the operator created in a region and account,
+// the instances are described page by page and told apart by their tags
+func (r *RDSClient) ListDBs(loc database.Location) ([]*database.Database, error) {
+	client, err := r.clients.RDS(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []*awsrds.DBInstance
+	err = client.DescribeDBInstancesPages(&awsrds.DescribeDBInstancesInput{}, func(page *awsrds.DescribeDBInstancesOutput, _ bool) bool {
+		instances = append(instances, page.DBInstances...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var dbs []*database.Database
+	for _, instance := range instances {
+		tags, err := instanceTags(client, instance)
+		if err != nil {
+			return nil, err
+		}
+		if tags[CreatedByTag] != CreatedByOperator {
+			continue
+		}
+		db, err := r.RDSToModel(instance)
+		if err != nil {
+			return nil, err
+		}
+		db.Owner = tags[OwnerTag]
+		db.Name = tags[NameTag]
+		db.OwnerUID = tags[UIDTag]
+		dbs = append(dbs, db)
+	}
+	r.Logger.Debug("listed db instances", "region", loc.Region, "account", loc.Account, "instances", len(instances), "owned", len(dbs))
+	return dbs, nil
+}
+
+// RotateMasterPassword replaces the master password of a database, the change
+// is applied immediately while the database stays available
+func (r *RDSClient) RotateMasterPassword(loc database.Location, dbID database.DatabaseID, pw database.Password) error {
+	client, err := r.clients.RDS(loc)
+	if err != nil {
+		return err
+	}
+
+	r.Logger.Info("rotating master password", "db-id", dbID, "region", loc.Region, "account", loc.Account)
+	_, err = client.ModifyDBInstance(&awsrds.ModifyDBInstanceInput{
+		DBInstanceIdentifier: aws.String(string(dbID)),
+		MasterUserPassword:   aws.String(string(pw)),
+		ApplyImmediately:     aws.Bool(true),
+	})
+	return err
 }
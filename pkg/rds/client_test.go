@@ -0,0 +1,147 @@
+package rds
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRDS has one instance, tagged with the uid of its postgresdb, unless
+// pages of instances are given
+type fakeRDS struct {
+	rdsiface.RDSAPI
+	ownerUID string
+	pages    [][]*awsrds.DBInstance
+	tags     map[string][]*awsrds.Tag
+	added    *awsrds.AddTagsToResourceInput
+	removed  *awsrds.RemoveTagsFromResourceInput
+}
+
+func (f *fakeRDS) AddTagsToResource(in *awsrds.AddTagsToResourceInput) (*awsrds.AddTagsToResourceOutput, error) {
+	f.added = in
+	return &awsrds.AddTagsToResourceOutput{}, nil
+}
+
+func (f *fakeRDS) RemoveTagsFromResource(in *awsrds.RemoveTagsFromResourceInput) (*awsrds.RemoveTagsFromResourceOutput, error) {
+	f.removed = in
+	return &awsrds.RemoveTagsFromResourceOutput{}, nil
+}
+
+func (f *fakeRDS) CreateDBInstance(*awsrds.CreateDBInstanceInput) (*awsrds.CreateDBInstanceOutput, error) {
+	return nil, awserr.New(awsrds.ErrCodeDBInstanceAlreadyExistsFault, "instance already exists", nil)
+}
+
+func (f *fakeRDS) DescribeDBInstances(*awsrds.DescribeDBInstancesInput) (*awsrds.DescribeDBInstancesOutput, error) {
+	i := getRDSInstance()
+	i.DBInstanceArn = aws.String("arn:aws:rds:ap-southeast-2:123456789012:db:test-test-test")
+	return &awsrds.DescribeDBInstancesOutput{DBInstances: []*awsrds.DBInstance{i}}, nil
+}
+
+func (f *fakeRDS) DescribeDBInstancesPages(_ *awsrds.DescribeDBInstancesInput, fn func(*awsrds.DescribeDBInstancesOutput, bool) bool) error {
+	for i, page := range f.pages {
+		if !fn(&awsrds.DescribeDBInstancesOutput{DBInstances: page}, i == len(f.pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeRDS) ListTagsForResource(in *awsrds.ListTagsForResourceInput) (*awsrds.ListTagsForResourceOutput, error) {
+	if f.tags != nil {
+		return &awsrds.ListTagsForResourceOutput{TagList: f.tags[aws.StringValue(in.ResourceName)]}, nil
+	}
+	return &awsrds.ListTagsForResourceOutput{TagList: []*awsrds.Tag{
+		{Key: aws.String("crd-name"), Value: aws.String("test")},
+		{Key: aws.String(UIDTag), Value: aws.String(f.ownerUID)},
+	}}, nil
+}
+
+type fixedClients struct {
+	rdsiface.RDSAPI
+}
+
+func (c fixedClients) RDS(database.Location) (rdsiface.RDSAPI, error) {
+	return c.RDSAPI, nil
+}
+
+func getClient(ownerUID string) *RDSClient {
+	return newClient(&fakeRDS{ownerUID: ownerUID})
+}
+
+func newClient(f *fakeRDS) *RDSClient {
+	s := "test"
+	c := NewRDSImpure(fixedClients{f}, NewBumblebee(NewRDSTransformerConfig(&s, []*string{&s})))
+	c.Logger = log.Nop()
+	return c
+}
+
+func instance(id string) *awsrds.DBInstance {
+	i := getRDSInstance()
+	i.DBInstanceIdentifier = aws.String(id)
+	i.DBInstanceArn = aws.String("arn:aws:rds:ap-southeast-2:123456789012:db:" + id)
+	return i
+}
+
+func tags(kv ...string) []*awsrds.Tag {
+	var t []*awsrds.Tag
+	for i := 0; i < len(kv); i += 2 {
+		t = append(t, &awsrds.Tag{Key: aws.String(kv[i]), Value: aws.String(kv[i+1])})
+	}
+	return t
+}
+
+func TestListDBs_Paginated(t *testing.T) {
+	arn := "arn:aws:rds:ap-southeast-2:123456789012:db:"
+	f := &fakeRDS{
+		pages: [][]*awsrds.DBInstance{
+			{instance("orders-8e60dae354"), instance("someone-else")},
+			{instance("legacy-5678")},
+		},
+		tags: map[string][]*awsrds.Tag{
+			arn + "orders-8e60dae354": tags(CreatedByTag, CreatedByOperator, OwnerTag, "team", NameTag, "orders", UIDTag, "1234"),
+			arn + "someone-else":      tags("created-by", "terraform"),
+			arn + "legacy-5678":       tags(CreatedByTag, CreatedByOperator, OwnerTag, "team", NameTag, "legacy"),
+		},
+	}
+
+	dbs, err := newClient(f).ListDBs(database.Location{})
+	assert.Nil(t, err)
+	assert.Len(t, dbs, 2)
+	assert.Equal(t, database.DatabaseID("orders-8e60dae354"), dbs[0].ID)
+	assert.Equal(t, "team", dbs[0].Owner)
+	assert.Equal(t, "orders", dbs[0].Name)
+	assert.Equal(t, "1234", dbs[0].OwnerUID)
+	assert.Equal(t, database.DatabaseID("legacy-5678"), dbs[1].ID)
+	assert.Empty(t, dbs[1].OwnerUID)
+}
+
+func TestGetDB_OwnerUID(t *testing.T) {
+	db, err := getClient("1234").GetDB(database.Location{}, "test-test-test")
+	assert.Nil(t, err)
+	assert.Equal(t, "1234", db.OwnerUID)
+}
+
+func TestCreateDB_AdoptsOwnInstance(t *testing.T) {
+	req := getRequest()
+	req.OwnerUID = "1234"
+
+	db, err := getClient("1234").CreateDB(req, getMasterCred())
+	assert.Nil(t, err)
+	assert.Equal(t, database.DatabaseID("test-test-test"), db.ID)
+}
+
+func TestCreateDB_InstanceOfAnotherPostgresDB(t *testing.T) {
+	req := getRequest()
+	req.OwnerUID = "1234"
+
+	db, err := getClient("5678").CreateDB(req, getMasterCred())
+	assert.Nil(t, db)
+	assert.EqualError(t, err, "db instance test-test-test is owned by postgresdb 5678")
+	assert.Equal(t, ClassOwnershipConflict, Classify(err))
+}
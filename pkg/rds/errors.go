@@ -0,0 +1,174 @@
+package rds
+
+import (
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+)
+
+// ErrorClass tells the errors of RDS calls that go away on their own apart
+// from those that need someone to change the postgresdb or the account
+type ErrorClass int
+
+const (
+	// ClassUnknown errors are not retried, they are reported as they are
+	ClassUnknown ErrorClass = iota
+	// ClassThrottled errors mean the operator called the RDS API too often
+	ClassThrottled
+	// ClassTransient errors are timeouts, connection errors and errors of
+	// the RDS API itself
+	ClassTransient
+	// ClassInsufficientCapacity errors mean the availability zone has no
+	// capacity of the instance class for now
+	ClassInsufficientCapacity
+	// ClassQuotaExceeded errors need a quota of the account raised or
+	// databases removed
+	ClassQuotaExceeded
+	// ClassInvalidParameter errors need the postgresdb or the operator
+	// config changed
+	ClassInvalidParameter
+	// ClassAlreadyExists errors mean the database was created by an earlier
+	// reconcile
+	ClassAlreadyExists
+	// ClassOwnershipConflict errors mean the identifier of the database is
+	// taken by the instance of another postgresdb
+	ClassOwnershipConflict
+	// ClassPostgresDBQuotaExceeded errors mean the postgresdb would take its
+	// namespace over a PostgresDBQuota, they go away once databases of the
+	// namespace are removed or the quota is raised
+	ClassPostgresDBQuotaExceeded
+)
+
+var classReasons = map[ErrorClass]string{
+	ClassUnknown:              "Unknown",
+	ClassThrottled:            "Throttled",
+	ClassTransient:            "TransientError",
+	ClassInsufficientCapacity: "InsufficientCapacity",
+	ClassQuotaExceeded:        "QuotaExceeded",
+	ClassInvalidParameter:     "InvalidParameter",
+	ClassAlreadyExists:        "AlreadyExists",
+	ClassOwnershipConflict:    "OwnershipConflict",
+
+	ClassPostgresDBQuotaExceeded: "PostgresDBQuotaExceeded",
+}
+
+// Reason returns the reason of the conditions of postgresdbs failing with
+// errors of the class
+func (c ErrorClass) Reason() string {
+	if r, ok := classReasons[c]; ok {
+		return r
+	}
+	return classReasons[ClassUnknown]
+}
+
+func (c ErrorClass) String() string {
+	return c.Reason()
+}
+
+// Retryable returns true for classes of errors that go away on their own,
+// the call is worth trying again after a backoff
+func (c ErrorClass) Retryable() bool {
+	switch c {
+	case ClassThrottled, ClassTransient, ClassInsufficientCapacity, ClassAlreadyExists:
+		return true
+	}
+	return false
+}
+
+// Terminal returns true for classes of errors that need someone to change the
+// postgresdb or the account, trying again does not help. Errors of the other
+// classes, including unknown ones, are worth trying again after a backoff.
+func (c ErrorClass) Terminal() bool {
+	switch c {
+	case ClassInvalidParameter, ClassQuotaExceeded, ClassOwnershipConflict:
+		return true
+	}
+	return false
+}
+
+var classCodes = map[string]ErrorClass{
+	awsrds.ErrCodeInstanceQuotaExceededFault:         ClassQuotaExceeded,
+	awsrds.ErrCodeStorageQuotaExceededFault:          ClassQuotaExceeded,
+	awsrds.ErrCodeDBSubnetQuotaExceededFault:         ClassQuotaExceeded,
+	awsrds.ErrCodeDBSubnetGroupQuotaExceededFault:    ClassQuotaExceeded,
+	awsrds.ErrCodeDBParameterGroupQuotaExceededFault: ClassQuotaExceeded,
+	awsrds.ErrCodeSnapshotQuotaExceededFault:         ClassQuotaExceeded,
+
+	awsrds.ErrCodeInsufficientDBInstanceCapacityFault:     ClassInsufficientCapacity,
+	awsrds.ErrCodeInsufficientStorageClusterCapacityFault: ClassInsufficientCapacity,
+
+	"InvalidParameterValue":                        ClassInvalidParameter,
+	"InvalidParameterCombination":                  ClassInvalidParameter,
+	"MissingParameter":                             ClassInvalidParameter,
+	awsrds.ErrCodeDBSubnetGroupNotFoundFault:       ClassInvalidParameter,
+	awsrds.ErrCodeDBParameterGroupNotFoundFault:    ClassInvalidParameter,
+	awsrds.ErrCodeDBSecurityGroupNotFoundFault:     ClassInvalidParameter,
+	awsrds.ErrCodeOptionGroupNotFoundFault:         ClassInvalidParameter,
+	awsrds.ErrCodeStorageTypeNotSupportedFault:     ClassInvalidParameter,
+	awsrds.ErrCodeKMSKeyNotAccessibleFault:         ClassInvalidParameter,
+	awsrds.ErrCodeInvalidSubnet:                    ClassInvalidParameter,
+	awsrds.ErrCodeInvalidVPCNetworkStateFault:      ClassInvalidParameter,
+	awsrds.ErrCodeInvalidDBSubnetGroupStateFault:   ClassInvalidParameter,
+	awsrds.ErrCodeDBSecurityGroupNotSupportedFault: ClassInvalidParameter,
+
+	awsrds.ErrCodeDBInstanceAlreadyExistsFault: ClassAlreadyExists,
+}
+
+// Classify returns the class of an error of an RDS call. Errors that are not
+// from AWS, eg. of the operator config, are of ClassUnknown.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ClassUnknown
+	}
+	if _, ok := err.(*database.OwnershipError); ok {
+		return ClassOwnershipConflict
+	}
+	if _, ok := err.(*database.InvalidSpecError); ok {
+		return ClassInvalidParameter
+	}
+	if _, ok := err.(*database.QuotaExceededError); ok {
+		return ClassPostgresDBQuotaExceeded
+	}
+	if request.IsErrorThrottle(err) {
+		return ClassThrottled
+	}
+	if request.IsErrorRetryable(err) {
+		return ClassTransient
+	}
+	aerr, ok := err.(awserr.Error)
+	if !ok {
+		return ClassUnknown
+	}
+	if c, ok := classCodes[aerr.Code()]; ok {
+		return c
+	}
+	if f, ok := err.(awserr.RequestFailure); ok && f.StatusCode() >= 500 {
+		return ClassTransient
+	}
+	return ClassUnknown
+}
+
+// IsRetryable returns true if the RDS call that failed with err is worth
+// trying again after a backoff
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// IsTerminal returns true if the reconcile that failed with err is not worth
+// trying again until the postgresdb or the account changes
+func IsTerminal(err error) bool {
+	return err != nil && Classify(err).Terminal()
+}
+
+// IsTerminalReason returns true if a condition reason is not the reason of
+// a class of errors worth trying again, ie. it is the reason of a terminal
+// class or not the reason of a failure at all, eg. Creating
+func IsTerminalReason(reason string) bool {
+	for c, r := range classReasons {
+		if r == reason {
+			return c.Terminal()
+		}
+	}
+	return true
+}
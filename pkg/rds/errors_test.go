@@ -0,0 +1,53 @@
+package rds
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		err       error
+		class     ErrorClass
+		retryable bool
+		terminal  bool
+	}{
+		{awserr.New("Throttling", "Rate exceeded", nil), ClassThrottled, true, false},
+		{awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil), ClassThrottled, true, false},
+		{awserr.New("RequestError", "send request failed", errors.New("connection reset by peer")), ClassTransient, true, false},
+		{awserr.NewRequestFailure(awserr.New("InternalFailure", "internal failure", nil), 503, "req-1"), ClassTransient, true, false},
+		{awserr.New("InsufficientDBInstanceCapacity", "no capacity for db.r4.16xlarge", nil), ClassInsufficientCapacity, true, false},
+		{awserr.New("InstanceQuotaExceeded", "instance quota exceeded", nil), ClassQuotaExceeded, false, true},
+		{awserr.New("StorageQuotaExceeded", "storage quota exceeded", nil), ClassQuotaExceeded, false, true},
+		{awserr.New("InvalidParameterCombination", "db.t2.micro does not support encryption", nil), ClassInvalidParameter, false, true},
+		{awserr.New("DBSubnetGroupNotFoundFault", "subnets not found", nil), ClassInvalidParameter, false, true},
+		{awserr.New("DBInstanceAlreadyExists", "instance already exists", nil), ClassAlreadyExists, true, false},
+		{&database.OwnershipError{ID: "orders-8e60dae354", OwnerUID: "5678"}, ClassOwnershipConflict, false, true},
+		{&database.QuotaExceededError{Quota: "limits", Limit: "3 databases, limited to 2"}, ClassPostgresDBQuotaExceeded, false, false},
+		{awserr.NewRequestFailure(awserr.New("AccessDenied", "not authorized", nil), 403, "req-2"), ClassUnknown, false, false},
+		{errors.New("aws account billing is not configured"), ClassUnknown, false, false},
+		{&database.InvalidSpecError{Err: errors.New("size cannot be empty")}, ClassInvalidParameter, false, true},
+		{nil, ClassUnknown, false, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.class, Classify(c.err), "%v", c.err)
+		assert.Equal(t, c.retryable, IsRetryable(c.err), "%v", c.err)
+		assert.Equal(t, c.terminal, IsTerminal(c.err), "%v", c.err)
+	}
+}
+
+func TestErrorClass_Reason(t *testing.T) {
+	assert.Equal(t, "Throttled", ClassThrottled.Reason())
+	assert.Equal(t, "QuotaExceeded", ClassQuotaExceeded.String())
+	assert.Equal(t, "Unknown", ErrorClass(42).Reason())
+
+	assert.False(t, IsTerminalReason("InsufficientCapacity"))
+	assert.False(t, IsTerminalReason("Unknown"))
+	assert.False(t, IsTerminalReason("PostgresDBQuotaExceeded"))
+	assert.True(t, IsTerminalReason("InvalidParameter"))
+	assert.True(t, IsTerminalReason("Creating"))
+}
@@ -0,0 +1,71 @@
+package rds
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+)
+
+const (
+	// MaxIdentifierLength is the longest DB instance identifier RDS accepts
+	MaxIdentifierLength = 63
+	// identifierHashLength is the number of hex digits of the hash of the
+	// uid ending every identifier
+	identifierHashLength = 10
+	// identifierPrefix starts the identifiers of names not starting with a letter
+	identifierPrefix = "pg"
+)
+
+// Identifier returns the DB instance identifier of the postgresdb with a name
+// and uid. Identifiers are the name made valid for RDS, lower case letters,
+// digits and single hyphens starting with a letter, followed by a hash of the
+// uid so postgresdbs with the same name, or names only differing past the
+// length RDS allows, never share an instance.
+func Identifier(name, uid string) database.DatabaseID {
+	sum := sha256.Sum256([]byte(uid))
+	suffix := hex.EncodeToString(sum[:])[:identifierHashLength]
+
+	base := sanitizeIdentifier(name)
+	if base == "" || base[0] < 'a' || base[0] > 'z' {
+		base = strings.TrimSuffix(identifierPrefix+"-"+base, "-")
+	}
+	if max := MaxIdentifierLength - len(suffix) - 1; len(base) > max {
+		base = strings.TrimRight(base[:max], "-")
+	}
+	return database.DatabaseID(base + "-" + suffix)
+}
+
+// LegacyIdentifier returns the identifier earlier versions gave the instance
+// of a postgresdb, its name and uid cut to the length RDS allows. Instances
+// created under it keep it.
+func LegacyIdentifier(name, uid string) database.DatabaseID {
+	s := name + "-" + uid
+	for len(s) > MaxIdentifierLength {
+		_, i := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-i]
+	}
+	return database.DatabaseID(s)
+}
+
+// sanitizeIdentifier lower cases s and replaces runs of anything but letters
+// and digits with a single hyphen, dropping hyphens at either end
+func sanitizeIdentifier(s string) string {
+	var b bytes.Buffer
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
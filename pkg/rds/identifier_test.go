@@ -0,0 +1,57 @@
+package rds
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var validIdentifier = regexp.MustCompile(`^[a-z]([a-z0-9]|-[a-z0-9])*$`)
+
+func TestIdentifier_Valid(t *testing.T) {
+	names := []string{
+		"orders",
+		"bankfeed-migrator",
+		"9lives",
+		"-",
+		"",
+		"Orders..DB--",
+		"x" + strings.Repeat("-y", 40),
+		strings.Repeat("a", 200),
+	}
+	for _, n := range names {
+		id := string(Identifier(n, "2098284b-1daf-11e8-b83f-028cde27f28a"))
+		assert.Regexp(t, validIdentifier, id, n)
+		assert.True(t, len(id) <= MaxIdentifierLength, n)
+	}
+}
+
+func TestIdentifier_Names(t *testing.T) {
+	uid := "2098284b-1daf-11e8-b83f-028cde27f28a"
+	assert.Equal(t, "orders-8e60dae354", string(Identifier("orders", uid)))
+	assert.Equal(t, "orders-db-8e60dae354", string(Identifier("Orders..DB--", uid)))
+	assert.Equal(t, "pg-9lives-8e60dae354", string(Identifier("9lives", uid)))
+	assert.Equal(t, "pg-8e60dae354", string(Identifier("-", uid)))
+}
+
+func TestIdentifier_Deterministic(t *testing.T) {
+	assert.Equal(t, Identifier("orders", "1234"), Identifier("orders", "1234"))
+}
+
+func TestIdentifier_Collisions(t *testing.T) {
+	// names only differing past the length RDS allows
+	long := strings.Repeat("a", 70)
+	assert.NotEqual(t, Identifier(long+"1", "1234"), Identifier(long+"2", "5678"))
+	// postgresdbs of the same name in other namespaces
+	assert.NotEqual(t, Identifier("orders", "1234"), Identifier("orders", "5678"))
+}
+
+func TestLegacyIdentifier(t *testing.T) {
+	assert.Equal(t, "orders-2098284b-1daf-11e8-b83f-028cde27f28a", string(LegacyIdentifier("orders", "2098284b-1daf-11e8-b83f-028cde27f28a")))
+
+	id := LegacyIdentifier(strings.Repeat("a", 40), "2098284b-1daf-11e8-b83f-028cde27f28a")
+	assert.Equal(t, strings.Repeat("a", 40)+"-2098284b-1daf-11e8-b83", string(id))
+	assert.Len(t, string(id), MaxIdentifierLength)
+}
@@ -0,0 +1,65 @@
+package rds
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucket limits the rate of RDS calls. One bucket is shared by the RDS
+// clients of every region and account, so many postgresdbs reconciling at
+// once stay under the rate limits of the RDS API rather than being throttled.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewTokenBucket returns a full TokenBucket refilled with qps tokens a second
+// up to burst tokens
+func NewTokenBucket(qps float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   qps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// Wait takes a token, waiting until there is one. Callers are served in the
+// order they called Wait.
+func (b *TokenBucket) Wait() {
+	if d := b.reserve(); d > 0 {
+		b.sleep(d)
+	}
+}
+
+// reserve takes a token and returns how long until it is available, tokens
+// taken ahead of time leave the bucket in debt
+func (b *TokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,88 @@
+package rds
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slept = append(c.slept, d)
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestBucket(qps float64, burst int) (*TokenBucket, *clock) {
+	c := &clock{now: time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)}
+	b := NewTokenBucket(qps, burst)
+	b.now = c.Now
+	b.sleep = c.Sleep
+	return b, c
+}
+
+func TestTokenBucket_Burst(t *testing.T) {
+	b, c := newTestBucket(2, 3)
+
+	// the burst goes out right away, later calls wait for their token in turn
+	for i := 0; i < 5; i++ {
+		b.Wait()
+	}
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, time.Second}, c.slept)
+}
+
+func TestTokenBucket_Refill(t *testing.T) {
+	b, c := newTestBucket(2, 3)
+	for i := 0; i < 3; i++ {
+		b.Wait()
+	}
+
+	c.Advance(time.Second)
+	b.Wait()
+	b.Wait()
+	assert.Empty(t, c.slept)
+
+	// the bucket never holds more than the burst
+	c.Advance(time.Hour)
+	for i := 0; i < 4; i++ {
+		b.Wait()
+	}
+	assert.Equal(t, []time.Duration{500 * time.Millisecond}, c.slept)
+}
+
+func TestTokenBucket_Concurrent(t *testing.T) {
+	b := NewTokenBucket(1000, 10)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.Wait()
+		}()
+	}
+	wg.Wait()
+
+	// 40 tokens past the burst take 40ms at 1000 a second
+	assert.True(t, time.Since(start) >= 39*time.Millisecond, "took %s", time.Since(start))
+}
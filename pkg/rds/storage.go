@@ -0,0 +1,15 @@
+package rds
+
+// minStorage is the least storage in GiB RDS allocates to a PostgreSQL
+// instance of each storage type
+var minStorage = map[string]int64{
+	"gp2":      20,
+	"io1":      100,
+	"standard": 5,
+}
+
+// MinStorage returns the least storage in GiB RDS accepts for a PostgreSQL
+// instance of a storage type, 0 for types it does not know
+func MinStorage(storageType string) int64 {
+	return minStorage[storageType]
+}
@@ -0,0 +1,187 @@
+package rds
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+// Tags of the instances the operator creates
+const (
+	// UIDTag is the tag of the uid of the postgresdb owning an instance
+	UIDTag = "postgresdb-uid"
+	// OwnerTag is the tag of the namespace of the postgresdb
+	OwnerTag = "owner"
+	// NameTag is the tag of the name of the postgresdb
+	NameTag = "crd-name"
+	// CreatedByTag tells the instances of the operator apart, its value is
+	// CreatedByOperator
+	CreatedByTag      = "created-by"
+	CreatedByOperator = "ops-kube-db-operator"
+	// NamespaceTag is the tag of the namespace of the postgresdb for cost allocation
+	NamespaceTag = "namespace"
+	// APIVersionTag is the tag of the api version of the postgresdb
+	APIVersionTag = "api-version"
+	// ClusterTag is the tag of the name of the cluster of the operator
+	ClusterTag = "cluster"
+	// CostCentreTag is the tag of the cost centre of the namespace
+	CostCentreTag = "cost-centre"
+	// SizeTierTag, MaxConnectionsTag and ClassTag describe the size and
+	// class the instance was created with
+	SizeTierTag       = "size-tier"
+	MaxConnectionsTag = "max-connections"
+	ClassTag          = "postgresdb-class"
+)
+
+// operatorTags are the keys the operator manages, they are removed from
+// instances whose request no longer has them
+var operatorTags = []string{
+	UIDTag, OwnerTag, NameTag, CreatedByTag, NamespaceTag, APIVersionTag,
+	ClusterTag, CostCentreTag, SizeTierTag, MaxConnectionsTag, ClassTag,
+}
+
+// Limits of the tags of an RDS resource
+const (
+	MaxTags           = 50
+	maxTagKeyLength   = 128
+	maxTagValueLength = 256
+)
+
+// MaxSpecTags is the number of tags a postgresdb can add to those of the operator
+var MaxSpecTags = MaxTags - len(operatorTags)
+
+var tagChars = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+
+// ValidateTags returns the first tag RDS would reject, keys and values are
+// limited in length and characters and keys must not start with aws:
+func ValidateTags(tags map[string]string) error {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := tags[k]
+		switch {
+		case k == "":
+			return fmt.Errorf("tag keys cannot be empty")
+		case utf8.RuneCountInString(k) > maxTagKeyLength:
+			return fmt.Errorf("tag key %q is longer than %d characters", k, maxTagKeyLength)
+		case strings.HasPrefix(strings.ToLower(k), "aws:"):
+			return fmt.Errorf("tag key %q cannot start with aws:", k)
+		case !tagChars.MatchString(k):
+			return fmt.Errorf("tag key %q has characters other than letters, digits, spaces and _.:/=+-@", k)
+		case utf8.RuneCountInString(v) > maxTagValueLength:
+			return fmt.Errorf("value of tag %s is longer than %d characters", k, maxTagValueLength)
+		case !tagChars.MatchString(v):
+			return fmt.Errorf("value of tag %s has characters other than letters, digits, spaces and _.:/=+-@", k)
+		}
+	}
+	return nil
+}
+
+// Tags returns the tags of the instance of a request, its metadata and the
+// uid of its postgresdb
+func Tags(req *database.Request) map[string]string {
+	tags := make(map[string]string, len(req.Metadata)+1)
+	for k, v := range req.Metadata {
+		tags[k] = v
+	}
+	if req.OwnerUID != "" {
+		tags[UIDTag] = req.OwnerUID
+	}
+	return tags
+}
+
+// ReconcileTags brings the tags of an instance in line with tags. Tags are
+// added and changed, but only the keys the operator manages and the removed
+// keys, eg. taken out of spec.tags, are removed so tags added by others stay.
+func (r *RDSClient) ReconcileTags(loc database.Location, dbID database.DatabaseID, tags map[string]string, removed []string) error {
+	client, err := r.clients.RDS(loc)
+	if err != nil {
+		return err
+	}
+	out, err := client.DescribeDBInstances(&awsrds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(string(dbID))})
+	if err != nil {
+		return err
+	}
+	instance := out.DBInstances[0]
+	current, err := instanceTags(client, instance)
+	if err != nil {
+		return err
+	}
+
+	var add []*awsrds.Tag
+	for k, v := range tags {
+		if cv, ok := current[k]; !ok || cv != v {
+			add = append(add, &awsrds.Tag{Key: aws.String(k), Value: aws.String(v)})
+		}
+	}
+	var remove []string
+	for _, k := range append(append([]string(nil), operatorTags...), removed...) {
+		if _, ok := tags[k]; ok {
+			continue
+		}
+		if _, ok := current[k]; ok && !containsKey(remove, k) {
+			remove = append(remove, k)
+		}
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+
+	r.Logger.Info("reconciling tags", "db-id", dbID, "added", len(add), "removed", remove)
+	if len(add) > 0 {
+		sort.Slice(add, func(i, j int) bool { return *add[i].Key < *add[j].Key })
+		if _, err := client.AddTagsToResource(&awsrds.AddTagsToResourceInput{ResourceName: instance.DBInstanceArn, Tags: add}); err != nil {
+			return err
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := client.RemoveTagsFromResource(&awsrds.RemoveTagsFromResourceInput{ResourceName: instance.DBInstanceArn, TagKeys: aws.StringSlice(remove)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DBInstanceTags returns the tags of an instance, eg. to copy them to its snapshots
+func DBInstanceTags(client rdsiface.RDSAPI, dbID database.DatabaseID) (map[string]string, error) {
+	out, err := client.DescribeDBInstances(&awsrds.DescribeDBInstancesInput{DBInstanceIdentifier: aws.String(string(dbID))})
+	if err != nil {
+		return nil, err
+	}
+	return instanceTags(client, out.DBInstances[0])
+}
+
+// instanceTags returns the tags of an instance, instances without an ARN have none
+func instanceTags(client rdsiface.RDSAPI, instance *awsrds.DBInstance) (map[string]string, error) {
+	tags := map[string]string{}
+	if instance.DBInstanceArn == nil {
+		return tags, nil
+	}
+	out, err := client.ListTagsForResource(&awsrds.ListTagsForResourceInput{ResourceName: instance.DBInstanceArn})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range out.TagList {
+		tags[aws.StringValue(t.Key)] = aws.StringValue(t.Value)
+	}
+	return tags, nil
+}
+
+func containsKey(keys []string, k string) bool {
+	for _, key := range keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package rds
+
+import (
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconcileTags(t *testing.T) {
+	arn := "arn:aws:rds:ap-southeast-2:123456789012:db:test-test-test"
+	f := &fakeRDS{tags: map[string][]*awsrds.Tag{
+		arn: tags(CreatedByTag, CreatedByOperator, OwnerTag, "team", SizeTierTag, "large", "team", "payments", "billing", "ops", "backup", "daily"),
+	}}
+
+	err := newClient(f).ReconcileTags(database.Location{}, "test-test-test", map[string]string{
+		CreatedByTag:  CreatedByOperator,
+		OwnerTag:      "team",
+		CostCentreTag: "cc-1234",
+		"team":        "ledger",
+	}, []string{"billing"})
+	assert.Nil(t, err)
+
+	assert.Equal(t, arn, aws.StringValue(f.added.ResourceName))
+	assert.Equal(t, tags(CostCentreTag, "cc-1234", "team", "ledger"), f.added.Tags)
+	// tags added by others stay
+	assert.Equal(t, []string{SizeTierTag, "billing"}, aws.StringValueSlice(f.removed.TagKeys))
+}
+
+func TestReconcileTags_InLine(t *testing.T) {
+	arn := "arn:aws:rds:ap-southeast-2:123456789012:db:test-test-test"
+	f := &fakeRDS{tags: map[string][]*awsrds.Tag{arn: tags(OwnerTag, "team", "backup", "daily")}}
+
+	assert.Nil(t, newClient(f).ReconcileTags(database.Location{}, "test-test-test", map[string]string{OwnerTag: "team"}, nil))
+	assert.Nil(t, f.added)
+	assert.Nil(t, f.removed)
+}
+
+func TestTags(t *testing.T) {
+	req := &database.Request{Metadata: map[string]string{OwnerTag: "team"}, OwnerUID: "1234"}
+	assert.Equal(t, map[string]string{OwnerTag: "team", UIDTag: "1234"}, Tags(req))
+	assert.Len(t, req.Metadata, 1)
+}
+
+func TestValidateTags(t *testing.T) {
+	assert.Nil(t, ValidateTags(map[string]string{"team": "payments", "Kostenstelle": "Zürich 1", "empty": ""}))
+	assert.EqualError(t, ValidateTags(map[string]string{"": "v"}), "tag keys cannot be empty")
+	assert.EqualError(t, ValidateTags(map[string]string{"AWS:name": "v"}), `tag key "AWS:name" cannot start with aws:`)
+	assert.EqualError(t, ValidateTags(map[string]string{"team;drop": "v"}), `tag key "team;drop" has characters other than letters, digits, spaces and _.:/=+-@`)
+
+	long := make([]byte, 257)
+	for i := range long {
+		long[i] = 'v'
+	}
+	assert.EqualError(t, ValidateTags(map[string]string{"team": string(long)}), "value of tag team is longer than 256 characters")
+}
@@ -53,63 +53,110 @@ func (b *bumblebee) RDSToModel(db *awsrds.DBInstance) (*database.Database, error
 
 func (b *bumblebee) ModelToRDS(req *database.Request, master *database.Credential) (*awsrds.CreateDBInstanceInput, error) {
 
-	class, err := getInstanceClassForSize(&req.Size)
-	if err != nil {
-		return nil, err
+	if req.InstanceClass == "" {
+		return nil, fmt.Errorf("no instance class for database %s", req.ID)
 	}
 	input := &awsrds.CreateDBInstanceInput{
-		DBInstanceIdentifier:       aws.String(string(req.ID)),
-		DBInstanceClass:            class,
-		MultiAZ:                    aws.Bool(req.HA),
-		Tags:                       mapToAWSTags(req.Metadata),
-		AllocatedStorage:           aws.Int64(req.Storage),
-		CopyTagsToSnapshot:         aws.Bool(true),
-		Engine:                     aws.String("postgres"),
-		EngineVersion:              aws.String("9.6.5"),
-		Port:                       aws.Int64(5432),
-		StorageEncrypted:           aws.Bool(true),
-		StorageType:                aws.String("gp2"),
-		BackupRetentionPeriod:      aws.Int64(35),
-		PreferredMaintenanceWindow: aws.String("Sat:14:30-Sat:15:30"), // Sun 01:30-02:30 AEDT
-		PreferredBackupWindow:      aws.String("13:30-14:30"),         // Sun 00:30-01:30 AEDT
-		MasterUserPassword:         aws.String(string(master.Password)),
-		MasterUsername:             aws.String(master.Username),
-		DBSubnetGroupName:          b.dbSubnetGroup,
-		VpcSecurityGroupIds:        b.dbSecurityGroups,
-	}
-	err = input.Validate()
+		DBInstanceIdentifier:            aws.String(string(req.ID)),
+		DBInstanceClass:                 aws.String(req.InstanceClass),
+		MultiAZ:                         aws.Bool(req.HA),
+		PubliclyAccessible:              aws.Bool(req.PubliclyAccessible),
+		EnableIAMDatabaseAuthentication: aws.Bool(req.IAMAuthentication),
+		Tags:                            mapToAWSTags(Tags(req)),
+		AllocatedStorage:                aws.Int64(req.Storage),
+		CopyTagsToSnapshot:              aws.Bool(true),
+		Engine:                          aws.String("postgres"),
+		EngineVersion:                   aws.String("9.6.5"),
+		Port:                            aws.Int64(5432),
+		StorageEncrypted:                aws.Bool(true),
+		StorageType:                     aws.String(storageTypeOrDefault(req.StorageType)),
+		BackupRetentionPeriod:           aws.Int64(35),
+		PreferredMaintenanceWindow:      aws.String("Sat:14:30-Sat:15:30"), // Sun 01:30-02:30 AEDT
+		PreferredBackupWindow:           aws.String("13:30-14:30"),         // Sun 00:30-01:30 AEDT
+		MasterUserPassword:              aws.String(string(master.Password)),
+		MasterUsername:                  aws.String(master.Username),
+		DBSubnetGroupName:               b.dbSubnetGroup,
+		VpcSecurityGroupIds:             b.dbSecurityGroups,
+	}
+	if req.EngineVersion != "" {
+		input.EngineVersion = aws.String(req.EngineVersion)
+	}
+	if req.ParameterGroup != "" {
+		input.DBParameterGroupName = aws.String(req.ParameterGroup)
+	}
+	if req.BackupRetentionDays != nil {
+		input.BackupRetentionPeriod = req.BackupRetentionDays
+	}
+	if req.BackupWindow != "" {
+		input.PreferredBackupWindow = aws.String(req.BackupWindow)
+	}
+	if req.MaintenanceWindow != "" {
+		input.PreferredMaintenanceWindow = aws.String(req.MaintenanceWindow)
+	}
+	if req.SubnetGroup != "" {
+		input.DBSubnetGroupName = aws.String(req.SubnetGroup)
+	}
+	if len(req.SecurityGroupIDs) > 0 {
+		input.VpcSecurityGroupIds = aws.StringSlice(req.SecurityGroupIDs)
+	}
+	if req.KMSKeyID != "" {
+		input.KmsKeyId = aws.String(req.KMSKeyID)
+	}
+	if req.Iops > 0 {
+		input.Iops = aws.Int64(req.Iops)
+	}
+	err := input.Validate()
 	if err != nil {
 		return nil, err
 	}
 	return input, nil
 }
 
-/**
-available: available,
-Unavailable: backing-up,configuring-enhanced-monitoring,starting,
-			 modifying,rebooting,renaming,resetting-master-credentials,
-			 stopping,creating,deleting,maintenance
-error: 	restore-error,failed,inaccessible-encryption-credentials
-		incompatible-parameters,,incompatible-option-group,
-		incompatible-credentials,incompatible-network,incompatible-restore
-*/
+// awsStatuses maps every RDS DBInstanceStatus to a database status, see
+// https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/Overview.DBInstance.Status.html
+var awsStatuses = map[string]database.Status{
+	"available":                           database.StatusAvailable,
+	"backing-up":                          database.StatusBackingUp,
+	"configuring-enhanced-monitoring":     database.StatusModifying,
+	"configuring-iam-database-auth":       database.StatusModifying,
+	"configuring-log-exports":             database.StatusModifying,
+	"converting-to-vpc":                   database.StatusModifying,
+	"creating":                            database.StatusCreating,
+	"deleting":                            database.StatusDeleting,
+	"failed":                              database.StatusFailed,
+	"inaccessible-encryption-credentials": database.StatusFailed,
+	"incompatible-credentials":            database.StatusFailed,
+	"incompatible-network":                database.StatusFailed,
+	"incompatible-option-group":           database.StatusFailed,
+	"incompatible-parameters":             database.StatusFailed,
+	"incompatible-restore":                database.StatusFailed,
+	"maintenance":                         database.StatusMaintenance,
+	"modifying":                           database.StatusModifying,
+	"moving-to-vpc":                       database.StatusModifying,
+	"rebooting":                           database.StatusRebooting,
+	"renaming":                            database.StatusModifying,
+	"resetting-master-credentials":        database.StatusModifying,
+	"restore-error":                       database.StatusFailed,
+	"starting":                            database.StatusStarting,
+	"stopped":                             database.StatusStopped,
+	"stopping":                            database.StatusStopping,
+	"storage-full":                        database.StatusStorageFull,
+	"storage-optimization":                database.StatusModifying,
+	"upgrading":                           database.StatusModifying,
+}
+
 func awsStatusMatcher(status string) database.Status {
-	switch status {
-	case "available":
-		return database.StatusAvailable
-	case "restore-error":
-	case "failed":
-	case "inaccessible-encryption-credential":
-	case "incompatible-parameters":
-	case "incompatible-option-group":
-	case "incompatible-credentials":
-	case "incompatible-network":
-	case "incompatible-restore":
-		return database.StatusErrored
-	default:
-		return database.StatusUnavailable
-	}
-	return 2
+	if s, ok := awsStatuses[status]; ok {
+		return s
+	}
+	return database.StatusUnavailable
+}
+
+func storageTypeOrDefault(t string) string {
+	if t == "" {
+		return "gp2"
+	}
+	return t
 }
 
 func mapToAWSTags(m map[string]string) []*awsrds.Tag {
@@ -124,32 +171,3 @@ func mapToAWSTags(m map[string]string) []*awsrds.Tag {
 
 	return tags
 }
-
-func GetSizeForInstanceClass(class string) (*database.Size, error) {
-	for k, v := range getMap() {
-		if v == class {
-			return &k, nil
-		}
-	}
-	return nil, fmt.Errorf("cannot find instance class for size: %s", class)
-}
-
-func getInstanceClassForSize(size *database.Size) (*string, error) {
-	c := getMap()
-	if v, ok := c[*size]; ok {
-		return &v, nil
-	}
-	return nil, fmt.Errorf("unsupported database size: %v", size)
-}
-
-func getMap() map[database.Size]string {
-	return map[database.Size]string{
-		database.SizeXSmall:  "db.t2.small",
-		database.SizeSmall:   "db.t2.medium",
-		database.SizeMedium:  "db.t2.xlarge",
-		database.SizeLarge:   "db.m4.large",
-		database.SizeXLarge:  "db.m4.2xlarge",
-		database.SizeXXLarge: "db.m4.4xlarge",
-		database.SizeMassive: "db.m4.16xlarge",
-	}
-}
@@ -43,6 +43,121 @@ func TestRDSToModel_EndpointNil(t *testing.T) {
 
 }
 
+func TestModelToRDS_StorageTypeDefault(t *testing.T) {
+	s := "test"
+	bee := NewBumblebee(NewRDSTransformerConfig(&s, []*string{&s}))
+
+	input, err := bee.ModelToRDS(getRequest(), getMasterCred())
+	assert.Nil(t, err)
+	assert.Equal(t, "gp2", *input.StorageType)
+	assert.Nil(t, input.Iops)
+	assert.False(t, *input.PubliclyAccessible)
+	assert.False(t, *input.EnableIAMDatabaseAuthentication)
+	assert.Nil(t, input.KmsKeyId)
+}
+
+func TestModelToRDS_UIDTag(t *testing.T) {
+	s := "test"
+	bee := NewBumblebee(NewRDSTransformerConfig(&s, []*string{&s}))
+	req := getRequest()
+	req.OwnerUID = "1234"
+
+	input, err := bee.ModelToRDS(req, getMasterCred())
+	assert.Nil(t, err)
+	tags := map[string]string{}
+	for _, tag := range input.Tags {
+		tags[*tag.Key] = *tag.Value
+	}
+	assert.Equal(t, "1234", tags[UIDTag])
+}
+
+func TestModelToRDS_ProvisionedIops(t *testing.T) {
+	s := "test"
+	bee := NewBumblebee(NewRDSTransformerConfig(&s, []*string{&s}))
+	req := getRequest()
+	req.StorageType = "io1"
+	req.Iops = 1000
+
+	input, err := bee.ModelToRDS(req, getMasterCred())
+	assert.Nil(t, err)
+	assert.Equal(t, "io1", *input.StorageType)
+	assert.Equal(t, int64(1000), *input.Iops)
+}
+
+func TestModelToRDS_BackupAndNetworkOverrides(t *testing.T) {
+	s := "test"
+	bee := NewBumblebee(NewRDSTransformerConfig(&s, []*string{&s}))
+	days := int64(14)
+	req := getRequest()
+	req.BackupRetentionDays = &days
+	req.BackupWindow = "03:00-04:00"
+	req.MaintenanceWindow = "sun:05:00-sun:06:00"
+	req.SubnetGroup = "private"
+	req.SecurityGroupIDs = []string{"sg-1", "sg-2"}
+	req.EngineVersion = "10.4"
+	req.ParameterGroup = "postgres10-tuned"
+	req.PubliclyAccessible = true
+	req.IAMAuthentication = true
+	req.KMSKeyID = "arn:aws:kms:ap-southeast-2:123456789012:key/1234"
+
+	input, err := bee.ModelToRDS(req, getMasterCred())
+	assert.Nil(t, err)
+	assert.Equal(t, "10.4", *input.EngineVersion)
+	assert.Equal(t, "postgres10-tuned", *input.DBParameterGroupName)
+	assert.Equal(t, int64(14), *input.BackupRetentionPeriod)
+	assert.Equal(t, "03:00-04:00", *input.PreferredBackupWindow)
+	assert.Equal(t, "sun:05:00-sun:06:00", *input.PreferredMaintenanceWindow)
+	assert.Equal(t, "private", *input.DBSubnetGroupName)
+	assert.Equal(t, []string{"sg-1", "sg-2"}, aws.StringValueSlice(input.VpcSecurityGroupIds))
+	assert.True(t, *input.PubliclyAccessible)
+	assert.True(t, *input.EnableIAMDatabaseAuthentication)
+	assert.Equal(t, "arn:aws:kms:ap-southeast-2:123456789012:key/1234", *input.KmsKeyId)
+}
+
+func TestModelToRDS_NoInstanceClass(t *testing.T) {
+	s := "test"
+	bee := NewBumblebee(NewRDSTransformerConfig(&s, []*string{&s}))
+	req := getRequest()
+	req.InstanceClass = ""
+
+	_, err := bee.ModelToRDS(req, getMasterCred())
+	assert.NotNil(t, err)
+}
+
+func TestAWSStatusMatcher(t *testing.T) {
+	tests := map[string]database.Status{
+		"available":                           database.StatusAvailable,
+		"creating":                            database.StatusCreating,
+		"backing-up":                          database.StatusBackingUp,
+		"failed":                              database.StatusFailed,
+		"restore-error":                       database.StatusFailed,
+		"incompatible-parameters":             database.StatusFailed,
+		"inaccessible-encryption-credentials": database.StatusFailed,
+		"stopped":                             database.StatusStopped,
+		"storage-full":                        database.StatusStorageFull,
+		"deleting":                            database.StatusDeleting,
+		"something-new":                       database.StatusUnavailable,
+	}
+
+	for aws, expected := range tests {
+		assert.Equal(t, expected, awsStatusMatcher(aws), aws)
+	}
+}
+
+func getRequest() *database.Request {
+	return &database.Request{
+		ID:            "test-test-test",
+		Name:          "test",
+		Owner:         "test",
+		InstanceClass: "db.t2.small",
+		Storage:       100,
+	}
+}
+
+func getMasterCred() *database.Credential {
+	return &database.Credential{Username: "master", Password: "crypticbanana"}
+}
+
 func getRDSInstance() *awsrds.DBInstance {
 	return &awsrds.DBInstance{
 		Endpoint: &awsrds.Endpoint{
@@ -0,0 +1,348 @@
+package snapshot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
+	listers "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/listers/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/leader"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/metrics"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// KeyResolver returns the ARN of a customer managed KMS key and tells them
+// apart from the AWS managed keys
+type KeyResolver interface {
+	ResolveKey(loc database.Location, keyID string) (string, error)
+	CustomerManaged(loc database.Location, keyID string) (bool, error)
+}
+
+// Clients returns the RDS client of the region and account of a database
+type Clients interface {
+	RDS(loc database.Location) (rdsiface.RDSAPI, error)
+}
+
+// Controller takes the snapshots of PostgresDBSnapshots, copies them under
+// their KMS key and shares them with other accounts
+type Controller struct {
+	clients   Clients
+	keys      KeyResolver
+	client    versioned.Interface
+	dbs       listers.PostgresDBLister
+	snapshots listers.PostgresDBSnapshotLister
+	synced    cache.InformerSynced
+	queue     workqueue.RateLimitingInterface
+	interval  time.Duration
+}
+
+// New returns a Controller watching the snapshots of the factory, snapshots
+// in progress are checked every interval
+func New(factory externalversions.SharedInformerFactory, client versioned.Interface, r Clients, k KeyResolver, interval time.Duration) *Controller {
+	informer := factory.Postgresdb().V1beta1().PostgresDBSnapshots()
+	c := &Controller{
+		clients:   r,
+		keys:      k,
+		client:    client,
+		dbs:       factory.Postgresdb().V1beta1().PostgresDBs().Lister(),
+		snapshots: informer.Lister(),
+		synced:    informer.Informer().HasSynced,
+		queue:     workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "postgresdbsnapshots"),
+		interval:  interval,
+	}
+
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueue,
+		UpdateFunc: func(old, obj interface{}) {
+			c.enqueue(obj)
+		},
+	})
+	return c
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		glog.Errorf("unable to get key for %v: %v", obj, err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Run processes snapshots until stopCh is closed, it returns once the
+// snapshot in flight is done
+func (c *Controller) Run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, c.synced) {
+		glog.Info("unable to sync postgresdb snapshots")
+		c.queue.ShutDown()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait.Until(func() { leader.RunWorker(stopCh, c.processNextItem) }, time.Second, stopCh)
+	}()
+	<-stopCh
+	c.queue.ShutDown()
+	<-done
+}
+
+func (c *Controller) processNextItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	ns, name, err := cache.SplitMetaNamespaceKey(key.(string))
+	if err != nil {
+		c.queue.Forget(key)
+		return true
+	}
+	snap, err := c.snapshots.PostgresDBSnapshots(ns).Get(name)
+	if errors.IsNotFound(err) {
+		c.queue.Forget(key)
+		return true
+	}
+
+	start := time.Now()
+	requeue, err := c.Reconcile(snap.DeepCopy())
+	metrics.ObserveReconcile("postgresdbsnapshot", start, requeue, err)
+	if err != nil {
+		glog.Errorf("unable to reconcile postgresdb snapshot %s: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	if requeue {
+		c.queue.AddAfter(key, c.interval)
+	}
+	return true
+}
+
+// Reconcile moves a snapshot on to its next phase, it returns true while the
+// snapshot is waiting on RDS and should be checked again later
+func (c *Controller) Reconcile(snap *v1beta1.PostgresDBSnapshot) (bool, error) {
+	switch snap.Status.Phase {
+	case "":
+		return c.create(snap)
+	case v1beta1.SnapshotCreating:
+		return c.waitForSnapshot(snap, snap.Status.SnapshotID, c.copyOrShare)
+	case v1beta1.SnapshotCopying:
+		return c.waitForSnapshot(snap, snap.Status.CopyID, c.share)
+	}
+	return false, nil
+}
+
+func (c *Controller) create(snap *v1beta1.PostgresDBSnapshot) (bool, error) {
+	db, err := c.dbs.PostgresDBs(snap.Namespace).Get(snap.Spec.PostgresDB)
+	if errors.IsNotFound(err) {
+		return false, c.fail(snap, fmt.Sprintf("postgresdb %s does not exist", snap.Spec.PostgresDB))
+	}
+	if err != nil {
+		return false, err
+	}
+	// a database is only snapshotted once it is available
+	if db.Status.ID == "" || db.Status.Phase != database.StatusAvailable.String() {
+		return true, nil
+	}
+
+	// the snapshot stays where it was taken if the database moves or is deleted
+	snap.Status.Region = db.Spec.Region
+	snap.Status.AWSAccount = db.Spec.AWSAccount
+	if snap.Spec.KMSKeyID != "" {
+		if _, err := c.keys.ResolveKey(location(snap), snap.Spec.KMSKeyID); err != nil {
+			return false, c.fail(snap, err.Error())
+		}
+	}
+	client, err := c.clients.RDS(location(snap))
+	if err != nil {
+		return false, c.fail(snap, err.Error())
+	}
+	if len(snap.Spec.ShareWith) > 0 && snap.Spec.KMSKeyID == "" {
+		shareable, err := c.encryptedWithCustomerKey(client, location(snap), db.Status.ID)
+		if err != nil {
+			return false, err
+		}
+		if !shareable {
+			return false, c.fail(snap, fmt.Sprintf("database %s is not encrypted with a customer managed key, shareWith needs a kmsKeyId", db.Status.ID))
+		}
+	}
+
+	// snapshots carry the tags of their database, the cost allocation tags
+	// among them, but are owned by the snapshot object
+	tags, err := rds.DBInstanceTags(client, database.DatabaseID(db.Status.ID))
+	if err != nil {
+		return false, err
+	}
+	tags[rds.OwnerTag] = snap.Namespace
+	tags[rds.NameTag] = snap.Name
+	tags[rds.CreatedByTag] = rds.CreatedByOperator
+
+	id := snapshotID(snap)
+	_, err = client.CreateDBSnapshot(&awsrds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: aws.String(db.Status.ID),
+		DBSnapshotIdentifier: aws.String(id),
+		Tags:                 sortedTags(tags),
+	})
+	if err != nil && !isAWSError(err, awsrds.ErrCodeDBSnapshotAlreadyExistsFault) {
+		return false, err
+	}
+
+	snap.Status.Phase = v1beta1.SnapshotCreating
+	snap.Status.SnapshotID = id
+	return true, c.updateStatus(snap)
+}
+
+// encryptedWithCustomerKey returns true if a database is encrypted with a
+// customer managed key, snapshots encrypted with the AWS managed key cannot be
+// restored by other accounts and have to be copied under spec.kmsKeyId
+func (c *Controller) encryptedWithCustomerKey(client rdsiface.RDSAPI, loc database.Location, dbID string) (bool, error) {
+	out, err := client.DescribeDBInstances(&awsrds.DescribeDBInstancesInput{
+		DBInstanceIdentifier: aws.String(dbID),
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(out.DBInstances) == 0 || out.DBInstances[0].KmsKeyId == nil {
+		return false, nil
+	}
+	return c.keys.CustomerManaged(loc, aws.StringValue(out.DBInstances[0].KmsKeyId))
+}
+
+// waitForSnapshot calls next once the snapshot id is available
+func (c *Controller) waitForSnapshot(snap *v1beta1.PostgresDBSnapshot, id string, next func(*v1beta1.PostgresDBSnapshot) (bool, error)) (bool, error) {
+	client, err := c.clients.RDS(location(snap))
+	if err != nil {
+		return false, err
+	}
+	out, err := client.DescribeDBSnapshots(&awsrds.DescribeDBSnapshotsInput{
+		DBSnapshotIdentifier: aws.String(id),
+	})
+	if isAWSError(err, awsrds.ErrCodeDBSnapshotNotFoundFault) || (err == nil && len(out.DBSnapshots) == 0) {
+		return false, c.fail(snap, fmt.Sprintf("snapshot %s does not exist", id))
+	}
+	if err != nil {
+		return false, err
+	}
+
+	switch status := aws.StringValue(out.DBSnapshots[0].Status); status {
+	case "available":
+		return next(snap)
+	case "creating", "copying":
+		return true, nil
+	default:
+		return false, c.fail(snap, fmt.Sprintf("snapshot %s is %s", id, status))
+	}
+}
+
+// copyOrShare copies the snapshot under spec.kmsKeyId if set, otherwise it is shared as is
+func (c *Controller) copyOrShare(snap *v1beta1.PostgresDBSnapshot) (bool, error) {
+	if snap.Spec.KMSKeyID == "" {
+		return c.share(snap)
+	}
+
+	arn, err := c.keys.ResolveKey(location(snap), snap.Spec.KMSKeyID)
+	if err != nil {
+		return false, c.fail(snap, err.Error())
+	}
+	client, err := c.clients.RDS(location(snap))
+	if err != nil {
+		return false, err
+	}
+
+	id := snap.Status.SnapshotID + "-copy"
+	_, err = client.CopyDBSnapshot(&awsrds.CopyDBSnapshotInput{
+		SourceDBSnapshotIdentifier: aws.String(snap.Status.SnapshotID),
+		TargetDBSnapshotIdentifier: aws.String(id),
+		KmsKeyId:                   aws.String(arn),
+		CopyTags:                   aws.Bool(true),
+	})
+	if err != nil && !isAWSError(err, awsrds.ErrCodeDBSnapshotAlreadyExistsFault) {
+		return false, err
+	}
+
+	snap.Status.Phase = v1beta1.SnapshotCopying
+	snap.Status.CopyID = id
+	return true, c.updateStatus(snap)
+}
+
+// share allows the accounts of spec.shareWith to restore the final snapshot
+func (c *Controller) share(snap *v1beta1.PostgresDBSnapshot) (bool, error) {
+	id := snap.Status.SnapshotID
+	if snap.Status.CopyID != "" {
+		id = snap.Status.CopyID
+	}
+
+	if len(snap.Spec.ShareWith) > 0 {
+		client, err := c.clients.RDS(location(snap))
+		if err != nil {
+			return false, err
+		}
+		_, err = client.ModifyDBSnapshotAttribute(&awsrds.ModifyDBSnapshotAttributeInput{
+			DBSnapshotIdentifier: aws.String(id),
+			AttributeName:        aws.String("restore"),
+			ValuesToAdd:          aws.StringSlice(snap.Spec.ShareWith),
+		})
+		if err != nil {
+			return false, c.fail(snap, fmt.Sprintf("unable to share snapshot %s: %v", id, err))
+		}
+	}
+
+	snap.Status.Phase = v1beta1.SnapshotAvailable
+	snap.Status.Message = ""
+	return false, c.updateStatus(snap)
+}
+
+func (c *Controller) fail(snap *v1beta1.PostgresDBSnapshot, message string) error {
+	snap.Status.Phase = v1beta1.SnapshotFailed
+	snap.Status.Message = message
+	return c.updateStatus(snap)
+}
+
+func (c *Controller) updateStatus(snap *v1beta1.PostgresDBSnapshot) error {
+	_, err := c.client.PostgresdbV1beta1().PostgresDBSnapshots(snap.Namespace).UpdateStatus(snap)
+	return err
+}
+
+// location is the region and account the snapshot was taken in
+func location(snap *v1beta1.PostgresDBSnapshot) database.Location {
+	return database.Location{Region: snap.Status.Region, Account: snap.Status.AWSAccount}
+}
+
+// snapshotID is unique to the PostgresDBSnapshot and a valid RDS identifier
+func snapshotID(snap *v1beta1.PostgresDBSnapshot) string {
+	return fmt.Sprintf("pgdbsnap-%s", snap.UID)
+}
+
+func isAWSError(err error, code string) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == code
+}
+
+func sortedTags(tags map[string]string) []*awsrds.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	list := make([]*awsrds.Tag, len(keys))
+	for i, k := range keys {
+		list[i] = &awsrds.Tag{Key: aws.String(k), Value: aws.String(tags[k])}
+	}
+	return list
+}
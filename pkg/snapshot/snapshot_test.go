@@ -0,0 +1,236 @@
+package snapshot
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/awsclient"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/informers/externalversions"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/aws/aws-sdk-go/aws"
+	awsrds "github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeRDS struct {
+	rdsiface.RDSAPI
+	key      string
+	statuses map[string]string
+	created  *awsrds.CreateDBSnapshotInput
+	copied   *awsrds.CopyDBSnapshotInput
+	shared   *awsrds.ModifyDBSnapshotAttributeInput
+}
+
+func (f *fakeRDS) DescribeDBInstances(in *awsrds.DescribeDBInstancesInput) (*awsrds.DescribeDBInstancesOutput, error) {
+	arn := "arn:aws:rds:ap-southeast-2:123456789012:db:" + aws.StringValue(in.DBInstanceIdentifier)
+	return &awsrds.DescribeDBInstancesOutput{DBInstances: []*awsrds.DBInstance{{DBInstanceArn: aws.String(arn), KmsKeyId: aws.String(f.key)}}}, nil
+}
+
+func (f *fakeRDS) ListTagsForResource(in *awsrds.ListTagsForResourceInput) (*awsrds.ListTagsForResourceOutput, error) {
+	return &awsrds.ListTagsForResourceOutput{TagList: []*awsrds.Tag{
+		{Key: aws.String("crd-name"), Value: aws.String("orders")},
+		{Key: aws.String("cost-centre"), Value: aws.String("cc-1234")},
+	}}, nil
+}
+
+func (f *fakeRDS) CreateDBSnapshot(in *awsrds.CreateDBSnapshotInput) (*awsrds.CreateDBSnapshotOutput, error) {
+	f.created = in
+	return &awsrds.CreateDBSnapshotOutput{}, nil
+}
+
+func (f *fakeRDS) DescribeDBSnapshots(in *awsrds.DescribeDBSnapshotsInput) (*awsrds.DescribeDBSnapshotsOutput, error) {
+	status, ok := f.statuses[aws.StringValue(in.DBSnapshotIdentifier)]
+	if !ok {
+		return &awsrds.DescribeDBSnapshotsOutput{}, nil
+	}
+	return &awsrds.DescribeDBSnapshotsOutput{DBSnapshots: []*awsrds.DBSnapshot{{Status: aws.String(status)}}}, nil
+}
+
+func (f *fakeRDS) CopyDBSnapshot(in *awsrds.CopyDBSnapshotInput) (*awsrds.CopyDBSnapshotOutput, error) {
+	f.copied = in
+	return &awsrds.CopyDBSnapshotOutput{}, nil
+}
+
+func (f *fakeRDS) ModifyDBSnapshotAttribute(in *awsrds.ModifyDBSnapshotAttributeInput) (*awsrds.ModifyDBSnapshotAttributeOutput, error) {
+	f.shared = in
+	return &awsrds.ModifyDBSnapshotAttributeOutput{}, nil
+}
+
+type fakeKeys map[string]string
+
+func (f fakeKeys) ResolveKey(loc database.Location, keyID string) (string, error) {
+	if arn, ok := f[keyID]; ok {
+		return arn, nil
+	}
+	return "", fmt.Errorf("kms key %s does not exist", keyID)
+}
+
+func (f fakeKeys) CustomerManaged(loc database.Location, keyID string) (bool, error) {
+	return keyID == keyARN, nil
+}
+
+const (
+	keyARN        = "arn:aws:kms:ap-southeast-2:123456789012:key/1234"
+	managedKeyARN = "arn:aws:kms:ap-southeast-2:123456789012:key/5678"
+)
+
+func newSnapshot(spec v1beta1.PostgresDBSnapshotSpec) *v1beta1.PostgresDBSnapshot {
+	return &v1beta1.PostgresDBSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "team", UID: "1234"},
+		Spec:       spec,
+	}
+}
+
+func newController(r *fakeRDS, snap *v1beta1.PostgresDBSnapshot, phase string) (*Controller, *fake.Clientset) {
+	db := &v1beta1.PostgresDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team"},
+		Status:     v1beta1.PostgresDBStatus{ID: "orders-5678", Phase: phase},
+	}
+	client := fake.NewSimpleClientset(snap)
+	factory := externalversions.NewSharedInformerFactory(client, 0)
+	c := New(factory, client, awsclient.Fixed{RDSAPI: r}, fakeKeys{"alias/shared": keyARN}, time.Minute)
+	factory.Postgresdb().V1beta1().PostgresDBs().Informer().GetIndexer().Add(db)
+	return c, client
+}
+
+func stored(t *testing.T, client *fake.Clientset) *v1beta1.PostgresDBSnapshot {
+	snap, err := client.PostgresdbV1beta1().PostgresDBSnapshots("team").Get("nightly", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snap
+}
+
+func TestReconcile_CopiesAndShares(t *testing.T) {
+	r := &fakeRDS{statuses: map[string]string{}}
+	snap := newSnapshot(v1beta1.PostgresDBSnapshotSpec{PostgresDB: "orders", KMSKeyID: "alias/shared", ShareWith: []string{"210987654321"}})
+	c, client := newController(r, snap, "Available")
+
+	requeue, err := c.Reconcile(snap)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	assert.Equal(t, "orders-5678", aws.StringValue(r.created.DBInstanceIdentifier))
+	tags := map[string]string{}
+	for _, tag := range r.created.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	assert.Equal(t, map[string]string{"cost-centre": "cc-1234", "crd-name": "nightly", "owner": "team", "created-by": "ops-kube-db-operator"}, tags)
+	assert.Equal(t, v1beta1.SnapshotCreating, stored(t, client).Status.Phase)
+	assert.Equal(t, "pgdbsnap-1234", stored(t, client).Status.SnapshotID)
+
+	// still being taken
+	r.statuses["pgdbsnap-1234"] = "creating"
+	requeue, err = c.Reconcile(stored(t, client))
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	assert.Nil(t, r.copied)
+
+	r.statuses["pgdbsnap-1234"] = "available"
+	requeue, err = c.Reconcile(stored(t, client))
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	assert.Equal(t, keyARN, aws.StringValue(r.copied.KmsKeyId))
+	assert.Equal(t, "pgdbsnap-1234-copy", aws.StringValue(r.copied.TargetDBSnapshotIdentifier))
+	assert.Equal(t, v1beta1.SnapshotCopying, stored(t, client).Status.Phase)
+
+	r.statuses["pgdbsnap-1234-copy"] = "available"
+	requeue, err = c.Reconcile(stored(t, client))
+	assert.Nil(t, err)
+	assert.False(t, requeue)
+	assert.Equal(t, "pgdbsnap-1234-copy", aws.StringValue(r.shared.DBSnapshotIdentifier))
+	assert.Equal(t, []string{"210987654321"}, aws.StringValueSlice(r.shared.ValuesToAdd))
+	assert.Equal(t, v1beta1.SnapshotAvailable, stored(t, client).Status.Phase)
+}
+
+func TestReconcile_WithoutKey(t *testing.T) {
+	r := &fakeRDS{statuses: map[string]string{"pgdbsnap-1234": "available"}}
+	snap := newSnapshot(v1beta1.PostgresDBSnapshotSpec{PostgresDB: "orders"})
+	snap.Status = v1beta1.PostgresDBSnapshotStatus{Phase: v1beta1.SnapshotCreating, SnapshotID: "pgdbsnap-1234"}
+	c, client := newController(r, snap, "Available")
+
+	requeue, err := c.Reconcile(snap)
+	assert.Nil(t, err)
+	assert.False(t, requeue)
+	assert.Nil(t, r.copied)
+	assert.Nil(t, r.shared)
+	assert.Equal(t, v1beta1.SnapshotAvailable, stored(t, client).Status.Phase)
+}
+
+func TestReconcile_SharesAsIs(t *testing.T) {
+	r := &fakeRDS{key: keyARN, statuses: map[string]string{"pgdbsnap-1234": "available"}}
+	snap := newSnapshot(v1beta1.PostgresDBSnapshotSpec{PostgresDB: "orders", ShareWith: []string{"210987654321"}})
+	c, client := newController(r, snap, "Available")
+
+	// the database is encrypted with a customer managed key
+	requeue, err := c.Reconcile(snap)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	assert.NotNil(t, r.created)
+
+	requeue, err = c.Reconcile(stored(t, client))
+	assert.Nil(t, err)
+	assert.False(t, requeue)
+	assert.Nil(t, r.copied)
+	assert.Equal(t, "pgdbsnap-1234", aws.StringValue(r.shared.DBSnapshotIdentifier))
+	assert.Equal(t, v1beta1.SnapshotAvailable, stored(t, client).Status.Phase)
+}
+
+func TestReconcile_ShareWithoutKey(t *testing.T) {
+	r := &fakeRDS{key: managedKeyARN}
+	snap := newSnapshot(v1beta1.PostgresDBSnapshotSpec{PostgresDB: "orders", ShareWith: []string{"210987654321"}})
+	c, client := newController(r, snap, "Available")
+
+	requeue, err := c.Reconcile(snap)
+	assert.Nil(t, err)
+	assert.False(t, requeue)
+	assert.Nil(t, r.created)
+	assert.Equal(t, v1beta1.SnapshotFailed, stored(t, client).Status.Phase)
+	assert.Equal(t, "database orders-5678 is not encrypted with a customer managed key, shareWith needs a kmsKeyId", stored(t, client).Status.Message)
+}
+
+func TestReconcile_WaitsForDatabase(t *testing.T) {
+	r := &fakeRDS{}
+	snap := newSnapshot(v1beta1.PostgresDBSnapshotSpec{PostgresDB: "orders"})
+	c, _ := newController(r, snap, "Creating")
+
+	requeue, err := c.Reconcile(snap)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	assert.Nil(t, r.created)
+}
+
+func TestReconcile_Failures(t *testing.T) {
+	specs := map[string]v1beta1.PostgresDBSnapshotSpec{
+		"postgresdb missing does not exist":    {PostgresDB: "missing"},
+		"kms key alias/missing does not exist": {PostgresDB: "orders", KMSKeyID: "alias/missing"},
+	}
+	for message, spec := range specs {
+		r := &fakeRDS{}
+		snap := newSnapshot(spec)
+		c, client := newController(r, snap, "Available")
+
+		requeue, err := c.Reconcile(snap)
+		assert.Nil(t, err)
+		assert.False(t, requeue)
+		assert.Nil(t, r.created)
+		assert.Equal(t, v1beta1.SnapshotFailed, stored(t, client).Status.Phase)
+		assert.Equal(t, message, stored(t, client).Status.Message)
+	}
+}
+
+func TestReconcile_SnapshotFailed(t *testing.T) {
+	r := &fakeRDS{statuses: map[string]string{"pgdbsnap-1234": "failed"}}
+	snap := newSnapshot(v1beta1.PostgresDBSnapshotSpec{PostgresDB: "orders"})
+	snap.Status = v1beta1.PostgresDBSnapshotStatus{Phase: v1beta1.SnapshotCreating, SnapshotID: "pgdbsnap-1234"}
+	c, client := newController(r, snap, "Available")
+
+	requeue, err := c.Reconcile(snap)
+	assert.Nil(t, err)
+	assert.False(t, requeue)
+	assert.Equal(t, "snapshot pgdbsnap-1234 is failed", stored(t, client).Status.Message)
+}
@@ -0,0 +1,304 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+)
+
+// TracesPath is where OTLP/HTTP collectors receive spans
+const TracesPath = "/v1/traces"
+
+// scopeName is the instrumentation scope of the operator's spans
+const scopeName = "github.com/MYOB-Technology/ops-kube-db-operator"
+
+// the OTLP/HTTP JSON encoding of spans, see
+// https://github.com/open-telemetry/opentelemetry-proto
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              Kind            `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+// status codes of spans, spans without an error are left unset
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// OTLPExporter posts spans as OTLP/HTTP JSON to a collector
+type OTLPExporter struct {
+	url     string
+	service string
+	client  *http.Client
+}
+
+// NewOTLPExporter returns an OTLPExporter posting to the collector at
+// endpoint, eg. http://otel-collector:4318, as the service named service
+func NewOTLPExporter(endpoint, service string) *OTLPExporter {
+	return &OTLPExporter{
+		url:     strings.TrimSuffix(endpoint, "/") + TracesPath,
+		service: service,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export posts spans to the collector in one request
+func (e *OTLPExporter) Export(spans []SpanData) error {
+	body, err := json.Marshal(encodeRequest(e.service, spans))
+	if err != nil {
+		return err
+	}
+	res, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("collector %s returned %s: %s", e.url, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func encodeRequest(service string, spans []SpanData) otlpRequest {
+	encoded := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		o := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        encodeAttributes(s.Attributes),
+			Status:            otlpStatus{Code: statusUnset},
+		}
+		if s.ParentSpanID.IsValid() {
+			o.ParentSpanID = s.ParentSpanID.String()
+		}
+		if s.Error != "" {
+			o.Status = otlpStatus{Code: statusError, Message: s.Error}
+		}
+		encoded = append(encoded, o)
+	}
+
+	name := service
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: &name}},
+		}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+func encodeAttributes(fields []log.Field) []otlpAttribute {
+	attrs := make([]otlpAttribute, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, otlpAttribute{Key: f.Key, Value: encodeValue(f.Value)})
+	}
+	return attrs
+}
+
+// encodeValue encodes the values of redacted fields, which are strings,
+// numbers, booleans or nil
+func encodeValue(value interface{}) otlpValue {
+	if value == nil {
+		s := ""
+		return otlpValue{StringValue: &s}
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		b := v.Bool()
+		return otlpValue{BoolValue: &b}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := strconv.FormatInt(v.Int(), 10)
+		return otlpValue{IntValue: &i}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i := strconv.FormatUint(v.Uint(), 10)
+		return otlpValue{IntValue: &i}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return otlpValue{DoubleValue: &f}
+	}
+	s := fmt.Sprint(value)
+	return otlpValue{StringValue: &s}
+}
+
+// Collector is an in-process OTLP/HTTP JSON collector, it keeps the spans
+// posted to it so tests can check what an exporter sent
+type Collector struct {
+	mu       sync.Mutex
+	spans    []SpanData
+	services []string
+}
+
+// NewCollector returns a Collector without spans
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// ServeHTTP receives the spans of a request to TracesPath
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != TracesPath || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	var req otlpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var spans []SpanData
+	var services []string
+	for _, rs := range req.ResourceSpans {
+		for _, a := range rs.Resource.Attributes {
+			if a.Key == "service.name" && a.Value.StringValue != nil {
+				services = append(services, *a.Value.StringValue)
+			}
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, o := range ss.Spans {
+				s, err := decodeSpan(o)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				spans = append(spans, s)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.spans = append(c.spans, spans...)
+	c.services = append(c.services, services...)
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, "{}")
+}
+
+// Spans returns the spans received so far, integer attributes are int64
+func (c *Collector) Spans() []SpanData {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]SpanData{}, c.spans...)
+}
+
+// Services returns the service names of the requests received so far
+func (c *Collector) Services() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.services...)
+}
+
+func decodeSpan(o otlpSpan) (SpanData, error) {
+	s := SpanData{Name: o.Name, Kind: o.Kind, Error: o.Status.Message}
+	if err := decodeID(o.TraceID, s.TraceID[:]); err != nil {
+		return s, fmt.Errorf("invalid traceId: %v", err)
+	}
+	if err := decodeID(o.SpanID, s.SpanID[:]); err != nil {
+		return s, fmt.Errorf("invalid spanId: %v", err)
+	}
+	if o.ParentSpanID != "" {
+		if err := decodeID(o.ParentSpanID, s.ParentSpanID[:]); err != nil {
+			return s, fmt.Errorf("invalid parentSpanId: %v", err)
+		}
+	}
+	start, err := strconv.ParseInt(o.StartTimeUnixNano, 10, 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid startTimeUnixNano: %v", err)
+	}
+	end, err := strconv.ParseInt(o.EndTimeUnixNano, 10, 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid endTimeUnixNano: %v", err)
+	}
+	s.Start = time.Unix(0, start)
+	s.End = time.Unix(0, end)
+	for _, a := range o.Attributes {
+		s.Attributes = append(s.Attributes, log.Field{Key: a.Key, Value: decodeValue(a.Value)})
+	}
+	return s, nil
+}
+
+func decodeID(s string, id []byte) error {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(b) != len(id) {
+		return fmt.Errorf("%q is not %d bytes long", s, len(id))
+	}
+	copy(id, b)
+	return nil
+}
+
+func decodeValue(v otlpValue) interface{} {
+	switch {
+	case v.BoolValue != nil:
+		return *v.BoolValue
+	case v.IntValue != nil:
+		i, _ := strconv.ParseInt(*v.IntValue, 10, 64)
+		return i
+	case v.DoubleValue != nil:
+		return *v.DoubleValue
+	case v.StringValue != nil:
+		return *v.StringValue
+	}
+	return nil
+}
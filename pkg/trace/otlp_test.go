@@ -0,0 +1,74 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPExporter_Collector(t *testing.T) {
+	collector := NewCollector()
+	srv := httptest.NewServer(collector)
+	defer srv.Close()
+
+	tracer := NewTracer(NewOTLPExporter(srv.URL+"/", "postgresdb-controller"))
+	start := time.Date(2018, 3, 1, 10, 0, 0, 0, time.UTC)
+	tracer.now = func() time.Time { return start }
+
+	ctx, root := tracer.Start(context.Background(), "postgresdb.check-availability", "namespace", "shadow", "requeue", true)
+	_, child := StartClient(ctx, "rds.DescribeDBInstances", "storage-gb", 5, "ratio", 0.5)
+	tracer.now = func() time.Time { return start.Add(time.Second) }
+	child.End(errors.New("Throttling: Rate exceeded"))
+	root.End(nil)
+	assert.Nil(t, tracer.Flush())
+
+	assert.Equal(t, []string{"postgresdb-controller"}, collector.Services())
+	spans := collector.Spans()
+	assert.Len(t, spans, 2)
+
+	c := spans[0]
+	assert.Equal(t, "rds.DescribeDBInstances", c.Name)
+	assert.Equal(t, KindClient, c.Kind)
+	assert.Equal(t, root.TraceID(), c.TraceID)
+	assert.Equal(t, "Throttling: Rate exceeded", c.Error)
+	assert.Equal(t, start.UnixNano(), c.Start.UnixNano())
+	assert.Equal(t, time.Second, c.End.Sub(c.Start))
+	assert.Equal(t, []log.Field{{Key: "storage-gb", Value: int64(5)}, {Key: "ratio", Value: 0.5}}, c.Attributes)
+
+	p := spans[1]
+	assert.Equal(t, c.ParentSpanID, p.SpanID)
+	assert.False(t, p.ParentSpanID.IsValid())
+	assert.Empty(t, p.Error)
+	assert.Equal(t, []log.Field{{Key: "namespace", Value: "shadow"}, {Key: "requeue", Value: true}}, p.Attributes)
+}
+
+func TestOTLPExporter_CollectorError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	err := NewOTLPExporter(srv.URL, "postgresdb-controller").Export([]SpanData{{Name: "validate"}})
+	assert.EqualError(t, err, "collector "+srv.URL+"/v1/traces returned 503 Service Unavailable: overloaded")
+}
+
+func TestCollector_InvalidSpans(t *testing.T) {
+	collector := NewCollector()
+	srv := httptest.NewServer(collector)
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+TracesPath, "application/json", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Get(srv.URL + TracesPath)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Empty(t, collector.Spans())
+}
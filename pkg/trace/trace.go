@@ -0,0 +1,251 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+)
+
+// TraceID identifies the spans of one reconcile
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// IsValid returns false for the zero id of spans without a trace
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+// SpanID identifies a span within its trace
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// IsValid returns false for the zero id of root spans' parents
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// Kind is the OTLP kind of a span
+type Kind int
+
+// Kinds of spans, internal work of the operator and calls to AWS and kubernetes
+const (
+	KindInternal Kind = 1
+	KindClient   Kind = 3
+)
+
+// SpanData is an ended span as exported
+type SpanData struct {
+	Name         string
+	Kind         Kind
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	// Attributes are redacted like the fields of log messages
+	Attributes []log.Field
+	// Error is the error the span ended with, empty if it succeeded
+	Error string
+}
+
+// Span times a piece of work of a reconcile, it is exported once it ended
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// TraceID returns the id of the trace of the span
+func (s *Span) TraceID() TraceID {
+	return s.data.TraceID
+}
+
+// SetAttributes adds attributes given as alternating keys and values
+func (s *Span) SetAttributes(keysAndValues ...interface{}) {
+	s.mu.Lock()
+	s.data.Attributes = append(s.data.Attributes, log.Fields(keysAndValues)...)
+	s.mu.Unlock()
+}
+
+// End ends the span with the error of the work it timed, if any. Ending a
+// span more than once has no effect.
+func (s *Span) End(err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = s.tracer.now()
+	if err != nil {
+		s.data.Error = log.Redact(err.Error())
+	}
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+type spanKey struct{}
+
+// ContextWithSpan returns a context whose spans are children of s
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// FromContext returns the span of ctx, or nil if there is none
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// Start starts an internal span, a child of the span of ctx if there is one
+func Start(ctx context.Context, name string, keysAndValues ...interface{}) (context.Context, *Span) {
+	return tracerOf(ctx).start(ctx, name, KindInternal, keysAndValues)
+}
+
+// StartClient starts a span of a call to AWS or kubernetes, a child of the
+// span of ctx if there is one
+func StartClient(ctx context.Context, name string, keysAndValues ...interface{}) (context.Context, *Span) {
+	return tracerOf(ctx).start(ctx, name, KindClient, keysAndValues)
+}
+
+// tracerOf returns the tracer of the span of ctx so children are exported
+// along with their parents, or the default tracer for new traces
+func tracerOf(ctx context.Context) *Tracer {
+	if parent := FromContext(ctx); parent != nil {
+		return parent.tracer
+	}
+	return Default()
+}
+
+// Exporter sends ended spans to a collector
+type Exporter interface {
+	Export(spans []SpanData) error
+}
+
+// maxQueued is how many ended spans are kept for the next export, later
+// spans are dropped while the collector is unreachable
+const maxQueued = 2048
+
+// Tracer starts spans and queues them for its exporter once they ended
+type Tracer struct {
+	exporter Exporter
+	now      func() time.Time
+
+	mu      sync.Mutex
+	queued  []SpanData
+	dropped int
+}
+
+// NewTracer returns a Tracer exporting spans with e, spans are only dropped
+// if e is nil
+func NewTracer(e Exporter) *Tracer {
+	return &Tracer{exporter: e, now: time.Now}
+}
+
+var defaultTracer = NewTracer(nil)
+var defaultMu sync.Mutex
+
+// Default returns the tracer of new traces, it drops spans unless SetDefault
+// replaced it
+func Default() *Tracer {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	return defaultTracer
+}
+
+// SetDefault replaces the tracer Default returns
+func SetDefault(t *Tracer) {
+	defaultMu.Lock()
+	defaultTracer = t
+	defaultMu.Unlock()
+}
+
+// Start starts a span of a new trace, or a child of the span of ctx
+func (t *Tracer) Start(ctx context.Context, name string, keysAndValues ...interface{}) (context.Context, *Span) {
+	return t.start(ctx, name, KindInternal, keysAndValues)
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind Kind, keysAndValues []interface{}) (context.Context, *Span) {
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Kind:       kind,
+			Start:      t.now(),
+			Attributes: log.Fields(keysAndValues),
+		},
+	}
+	if parent := FromContext(ctx); parent != nil {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentSpanID = parent.data.SpanID
+	} else {
+		rand.Read(s.data.TraceID[:])
+	}
+	rand.Read(s.data.SpanID[:])
+	return ContextWithSpan(ctx, s), s
+}
+
+func (t *Tracer) enqueue(data SpanData) {
+	if t.exporter == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queued) >= maxQueued {
+		t.dropped++
+		return
+	}
+	t.queued = append(t.queued, data)
+}
+
+// Flush exports the spans ended since the last flush
+func (t *Tracer) Flush() error {
+	t.mu.Lock()
+	spans := t.queued
+	dropped := t.dropped
+	t.queued = nil
+	t.dropped = 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		log.Default().Warn("dropped spans, the collector was unreachable for too long", "component", "trace", "count", dropped)
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	return t.exporter.Export(spans)
+}
+
+// Run flushes the tracer every interval until stopCh is closed, and once more
+// on the way out
+func (t *Tracer) Run(interval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			if err := t.Flush(); err != nil {
+				log.Default().Error("unable to export spans", "component", "trace", "err", err)
+			}
+			return
+		case <-ticker.C:
+		}
+		if err := t.Flush(); err != nil {
+			log.Default().Error("unable to export spans", "component", "trace", "err", err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	exports [][]SpanData
+}
+
+func (r *recorder) Export(spans []SpanData) error {
+	r.exports = append(r.exports, spans)
+	return nil
+}
+
+func TestTracer_Children(t *testing.T) {
+	r := &recorder{}
+	tracer := NewTracer(r)
+
+	ctx, root := tracer.Start(context.Background(), "postgresdb.create", "namespace", "shadow")
+	_, child := StartClient(ctx, "rds.CreateDBInstance", "db-id", "shadow-db")
+	child.End(errors.New("pq: password=s3cr3t"))
+	root.End(nil)
+
+	assert.Nil(t, tracer.Flush())
+	assert.Len(t, r.exports, 1)
+	spans := r.exports[0]
+	assert.Len(t, spans, 2)
+
+	c, p := spans[0], spans[1]
+	assert.Equal(t, "rds.CreateDBInstance", c.Name)
+	assert.Equal(t, KindClient, c.Kind)
+	assert.Equal(t, p.TraceID, c.TraceID)
+	assert.Equal(t, p.SpanID, c.ParentSpanID)
+	assert.Equal(t, "pq: password=[REDACTED]", c.Error)
+	assert.Equal(t, []log.Field{{Key: "db-id", Value: "shadow-db"}}, c.Attributes)
+
+	assert.Equal(t, KindInternal, p.Kind)
+	assert.True(t, p.TraceID.IsValid())
+	assert.False(t, p.ParentSpanID.IsValid())
+	assert.Empty(t, p.Error)
+}
+
+func TestTracer_NewTraces(t *testing.T) {
+	tracer := NewTracer(&recorder{})
+	_, a := tracer.Start(context.Background(), "a")
+	_, b := tracer.Start(context.Background(), "b")
+	assert.NotEqual(t, a.TraceID(), b.TraceID())
+}
+
+func TestSpan_EndOnce(t *testing.T) {
+	r := &recorder{}
+	tracer := NewTracer(r)
+	_, s := tracer.Start(context.Background(), "validate")
+	s.End(nil)
+	s.End(errors.New("too late"))
+
+	assert.Nil(t, tracer.Flush())
+	assert.Len(t, r.exports[0], 1)
+	assert.Empty(t, r.exports[0][0].Error)
+
+	// nothing is exported without spans
+	assert.Nil(t, tracer.Flush())
+	assert.Len(t, r.exports, 1)
+}
+
+func TestTracer_WithoutExporter(t *testing.T) {
+	tracer := NewTracer(nil)
+	ctx, s := tracer.Start(context.Background(), "postgresdb.create")
+	s.End(nil)
+
+	assert.Equal(t, s, FromContext(ctx))
+	assert.True(t, s.TraceID().IsValid())
+	assert.Nil(t, tracer.Flush())
+}
+
+func TestTracer_Run(t *testing.T) {
+	r := &recorder{}
+	tracer := NewTracer(r)
+	_, s := tracer.Start(context.Background(), "postgresdb.create")
+	s.End(nil)
+
+	stopCh := make(chan struct{})
+	close(stopCh)
+	tracer.Run(time.Hour, stopCh)
+	assert.Len(t, r.exports, 1)
+}
+
+func TestStart_Default(t *testing.T) {
+	r := &recorder{}
+	defer SetDefault(Default())
+	SetDefault(NewTracer(r))
+
+	_, s := Start(context.Background(), "postgresdb.update")
+	s.End(nil)
+	assert.Nil(t, Default().Flush())
+	assert.Len(t, r.exports, 1)
+}
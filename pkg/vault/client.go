@@ -0,0 +1,108 @@
+// Package vault talks to the HTTP API of the Vault of the operator configuration
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+)
+
+// Client sends requests to the Vault of the current configuration
+type Client struct {
+	http   *http.Client
+	config config.Getter
+}
+
+// NewClient returns a Client sending its requests with c
+func NewClient(c *http.Client, cfg config.Getter) *Client {
+	return &Client{http: c, config: cfg}
+}
+
+// Error is a response of Vault with an error status
+type Error struct {
+	Method string
+	Path   string
+	Status int
+	Errors []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("vault %s %s: %d %s", e.Method, e.Path, e.Status, strings.Join(e.Errors, ", "))
+}
+
+// Do sends a request to path, eg. secret/data/team/orders, and decodes the
+// response into out. It returns false if there is nothing at path.
+func (c *Client) Do(method, path string, in, out interface{}) (bool, error) {
+	cfg := c.config.Get().Vault
+	if cfg.Address == "" {
+		return false, fmt.Errorf("vault.address is not configured")
+	}
+	token, err := token(cfg)
+	if err != nil {
+		return false, err
+	}
+
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return false, err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(cfg.Address, "/")+"/v1/"+path, &body)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 300:
+		e := &Error{Method: method, Path: path, Status: resp.StatusCode}
+		errs := struct {
+			Errors []string `json:"errors"`
+		}{}
+		json.Unmarshal(raw, &errs)
+		e.Errors = errs.Errors
+		return false, e
+	case out != nil && len(raw) > 0:
+		if err := json.Unmarshal(raw, out); err != nil {
+			return false, fmt.Errorf("vault %s %s: %v", method, path, err)
+		}
+	}
+	return true, nil
+}
+
+// token is read for every request so the token file can be renewed
+func token(cfg config.Vault) (string, error) {
+	if cfg.TokenFile == "" {
+		if t := os.Getenv("VAULT_TOKEN"); t != "" {
+			return t, nil
+		}
+		return "", fmt.Errorf("no vault token, set vault.tokenFile or VAULT_TOKEN")
+	}
+	raw, err := ioutil.ReadFile(cfg.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read vault token: %v", err)
+	}
+	return strings.TrimSpace(string(raw)), nil
+}
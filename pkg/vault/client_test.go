@@ -0,0 +1,62 @@
+package vault
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig(t *testing.T, address, token string) config.Fixed {
+	f, err := ioutil.TempFile("", "vault-token")
+	assert.Nil(t, err)
+	f.WriteString(token + "\n")
+	f.Close()
+
+	c := config.Default()
+	c.Vault.Address = address
+	c.Vault.TokenFile = f.Name()
+	return config.Fixed{Config: c}
+}
+
+func TestDo(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("X-Vault-Token") != "root":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+		case r.URL.Path == "/v1/secret/data/found":
+			w.Write([]byte(`{"data":{"data":{"a":"b"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer s.Close()
+	c := NewClient(http.DefaultClient, testConfig(t, s.URL, "root"))
+
+	out := map[string]interface{}{}
+	found, err := c.Do("GET", "secret/data/found", nil, &out)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Contains(t, out, "data")
+
+	found, err = c.Do("GET", "secret/data/missing", nil, nil)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	_, err = NewClient(http.DefaultClient, testConfig(t, s.URL, "guest")).Do("GET", "secret/data/found", nil, nil)
+	assert.Equal(t, &Error{Method: "GET", Path: "secret/data/found", Status: 403, Errors: []string{"permission denied"}}, err)
+}
+
+func TestDo_NotConfigured(t *testing.T) {
+	_, err := NewClient(http.DefaultClient, testConfig(t, "", "root")).Do("GET", "secret/data/found", nil, nil)
+	assert.Contains(t, err.Error(), "not configured")
+
+	cfg := testConfig(t, "http://127.0.0.1:8200", "root")
+	cfg.Config.Vault.TokenFile = "/nonexistent/token"
+	_, err = NewClient(http.DefaultClient, cfg).Do("GET", "secret/data/found", nil, nil)
+	assert.Contains(t, err.Error(), "unable to read vault token")
+}
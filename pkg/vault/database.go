@@ -0,0 +1,141 @@
+package vault
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+)
+
+// connection is the name of the only connection of a database secrets engine
+const connection = "postgres"
+
+// DatabaseEngine mounts a database secrets engine for every postgresdb with
+// dynamic credentials, it connects as the master user and creates short
+// lived users of its roles
+type DatabaseEngine struct {
+	client *Client
+	config config.Getter
+}
+
+// NewDatabaseEngine returns a DatabaseEngine of the Vault of client
+func NewDatabaseEngine(client *Client, c config.Getter) *DatabaseEngine {
+	return &DatabaseEngine{client: client, config: c}
+}
+
+// roles are the roles of the engine of a database and the app credentials
+// that point to them
+var roles = []struct {
+	credType database.CredentialType
+	name     string
+}{
+	{database.CredTypeAppReadOnly, "readonly"},
+	{database.CredTypeAppUser, "readwrite"},
+	{database.CredTypeAppAdmin, "admin"},
+}
+
+// ConfigureDynamicCredentials mounts the engine of a postgresdb, if it is
+// not mounted yet, and writes its connection and roles. It returns the path
+// the credentials of the role of every app credential type are read from,
+// eg. postgresdb/team/orders/creds/readonly.
+func (e *DatabaseEngine) ConfigureDynamicCredentials(s database.Scope, name string, master *database.Credential) (map[database.CredentialType]string, error) {
+	cfg := e.config.Get()
+	mount := e.mount(s, name)
+
+	if err := e.enable(mount); err != nil {
+		return nil, err
+	}
+
+	allowed := make([]string, 0, len(roles))
+	for _, role := range roles {
+		allowed = append(allowed, role.name)
+	}
+	conn := map[string]interface{}{
+		"plugin_name":    "postgresql-database-plugin",
+		"connection_url": fmt.Sprintf("postgresql://{{username}}:{{password}}@%s:%d/%s?sslmode=%s", master.Host, master.Port, master.DatabaseName, cfg.Secrets.SSLMode),
+		"username":       master.Username,
+		"password":       string(master.Password),
+		"allowed_roles":  strings.Join(allowed, ","),
+	}
+	if _, err := e.client.Do("POST", path.Join(mount, "config", connection), conn, nil); err != nil {
+		return nil, err
+	}
+
+	paths := map[database.CredentialType]string{}
+	for _, role := range roles {
+		r := map[string]interface{}{
+			"db_name":               connection,
+			"creation_statements":   creationStatements(role.name, master),
+			"revocation_statements": revocationStatements(master),
+			"default_ttl":           strconv.FormatFloat(cfg.Vault.CredentialTTL.Seconds(), 'f', 0, 64),
+			"max_ttl":               strconv.FormatFloat(cfg.Vault.CredentialMaxTTL.Seconds(), 'f', 0, 64),
+		}
+		if _, err := e.client.Do("POST", path.Join(mount, "roles", role.name), r, nil); err != nil {
+			return nil, err
+		}
+		paths[role.credType] = path.Join(mount, "creds", role.name)
+	}
+	return paths, nil
+}
+
+// RemoveDynamicCredentials unmounts the engine of a postgresdb, Vault
+// revokes the leases of its credentials first
+func (e *DatabaseEngine) RemoveDynamicCredentials(s database.Scope, name string) error {
+	_, err := e.client.Do("DELETE", path.Join("sys/mounts", e.mount(s, name)), nil, nil)
+	return err
+}
+
+func (e *DatabaseEngine) mount(s database.Scope, name string) string {
+	return path.Join(e.config.Get().Vault.DatabaseMountPrefix, string(s), name)
+}
+
+// enable mounts a database secrets engine at mount unless it is there already
+func (e *DatabaseEngine) enable(mount string) error {
+	_, err := e.client.Do("POST", path.Join("sys/mounts", mount), map[string]interface{}{
+		"type":        "database",
+		"description": "dynamic credentials of postgresdb " + mount,
+	}, nil)
+	if verr, ok := err.(*Error); ok && verr.Status == 400 && strings.Contains(strings.Join(verr.Errors, " "), "already in use") {
+		return nil
+	}
+	return err
+}
+
+// creationStatements create a user of a role, readonly users can read every
+// table, readwrite users can also change rows and admin users get the
+// privileges of the master user
+func creationStatements(role string, master *database.Credential) []string {
+	statements := []string{
+		`CREATE ROLE "{{name}}" WITH LOGIN PASSWORD '{{password}}' VALID UNTIL '{{expiration}}';`,
+		fmt.Sprintf(`GRANT CONNECT ON DATABASE %s TO "{{name}}";`, quoteIdent(master.DatabaseName)),
+		`GRANT USAGE ON SCHEMA public TO "{{name}}";`,
+	}
+	switch role {
+	case "readonly":
+		statements = append(statements, `GRANT SELECT ON ALL TABLES IN SCHEMA public TO "{{name}}";`)
+	case "readwrite":
+		statements = append(statements,
+			`GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO "{{name}}";`,
+			`GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO "{{name}}";`)
+	case "admin":
+		statements = append(statements, fmt.Sprintf(`GRANT %s TO "{{name}}";`, quoteIdent(master.Username)))
+	}
+	return statements
+}
+
+// revocationStatements hand what a user created to the master user before
+// it is dropped
+func revocationStatements(master *database.Credential) []string {
+	return []string{
+		fmt.Sprintf(`REASSIGN OWNED BY "{{name}}" TO %s;`, quoteIdent(master.Username)),
+		`DROP OWNED BY "{{name}}";`,
+		`DROP ROLE IF EXISTS "{{name}}";`,
+	}
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
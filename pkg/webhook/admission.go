@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/golang/glog"
+	admission "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Validator validates a PostgresDB before it is admitted
+type Validator interface {
+	Validate(crd *v1beta1.PostgresDB) error
+}
+
+// AdmissionHandler rejects PostgresDBs that fail validation, eg. because they
+// would take their namespace over quota
+type AdmissionHandler struct {
+	validator Validator
+}
+
+// NewAdmissionHandler returns a http.Handler for the validating admission webhook
+func NewAdmissionHandler(v Validator) *AdmissionHandler {
+	return &AdmissionHandler{validator: v}
+}
+
+func (h *AdmissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := &admission.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.Admit(review.Request)
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		glog.Errorf("unable to write admission response: %v", err)
+	}
+}
+
+// Admit validates the PostgresDB of a create request, other operations are allowed
+func (h *AdmissionHandler) Admit(req *admission.AdmissionRequest) *admission.AdmissionResponse {
+	resp := &admission.AdmissionResponse{UID: req.UID, Allowed: true}
+	if req.Operation != admission.Create {
+		return resp
+	}
+
+	crd, err := decodePostgresDB(req.Object.Raw)
+	if err == nil {
+		// the object has not been stored yet, fill in what the api server would
+		if crd.Namespace == "" {
+			crd.Namespace = req.Namespace
+		}
+		err = h.validator.Validate(crd)
+	}
+	if err != nil {
+		resp.Allowed = false
+		resp.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+	}
+	return resp
+}
+
+func decodePostgresDB(raw []byte) (*v1beta1.PostgresDB, error) {
+	meta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return nil, err
+	}
+
+	switch meta.APIVersion {
+	case v1beta1.SchemeGroupVersion.String():
+		crd := &v1beta1.PostgresDB{}
+		err := json.Unmarshal(raw, crd)
+		return crd, err
+	case v1alpha1.SchemeGroupVersion.String():
+		in := &v1alpha1.PostgresDB{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		crd := &v1beta1.PostgresDB{}
+		err := v1beta1.ConvertFromV1alpha1(in, crd)
+		return crd, err
+	}
+	return nil, fmt.Errorf("unsupported postgresdb version %s", meta.APIVersion)
+}
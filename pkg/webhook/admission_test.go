@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/stretchr/testify/assert"
+	admission "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type fakeValidator struct {
+	err error
+	crd *v1beta1.PostgresDB
+}
+
+func (v *fakeValidator) Validate(crd *v1beta1.PostgresDB) error {
+	v.crd = crd
+	return v.err
+}
+
+func createRequest(object string) *admission.AdmissionRequest {
+	return &admission.AdmissionRequest{
+		UID:       "1234",
+		Namespace: "test-ns",
+		Operation: admission.Create,
+		Object:    runtime.RawExtension{Raw: []byte(object)},
+	}
+}
+
+func TestAdmit_Allowed(t *testing.T) {
+	v := &fakeValidator{}
+	resp := NewAdmissionHandler(v).Admit(createRequest(alphaObject))
+
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, "1234", string(resp.UID))
+	assert.Equal(t, "db.m4.large", v.crd.Spec.InstanceClass)
+	assert.Equal(t, "test-ns", v.crd.Namespace)
+}
+
+func TestAdmit_Denied(t *testing.T) {
+	v := &fakeValidator{err: fmt.Errorf("postgresdb quota team exceeded: 3 databases, limited to 2")}
+	resp := NewAdmissionHandler(v).Admit(createRequest(`{"apiVersion":"myob.com/v1beta1","kind":"PostgresDB",
+		"metadata":{"name":"test"},"spec":{"size":"small"}}`))
+
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, int32(http.StatusForbidden), resp.Result.Code)
+	assert.Contains(t, resp.Result.Message, "limited to 2")
+	assert.Equal(t, "test-ns", v.crd.Namespace)
+}
+
+func TestAdmit_UnsupportedVersion(t *testing.T) {
+	resp := NewAdmissionHandler(&fakeValidator{}).Admit(createRequest(`{"apiVersion":"myob.com/v2","kind":"PostgresDB"}`))
+
+	assert.False(t, resp.Allowed)
+}
+
+func TestAdmit_IgnoresUpdates(t *testing.T) {
+	v := &fakeValidator{err: fmt.Errorf("over quota")}
+	req := createRequest(alphaObject)
+	req.Operation = admission.Update
+
+	resp := NewAdmissionHandler(v).Admit(req)
+	assert.True(t, resp.Allowed)
+	assert.Nil(t, v.crd)
+}
+
+func TestAdmissionHandler_ServeHTTP(t *testing.T) {
+	body, _ := json.Marshal(admission.AdmissionReview{Request: createRequest(alphaObject)})
+
+	rec := httptest.NewRecorder()
+	NewAdmissionHandler(&fakeValidator{}).ServeHTTP(rec, httptest.NewRequest("POST", "/validate", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	out := admission.AdmissionReview{}
+	assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	assert.Nil(t, out.Request)
+	assert.True(t, out.Response.Allowed)
+}
+
+func TestAdmissionHandler_BadRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewAdmissionHandler(&fakeValidator{}).ServeHTTP(rec, httptest.NewRequest("POST", "/validate", bytes.NewReader([]byte("{"))))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
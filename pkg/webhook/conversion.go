@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConversionReview mirrors apiextensions.k8s.io/v1beta1 ConversionReview which
+// is not part of the apiextensions release we build against
+type ConversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *ConversionRequest  `json:"request,omitempty"`
+	Response        *ConversionResponse `json:"response,omitempty"`
+}
+
+// ConversionRequest is the request half of a ConversionReview
+type ConversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+// ConversionResponse is the response half of a ConversionReview
+type ConversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// ConversionHandler converts PostgresDB objects between the served versions
+type ConversionHandler struct{}
+
+// NewConversionHandler returns a http.Handler for the CRD conversion webhook
+func NewConversionHandler() *ConversionHandler {
+	return &ConversionHandler{}
+}
+
+func (h *ConversionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	review := &ConversionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "invalid conversion review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.Convert(review.Request)
+	review.Request = nil
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		glog.Errorf("unable to write conversion response: %v", err)
+	}
+}
+
+// Convert converts every object in the request to the desired version
+func (h *ConversionHandler) Convert(req *ConversionRequest) *ConversionResponse {
+	resp := &ConversionResponse{UID: req.UID}
+
+	for _, obj := range req.Objects {
+		converted, err := convert(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			resp.ConvertedObjects = nil
+			resp.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			return resp
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	resp.Result = metav1.Status{Status: metav1.StatusSuccess}
+	return resp
+}
+
+func convert(raw []byte, desired string) ([]byte, error) {
+	meta := &metav1.TypeMeta{}
+	if err := json.Unmarshal(raw, meta); err != nil {
+		return nil, err
+	}
+
+	if meta.APIVersion == desired {
+		return raw, nil
+	}
+
+	alphaVersion := v1alpha1.SchemeGroupVersion.String()
+	betaVersion := v1beta1.SchemeGroupVersion.String()
+
+	switch {
+	case meta.APIVersion == alphaVersion && desired == betaVersion:
+		in := &v1alpha1.PostgresDB{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		out := &v1beta1.PostgresDB{}
+		if err := v1beta1.ConvertFromV1alpha1(in, out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	case meta.APIVersion == betaVersion && desired == alphaVersion:
+		in := &v1beta1.PostgresDB{}
+		if err := json.Unmarshal(raw, in); err != nil {
+			return nil, err
+		}
+		out := &v1alpha1.PostgresDB{}
+		if err := v1beta1.ConvertToV1alpha1(in, out); err != nil {
+			return nil, err
+		}
+		return json.Marshal(out)
+	}
+
+	return nil, fmt.Errorf("unsupported conversion from %s to %s", meta.APIVersion, desired)
+}
@@ -0,0 +1,78 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const alphaObject = `{"apiVersion":"myob.com/v1alpha1","kind":"PostgresDB",
+	"metadata":{"name":"test","namespace":"test-ns"},
+	"spec":{"size":"db.m4.large","storage":"10"}}`
+
+func TestConvert_AlphaToBeta(t *testing.T) {
+	h := NewConversionHandler()
+	resp := h.Convert(&ConversionRequest{
+		UID:               "1234",
+		DesiredAPIVersion: "myob.com/v1beta1",
+		Objects:           []runtime.RawExtension{{Raw: []byte(alphaObject)}},
+	})
+
+	assert.Equal(t, metav1.StatusSuccess, resp.Result.Status)
+	assert.Equal(t, 1, len(resp.ConvertedObjects))
+
+	out := &v1beta1.PostgresDB{}
+	err := json.Unmarshal(resp.ConvertedObjects[0].Raw, out)
+	assert.Nil(t, err)
+	assert.Equal(t, "myob.com/v1beta1", out.APIVersion)
+	assert.Equal(t, "db.m4.large", out.Spec.InstanceClass)
+	assert.Equal(t, int64(10), out.Spec.StorageGiB())
+}
+
+func TestConvert_UnsupportedVersion(t *testing.T) {
+	h := NewConversionHandler()
+	resp := h.Convert(&ConversionRequest{
+		UID:               "1234",
+		DesiredAPIVersion: "myob.com/v2",
+		Objects:           []runtime.RawExtension{{Raw: []byte(alphaObject)}},
+	})
+
+	assert.Equal(t, metav1.StatusFailure, resp.Result.Status)
+	assert.Nil(t, resp.ConvertedObjects)
+}
+
+func TestConversionHandler_ServeHTTP(t *testing.T) {
+	review := ConversionReview{
+		Request: &ConversionRequest{
+			UID:               "1234",
+			DesiredAPIVersion: "myob.com/v1beta1",
+			Objects:           []runtime.RawExtension{{Raw: []byte(alphaObject)}},
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	rec := httptest.NewRecorder()
+	NewConversionHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	out := &ConversionReview{}
+	err := json.Unmarshal(rec.Body.Bytes(), out)
+	assert.Nil(t, err)
+	assert.Nil(t, out.Request)
+	assert.Equal(t, "1234", string(out.Response.UID))
+	assert.Equal(t, metav1.StatusSuccess, out.Response.Result.Status)
+}
+
+func TestConversionHandler_InvalidBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewConversionHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/convert", bytes.NewReader([]byte("banana"))))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
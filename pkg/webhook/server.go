@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Server serves the operator webhooks over TLS
+type Server struct {
+	addr     string
+	certFile string
+	keyFile  string
+	mux      *http.ServeMux
+}
+
+// NewServer returns a webhook server listening on addr with the given certificate
+func NewServer(addr, certFile, keyFile string) *Server {
+	return &Server{
+		addr:     addr,
+		certFile: certFile,
+		keyFile:  keyFile,
+		mux:      http.NewServeMux(),
+	}
+}
+
+// Handle registers a handler for the given path
+func (s *Server) Handle(path string, h http.Handler) {
+	s.mux.Handle(path, h)
+}
+
+// Run serves until stopCh is closed
+func (s *Server) Run(stopCh <-chan struct{}) error {
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: s.mux,
+	}
+
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			glog.Errorf("error shutting down webhook server: %v", err)
+		}
+	}()
+
+	glog.Infof("starting webhook server on %s", s.addr)
+	if err := srv.ListenAndServeTLS(s.certFile, s.keyFile); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
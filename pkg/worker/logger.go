@@ -1,28 +1,28 @@
 package worker
 
-//go:generate mockgen -source=$GOFILE -destination=../mocks/mock_logger.go -package=mocks
+import (
+	"crypto/rand"
+	"encoding/hex"
 
-import "github.com/golang/glog"
+	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+)
 
-type Logger interface {
-	Error(message string)
-	Info(message string)
-	Fatal(message string)
+// reconcileLogger returns the logger of one reconcile of a postgresdb, its
+// messages say which postgresdb they are about and which reconcile they are of
+func (w *DBWorker) reconcileLogger(crd *crds.PostgresDB) log.Logger {
+	l := w.Logger.With("namespace", crd.Namespace, "name", crd.Name, "reconcile-id", newReconcileID())
+	if crd.Status.ID != "" {
+		l = l.With("db-id", crd.Status.ID)
+	}
+	return l
 }
 
-type CustomLogger struct{}
-
-func NewLogger() *CustomLogger {
-	return &CustomLogger{}
-}
-func (c *CustomLogger) Error(message string) {
-	glog.Error(message)
-}
-
-func (c *CustomLogger) Fatal(message string) {
-	glog.Fatal(message)
-}
-
-func (c *CustomLogger) Info(message string) {
-	glog.Info(message)
+// newReconcileID returns a random id telling the messages of reconciles apart
+func newReconcileID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
 }
@@ -1,27 +1,47 @@
 package worker
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 
-	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/core"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 type DBWorker struct {
 	*DBWorkerConfig
 	PostgresDBValidator
 	Transformer
-	Logger
+	log.Logger
 	core.DBCreateGetter
 	core.StatusUpdater
 	core.CredentialsStorer
 	core.MetricsExporterCreator
+	core.NetworkPreparer
+	core.KeyResolver
+	core.IAMAuthenticator
+	core.DynamicCredentials
+	// MasterCredentials keeps the master credentials, the other credentials
+	// go to CredentialsStorer
+	MasterCredentials core.CredentialsStorer
+	// Tracer starts the spans of reconciles, it is the default tracer unless
+	// replaced
+	Tracer *trace.Tracer
 }
 
 type DBWorkerConfig struct {
-	checkIntervalInMillis int
-	nsSuffix              string
+	nsSuffix        string
+	masterNamespace string
+	config          config.Getter
 }
 
 // NewRDSWorker returns new DBWorker instance for handling change events on postgresDB crd
@@ -31,9 +51,14 @@ func NewDBWorker(
 	m core.MetricsExporterCreator,
 	cfg *DBWorkerConfig,
 	p PostgresDBValidator,
-	l Logger,
+	l log.Logger,
 	t Transformer,
 	u core.StatusUpdater,
+	n core.NetworkPreparer,
+	k core.KeyResolver,
+	a core.IAMAuthenticator,
+	d core.DynamicCredentials,
+	mc core.CredentialsStorer,
 ) *DBWorker {
 
 	return &DBWorker{
@@ -45,157 +70,557 @@ func NewDBWorker(
 		Logger:                 l,
 		Transformer:            t,
 		StatusUpdater:          u,
+		NetworkPreparer:        n,
+		KeyResolver:            k,
+		IAMAuthenticator:       a,
+		DynamicCredentials:     d,
+		MasterCredentials:      mc,
+		Tracer:                 trace.Default(),
 	}
 }
 
-func NewConfig(c int, s string) *DBWorkerConfig {
+func NewConfig(s string) *DBWorkerConfig {
+	return NewConfigWithOperatorConfig(s, config.Fixed{Config: config.Default()})
+}
+
+// NewConfigWithOperatorConfig returns a DBWorkerConfig keeping the master
+// credentials in the namespace of c and generating passwords with its
+// current password policies
+func NewConfigWithOperatorConfig(s string, c config.Getter) *DBWorkerConfig {
 	return &DBWorkerConfig{
-		checkIntervalInMillis: c,
-		nsSuffix:              s,
+		nsSuffix:        s,
+		masterNamespace: c.Get().MasterCredentials.Namespace,
+		config:          c,
 	}
 }
 
-func (w *DBWorker) OnCreate(obj interface{}) {
+// passwordPolicy returns the policy of the passwords of a credential type
+func (c *DBWorkerConfig) passwordPolicy(t database.CredentialType) config.PasswordPolicy {
+	return c.config.Get().Passwords.For(database.GetUserNameForType(t))
+}
 
+// OnCreate creates the database of a postgresdb along with its master
+// credentials, the controller checks its availability afterwards unless the
+// returned error is terminal
+func (w *DBWorker) OnCreate(obj interface{}) error {
 	crd := obj.(*crds.PostgresDB)
+	ctx, span, l := w.startReconcile(crd, "postgresdb.create")
+	err := w.create(ctx, crd, l)
+	span.End(err)
+	return err
+}
+
+func (w *DBWorker) create(ctx context.Context, crd *crds.PostgresDB, l log.Logger) error {
 	s := database.Scope(crd.Namespace)
 
 	// Validate the CRD
-	if err := w.Validate(crd); err != nil {
-		w.Error(fmt.Sprintf("invalid postgresdb object: %v", err))
-		updateCRDStatus(w.StatusUpdater, w.Logger, crd.Name, s, database.StatusErrored, nil)
-		return
+	_, span := trace.Start(ctx, "validate")
+	err := w.Validate(crd)
+	span.End(err)
+	if qerr, ok := err.(*database.QuotaExceededError); ok {
+		// the quota allows the database once others of the namespace are
+		// removed, the controller checks it again after a backoff
+		l.Error("postgresdb exceeds quota", "err", err)
+		updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to create db", qerr)
+		return qerr
+	}
+	if err != nil {
+		l.Error("invalid postgresdb object", "err", err)
+		err = &database.InvalidSpecError{Err: err}
+		updateCRDFailure(ctx, w.StatusUpdater, l, crd, "invalid postgresdb", err)
+		return err
 	}
 
 	// transform crd to our request object
 	req := w.CRDToRequest(crd)
+	if err := w.adoptLegacyID(ctx, crd, req, l); err != nil {
+		l.Error("unable to get database", "err", err, "class", rds.Classify(err))
+		updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to get db", err)
+		return err
+	}
+	if crd.Status.ID == "" {
+		l = l.With("db-id", req.ID)
+		trace.FromContext(ctx).SetAttributes("db-id", req.ID)
+	}
 
-	// generate all the credentials
-	creds, err := legacyGenCredentials(req, w.DBWorkerConfig)
+	// the network of databases still to be created has to exist, existing
+	// databases only get the ingress rules of their security group updated
+	_, span = trace.StartClient(ctx, "prepare-network", "region", req.Location.Region)
+	err = w.PrepareNetwork(req)
+	span.End(err)
 	if err != nil {
-		w.Error(fmt.Sprintf("unable to generate credentials err: %v", err))
-		return
+		l.Error("unable to prepare network", "err", err, "class", rds.Classify(err))
+		if crd.Status.ID == "" {
+			updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to prepare network", err)
+			return err
+		}
+	}
+
+	// the key has to exist before the database is encrypted with it
+	if req.KMSKeyID != "" && crd.Status.ID == "" {
+		_, span = trace.StartClient(ctx, "kms.resolve-key", "kms-key-id", req.KMSKeyID)
+		arn, err := w.ResolveKey(req.Location, req.KMSKeyID)
+		span.End(err)
+		if err != nil {
+			l.Error("invalid encryption key", "kms-key-id", req.KMSKeyID, "err", err)
+			updateCRDFailure(ctx, w.StatusUpdater, l, crd, "invalid encryption key", err)
+			return err
+		}
+		req.KMSKeyID = arn
+	}
+
+	// generate all the credentials, reusing the master password of an earlier
+	// attempt so it keeps matching the database
+	pw, err := w.storedMasterPassword(ctx, req)
+	if err != nil {
+		l.Error("unable to get master credentials", "err", err)
+		updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to get master credentials", err)
+		return err
+	}
+	var creds database.Credentials
+	if pw != "" {
+		creds = legacyCredentials(req, w.DBWorkerConfig, pw)
+	} else {
+		_, span = trace.Start(ctx, "generate-credentials")
+		creds, err = legacyGenCredentials(req, w.DBWorkerConfig)
+		span.End(err)
+		if err != nil {
+			l.Error("unable to generate credentials", "err", err)
+			updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to generate credentials", err)
+			return err
+		}
 	}
 
 	// store the credentials before creation just in case something breaks
 	// store only the master secret at this point
-	err = core.StoreDBCredentials(w.CredentialsStorer, &database.Credentials{database.CredTypeAdmin: creds[0]})
+	_, span = trace.StartClient(ctx, "store-master-credentials")
+	err = core.StoreDBCredentials(w.MasterCredentials, &database.Credentials{database.CredTypeAdmin: creds[0]})
+	span.End(err)
 	if err != nil {
-		w.Error(fmt.Sprintf("unable to store master credentials in kube-system err: %v", err))
-		return
+		l.Error("unable to store master credentials", "err", err)
+		updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to store master credentials", err)
+		return err
 	}
 
 	// create database for the request
-	db, err := core.CreateDatabaseIfNotExist(w.DBCreateGetter, req, creds[database.CredTypeAdmin])
+	db, err := core.CreateDatabaseIfNotExist(ctx, w.DBCreateGetter, req, creds[database.CredTypeAdmin], l)
 	if nil != err {
-		w.Error(fmt.Sprintf("unable to create database err: %v", err))
-		updateCRDStatus(w.StatusUpdater, w.Logger, crd.Name, s, database.StatusErrored, nil)
-		return
+		class := rds.Classify(err)
+		l.Error("unable to create database", "err", err, "class", class, "retryable", class.Retryable())
+		updateCRDFailure(ctx, w.StatusUpdater, l, crd, "unable to create db", err)
+		return err
+	}
+
+	// the controller keeps calling CheckAvailability until the database is available
+	updateCRDStatus(ctx, w.StatusUpdater, l, crd.Name, s, db.Status, db)
+	return nil
+}
+
+// CheckAvailability records the status of the database of a postgresdb and
+// finalises it once available. It returns true while the database is still on
+// its way and should be checked again later.
+func (w *DBWorker) CheckAvailability(obj interface{}) (bool, error) {
+	crd := obj.(*crds.PostgresDB)
+	ctx, span, l := w.startReconcile(crd, "postgresdb.check-availability")
+	requeue, err := w.checkAvailability(ctx, crd, l)
+	span.SetAttributes("requeue", requeue)
+	span.End(err)
+	return requeue, err
+}
+
+func (w *DBWorker) checkAvailability(ctx context.Context, crd *crds.PostgresDB, l log.Logger) (bool, error) {
+	s := database.Scope(crd.Namespace)
+	req := w.CRDToRequest(crd)
+
+	// databases whose creation failed with an error that is not terminal are
+	// created on their next check, which is retried until it succeeds
+	if awaitsCreateRetry(crd) {
+		l.Info("retrying database creation")
+		if err := w.create(ctx, crd, l); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	if err := w.adoptLegacyID(ctx, crd, req, l); err != nil {
+		return false, err
 	}
-	updateCRDStatus(w.StatusUpdater, w.Logger, crd.Name, s, database.StatusUnavailable, &db.ID)
 
-	// check and wait for DB to be available
-	db, err = core.WaitForDBToBeAvailable(w.DBCreateGetter, db.ID, w.checkIntervalInMillis)
+	db, available, err := core.CheckDBAvailability(ctx, w.DBCreateGetter, req.Location, req.ID, l)
 	if err != nil {
-		w.Error(fmt.Sprintf("unable to get database status err: %v", err))
-		return
+		if db != nil {
+			updateCRDStatus(ctx, w.StatusUpdater, l, crd.Name, s, db.Status, db)
+		}
+		return false, err
+	}
+
+	if !available {
+		updateCRDStatus(ctx, w.StatusUpdater, l, crd.Name, s, db.Status, db)
+		return true, nil
+	}
+
+	if err := w.finalise(ctx, req, db); err != nil {
+		return false, err
+	}
+	// databases that cannot be tagged are still available, the check fails
+	// so the controller retries it after a backoff
+	keys, removedTags, err := specTagKeys(crd)
+	if err != nil {
+		l.Error("unable to read applied tag keys", "err", err, "annotation", crds.TagKeysAnnotation)
+	}
+	terr := w.reconcileTags(ctx, req, removedTags)
+	if terr != nil {
+		l.Error("unable to reconcile tags", "err", terr, "class", rds.Classify(terr))
+		keys = nil
+	}
+	l.Info("database is available", "endpoint", db.Endpoint())
+	sReq := statusRequest(ctx, crd.Name, s, database.StatusAvailable, db)
+	sReq.TagKeys = keys
+	sendStatus(ctx, w.StatusUpdater, l, sReq)
+	return false, terr
+}
+
+// finalise stores the credentials with the host info of the available database
+// and creates its metrics exporter
+func (w *DBWorker) finalise(ctx context.Context, req *database.Request, db *database.Database) error {
+	pw, err := w.storedMasterPassword(ctx, req)
+	if err != nil {
+		return fmt.Errorf("unable to get master credentials err: %v", err)
+	}
+	if pw == "" {
+		return fmt.Errorf("master credentials for %s are missing", req.ID)
+	}
+	creds := legacyCredentials(req, w.DBWorkerConfig, pw)
+
+	// apps of databases with IAM authentication get an auth token, the
+	// token refresher replaces it before it expires
+	iamUser := database.GetUserNameForType(database.CredTypeAppUser)
+	if req.IAMAuthentication {
+		_, span := trace.StartClient(ctx, "iam.auth-token", "user", iamUser)
+		token, err := w.AuthToken(req.Location, db.Endpoint(), iamUser)
+		span.End(err)
+		if err != nil {
+			return fmt.Errorf("unable to get iam auth token err: %v", err)
+		}
+		for _, t := range database.GetAppCredentialTypes() {
+			creds[t].Username = iamUser
+			creds[t].Password = database.Password(token)
+		}
 	}
 
-	updateCRDStatus(w.StatusUpdater, w.Logger, crd.Name, s, database.StatusAvailable, &db.ID)
+	// the app credentials are copied to the external stores of the spec
+	for _, t := range database.GetAppCredentialTypes() {
+		creds[t].Stores = req.CredentialStores
+	}
 
 	// enrich credentials with database info
 	updatedCreds := addHostInfoToCredentials(creds, db)
 
-	// store updated credentials
-	err = core.StoreDBCredentials(w.CredentialsStorer, &updatedCreds)
+	// the engine connects as the master user, the app secrets say where
+	// the short lived credentials of their role come from
+	if req.VaultDynamicCredentials {
+		_, span := trace.StartClient(ctx, "vault.configure-dynamic-credentials")
+		paths, err := w.ConfigureDynamicCredentials(database.Scope(req.Owner), req.Name, updatedCreds[database.CredTypeAdmin])
+		span.End(err)
+		if err != nil {
+			return fmt.Errorf("unable to configure vault dynamic credentials err: %v", err)
+		}
+		for t, p := range paths {
+			updatedCreds[t].VaultCredsPath = p
+		}
+	}
+
+	// store updated credentials, the master credentials in their own home
+	master := updatedCreds[database.CredTypeAdmin]
+	_, span := trace.StartClient(ctx, "store-master-credentials")
+	err = core.StoreDBCredentials(w.MasterCredentials, &database.Credentials{database.CredTypeAdmin: master})
+	span.End(err)
 	if err != nil {
-		w.Error(fmt.Sprintf("unable to store credentials err: %v", err))
-		return
+		return fmt.Errorf("unable to store master credentials err: %v", err)
+	}
+	others := make(database.Credentials)
+	for t, cred := range updatedCreds {
+		if t != database.CredTypeAdmin {
+			others[t] = cred
+		}
+	}
+	_, span = trace.StartClient(ctx, "store-credentials", "count", len(others), "stores", req.CredentialStores)
+	err = core.StoreDBCredentials(w.CredentialsStorer, &others)
+	span.End(err)
+	if err != nil {
+		return fmt.Errorf("unable to store credentials err: %v", err)
+	}
+
+	// the grant connects with the master secret, which has the host by now
+	if req.IAMAuthentication {
+		_, span = trace.StartClient(ctx, "iam.grant-role", "user", iamUser)
+		err = w.GrantIAMRole(master, iamUser)
+		span.End(err)
+		if err != nil {
+			return fmt.Errorf("unable to grant iam authentication err: %v", err)
+		}
 	}
 
 	// create metrics exporter
-	err = core.CreateMetricsExporterForDB(w, getScope(req.Owner, w.DBWorkerConfig.nsSuffix), req.Name, creds[database.CredTypeMonitoring].ID)
+	scope := getScope(req.Owner, w.DBWorkerConfig.nsSuffix)
+	_, span = trace.StartClient(ctx, "apply-metrics-exporter", "namespace", scope)
+	err = core.CreateMetricsExporterForDB(w, scope, req.Name, creds[database.CredTypeMonitoring].ID)
+	span.End(err)
 	if err != nil {
-		w.Error(fmt.Sprintf("unable to create metrics exporter err: %v", err))
-		return
+		return fmt.Errorf("unable to create metrics exporter err: %v", err)
 	}
+	return nil
+}
 
+// storedMasterPassword returns the password of the stored master credentials,
+// or an empty password if there are none yet
+func (w *DBWorker) storedMasterPassword(ctx context.Context, req *database.Request) (database.Password, error) {
+	scope := getScopeForCredType(req.Owner, w.DBWorkerConfig, database.CredTypeAdmin)
+	_, span := trace.StartClient(ctx, "get-master-credentials")
+	cred, err := w.MasterCredentials.GetCred(scope, getCredentialID(req, database.CredTypeAdmin))
+	span.End(err)
+	if err != nil || cred == nil {
+		return "", err
+	}
+	return cred.Password, nil
 }
 
-// OnUpdate handles update event of postgresdb
+// OnUpdate handles update event of postgresdb, the app credentials are
+// removed from the external stores taken out of spec.credentialStores. The
+// controller checks the postgresdb again, which reconciles its tags.
 func (w *DBWorker) OnUpdate(obj interface{}, newObj interface{}) {
-	// TODO: fix no op
+	old, ok := obj.(*crds.PostgresDB)
+	if !ok {
+		return
+	}
+	crd := newObj.(*crds.PostgresDB)
+
+	var removed []string
+	for _, s := range old.Spec.CredentialStores {
+		if !containsString(crd.Spec.CredentialStores, s) {
+			removed = append(removed, s)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+
+	ctx, span, l := w.startReconcile(crd, "postgresdb.update")
+	err := w.deleteAppCredentials(ctx, l, crd, removed)
+	span.End(err)
+}
+
+// reconcileTags brings the tags of the database of a request in line with
+// it, removed are the keys of tags the postgresdb no longer has
+func (w *DBWorker) reconcileTags(ctx context.Context, req *database.Request, removed []string) error {
+	t, ok := w.DBCreateGetter.(core.TagReconciler)
+	if !ok {
+		return nil
+	}
+	_, span := trace.StartClient(ctx, "rds.reconcile-tags", "db-id", req.ID, "removed", removed)
+	err := t.ReconcileTags(req.Location, req.ID, rds.Tags(req), removed)
+	span.End(err)
+	return err
+}
+
+// specTagKeys returns the keys of spec.tags and those set on the instance by
+// an earlier reconcile that spec.tags no longer has. An annotation that
+// cannot be read removes nothing, its error is returned with the keys.
+func specTagKeys(crd *crds.PostgresDB) (keys, removed []string, err error) {
+	keys = []string{}
+	for k := range crd.Spec.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	annotation, ok := crd.Annotations[crds.TagKeysAnnotation]
+	if !ok {
+		return keys, nil, nil
+	}
+	var applied []string
+	if err := json.Unmarshal([]byte(annotation), &applied); err != nil {
+		return keys, nil, err
+	}
+	for _, k := range applied {
+		if _, ok := crd.Spec.Tags[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+	return keys, removed, nil
 }
 
-// OnDelete handles delete event of postgresdb
+// OnDelete handles delete event of postgresdb, the app credentials are
+// removed from its external stores and its vault database secrets engine is
+// unmounted. The database and its kubernetes secrets are left alone.
 func (w *DBWorker) OnDelete(obj interface{}) {
-	// TODO: fix no op
+	// objects deleted while the watch was down come as tombstones holding
+	// their last known state
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	crd, ok := obj.(*crds.PostgresDB)
+	if !ok {
+		return
+	}
+	ctx, span, l := w.startReconcile(crd, "postgresdb.delete")
+	err := w.deleteAppCredentials(ctx, l, crd, crd.Spec.CredentialStores)
+
+	if crd.Spec.VaultDynamicCredentials {
+		_, vspan := trace.StartClient(ctx, "vault.remove-dynamic-credentials")
+		verr := w.RemoveDynamicCredentials(database.Scope(crd.Namespace), crd.Name)
+		vspan.End(verr)
+		if verr != nil {
+			l.Error("unable to remove vault dynamic credentials", "err", verr)
+			err = verr
+		}
+	}
+	span.End(err)
+}
+
+// deleteAppCredentials deletes the app credentials from the stores, it
+// returns the last error after trying all of them
+func (w *DBWorker) deleteAppCredentials(ctx context.Context, l log.Logger, crd *crds.PostgresDB, stores []string) error {
+	d, ok := w.CredentialsStorer.(core.CredsDeleter)
+	if !ok || len(stores) == 0 {
+		return nil
+	}
+	var lastErr error
+	for _, t := range database.GetAppCredentialTypes() {
+		cred := &database.Credential{
+			ID:       database.GetCredentialID(crd.Namespace, crd.Name, t),
+			Scope:    getScopeForCredType(crd.Namespace, w.DBWorkerConfig, t),
+			CredType: t,
+			Stores:   stores,
+		}
+		_, span := trace.StartClient(ctx, "delete-credentials", "credential-id", cred.ID, "stores", stores)
+		err := d.DeleteCred(cred)
+		span.End(err)
+		if err != nil {
+			l.Error("unable to delete credentials from external stores", "credential-id", cred.ID, "stores", stores, "err", err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// updateCRDStatus updates the status of the postgresdb and records the trace
+// of the reconcile on it
+func updateCRDStatus(ctx context.Context, i core.StatusUpdater, l log.Logger, n string, s database.Scope, status database.Status, db *database.Database) {
+	sendStatus(ctx, i, l, statusRequest(ctx, n, s, status, db))
 }
 
-func updateCRDStatus(i core.StatusUpdater, l Logger, n string, s database.Scope, status database.Status, id *database.DatabaseID) {
+func statusRequest(ctx context.Context, n string, s database.Scope, status database.Status, db *database.Database) *database.StatusRequest {
 	sReq := &database.StatusRequest{
-		Name:   n,
-		Status: status,
-		ID:     id,
-		Scope:  s,
+		Name:    n,
+		Status:  status,
+		Scope:   s,
+		TraceID: traceID(ctx),
 	}
-	err := core.UpdateStatus(i, sReq)
-	if err != nil {
-		l.Error(fmt.Sprintf("unable to update crd status, %v", err))
+	if db != nil {
+		sReq.ID = &db.ID
+		sReq.Endpoint = db.Endpoint()
 	}
+	return sReq
 }
 
-func genCredentials(req *database.Request, c *DBWorkerConfig) (database.Credentials, error) {
-	creds := make(database.Credentials)
+// updateCRDFailure records why the database of a postgresdb could not be
+// created. Postgresdbs failing with terminal errors have failed until their
+// spec changes, the others stay progressing and get their database created
+// on their next check.
+func updateCRDFailure(ctx context.Context, i core.StatusUpdater, l log.Logger, crd *crds.PostgresDB, message string, err error) {
+	class := rds.Classify(err)
+	status := database.StatusUnavailable
+	if rds.IsTerminalReason(class.Reason()) {
+		status = database.StatusErrored
+	}
+	sendStatus(ctx, i, l, &database.StatusRequest{
+		Name:       crd.Name,
+		Status:     status,
+		Scope:      database.Scope(crd.Namespace),
+		TraceID:    traceID(ctx),
+		Reason:     class.Reason(),
+		Message:    fmt.Sprintf("%s: %s", message, log.Redact(err.Error())),
+		Generation: crd.Generation,
+	})
+}
 
-	for k, credType := range database.GetAllCredentialTypes() {
+func sendStatus(ctx context.Context, i core.StatusUpdater, l log.Logger, sReq *database.StatusRequest) {
+	_, span := trace.StartClient(ctx, "update-status", "status", sReq.Status.String())
+	err := core.UpdateStatus(i, sReq)
+	span.End(err)
+	if err != nil {
+		l.Error("unable to update crd status", "status", sReq.Status, "err", err)
+	}
+}
 
-		// generate password
-		pw, err := core.GenPasswords(30)
-		if err != nil {
-			return nil, err
-		}
-		id := fmt.Sprintf("%s-%s-%s", req.Owner, req.Name, database.GetUserNameForType(credType))
+// adoptLegacyID points the request of a postgresdb without a recorded
+// identifier at the instance earlier versions created for it, if there is one
+func (w *DBWorker) adoptLegacyID(ctx context.Context, crd *crds.PostgresDB, req *database.Request, l log.Logger) error {
+	legacy := rds.LegacyIdentifier(crd.Name, string(crd.GetUID()))
+	if crd.Status.ID != "" || legacy == req.ID {
+		return nil
+	}
+	_, span := trace.StartClient(ctx, "rds.DescribeDBInstances", "db-id", legacy, "region", req.Location.Region)
+	db, err := w.GetDB(req.Location, legacy)
+	span.End(err)
+	if err != nil {
+		return err
+	}
+	if db != nil && db.OwnedBy(req.OwnerUID) {
+		l.Info("found database under its legacy identifier", "db-id", legacy)
+		req.ID = legacy
+	}
+	return nil
+}
 
-		credential := &database.Credential{
-			Password:     database.Password(*pw),
-			Username:     database.GetUserNameForType(credType),
-			CredType:     database.CredentialType(k),
-			ID:           database.CredentialID(id),
-			Scope:        getScopeForCredType(req.Owner, c.nsSuffix, credType),
-			DatabaseName: "postgres",
+// awaitsCreateRetry returns true for postgresdbs without a database because
+// its creation failed with an error that is not terminal, or whose spec
+// changed since their creation failed
+func awaitsCreateRetry(crd *crds.PostgresDB) bool {
+	if crd.Status.ID != "" {
+		return false
+	}
+	for _, c := range crd.Status.Conditions {
+		if (c.Type == crds.ConditionProgressing || c.Type == crds.ConditionFailed) && c.Status == corev1.ConditionTrue {
+			return !rds.IsTerminalReason(c.Reason) || crd.Generation != crd.Status.ObservedGeneration
 		}
-		creds[credType] = credential
 	}
-	return creds, nil
+	return false
 }
 
 // DEPRECATED
+// every credential gets the master password, so the policy of master applies
 func legacyGenCredentials(req *database.Request, c *DBWorkerConfig) (database.Credentials, error) {
-	creds := make(database.Credentials)
-
-	pw, err := core.GenPasswords(30)
+	pw, err := core.GenPassword(c.passwordPolicy(database.CredTypeAdmin))
 	if err != nil {
 		return nil, err
 	}
+	return legacyCredentials(req, c, database.Password(pw)), nil
+}
+
+// DEPRECATED
+func legacyCredentials(req *database.Request, c *DBWorkerConfig, pw database.Password) database.Credentials {
+	creds := make(database.Credentials)
 	u := "master"
 
 	for k, credType := range database.GetAllCredentialTypes() {
 
-		id := fmt.Sprintf("%s-%s-%s", req.Owner, req.Name, database.GetUserNameForType(credType))
-
 		credential := &database.Credential{
-			Password:     database.Password(*pw),
+			Password:     pw,
 			Username:     u,
 			CredType:     database.CredentialType(k),
-			ID:           database.CredentialID(id),
-			Scope:        getScopeForCredType(req.Owner, c.nsSuffix, credType),
+			ID:           getCredentialID(req, credType),
+			Scope:        getScopeForCredType(req.Owner, c, credType),
 			DatabaseName: "postgres",
 		}
 		creds[credType] = credential
 	}
 
-	return creds, nil
+	return creds
+}
+
+func getCredentialID(req *database.Request, t database.CredentialType) database.CredentialID {
+	return database.GetCredentialID(req.Owner, req.Name, t)
 }
 
 func addHostInfoToCredentials(creds database.Credentials, db *database.Database) database.Credentials {
@@ -205,16 +630,15 @@ func addHostInfoToCredentials(creds database.Credentials, db *database.Database)
 		v.Port = db.Port
 		updatedCreds[k] = v
 	}
-	fmt.Println("In addHostInfoToCredentials")
 	return updatedCreds
 }
 
-func getScopeForCredType(requestNamespace string, nsSuffix string, t database.CredentialType) database.Scope {
+func getScopeForCredType(requestNamespace string, c *DBWorkerConfig, t database.CredentialType) database.Scope {
 	retNS := requestNamespace
 	if t == database.CredTypeAdmin {
-		return database.Scope("kube-system")
+		return database.Scope(c.masterNamespace)
 	} else if t == database.CredTypeMonitoring {
-		return getScope(requestNamespace, nsSuffix)
+		return getScope(requestNamespace, c.nsSuffix)
 	}
 	return database.Scope(retNS)
 }
@@ -3,20 +3,34 @@ package worker_test
 import (
 	"testing"
 
-	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/worker"
 
 	"fmt"
+	"net/http/httptest"
 	"strings"
 
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
 	fake2 "github.com/MYOB-Technology/ops-kube-db-operator/pkg/client/clientset/versioned/fake"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/credstore"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/k8s"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/master"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/mocks"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/trace"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	_ "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	k8sTesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 )
 
 type expectedAction struct {
@@ -30,29 +44,88 @@ func TestOnCreate_HappyPath(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	crd := crds.PostgresDB{}
-	crd.ObjectMeta.Name = "crdname"
-	crd.ObjectMeta.Namespace = "test-namespace"
-	crd.ObjectMeta.UID = "2098284b-1daf-11e8-b83f-028cde27f28a"
-	crd.Spec.Size = "db.m4.large"
-	crd.Spec.Storage = "5"
+	crd := getCRD()
 
 	// fake client set to assert actions
 	f := fake.NewSimpleClientset()
 	crdF := fake2.NewSimpleClientset()
 
-	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, crdF)
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, f, crdF)
 
 	// Given
 	expectedK8sActions := []expectedAction{
 
+		// Master secret lookup for an earlier attempt, in the legacy namespace too
+		{namespace: "postgresdb-operator", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBCreating.Credentials[0].ID)},
+		{namespace: "kube-system", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBCreating.Credentials[0].ID)},
+
 		// Master secret initial save
+		{namespace: "postgresdb-operator", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBCreating.Credentials[0].ID)},
+		{namespace: "kube-system", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBCreating.Credentials[0].ID)},
+		{namespace: "postgresdb-operator", verb: "update", resource: "secrets"},
+		{namespace: "postgresdb-operator", verb: "create", resource: "secrets"},
+		{namespace: "kube-system", verb: "delete", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBCreating.Credentials[0].ID)},
+	}
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDBCreating, nil).Times(1)
+
+	// When
+	wrkr.OnCreate(&crd)
+
+	// Then
+	assertActions(t, expectedK8sActions, f.Actions())
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Creating", stored.Status.Phase)
+}
+
+func TestOnCreate_ReusesMasterPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	f := fake.NewSimpleClientset()
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, f, fake2.NewSimpleClientset())
+	storeMasterCred(t, f, crd)
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Do(
+		func(req *database.Request, master *database.Credential) {
+			assert.Equal(t, database.Password("stored-password"), master.Password)
+		}).Return(retDBCreating, nil).Times(1)
+
+	wrkr.OnCreate(&crd)
+}
+
+func TestCheckAvailability_Available(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+
+	// fake client set to assert actions
+	f := fake.NewSimpleClientset()
+	crdF := fake2.NewSimpleClientset()
+
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, crdF)
+	storeMasterCred(t, f, crd)
+	f.ClearActions()
+
+	// Given
+	expectedK8sActions := []expectedAction{
+
+		// Master secret password lookup, found in the legacy namespace
+		{namespace: "postgresdb-operator", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBAvailable.Credentials[0].ID)},
 		{namespace: "kube-system", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBAvailable.Credentials[0].ID)},
-		{namespace: "kube-system", verb: "create", resource: "secrets"},
 
-		// Master secret update host info
+		// Master secret update host info, moving it out of kube-system
+		{namespace: "postgresdb-operator", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBAvailable.Credentials[0].ID)},
 		{namespace: "kube-system", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBAvailable.Credentials[0].ID)},
-		{namespace: "kube-system", verb: "update", resource: "secrets"},
+		{namespace: "postgresdb-operator", verb: "update", resource: "secrets"},
+		{namespace: "postgresdb-operator", verb: "create", resource: "secrets"},
+		{namespace: "kube-system", verb: "delete", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBAvailable.Credentials[0].ID)},
 
 		// Application User secret save
 		{namespace: "test-namespace", verb: "get", resource: "secrets", name: getCRDNameForCredential(crd.Namespace, crd.Name, retDBAvailable.Credentials[1].ID)},
@@ -83,16 +156,281 @@ func TestOnCreate_HappyPath(t *testing.T) {
 		{namespace: "test-namespace-shadow", verb: "create", resource: "deployments"},
 	}
 
-	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
-	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any()).Return(nil, nil).Times(1)
-	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(1)
-	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any()).Return(retDBAvailable, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(1)
 
 	// When
-	wrkr.OnCreate(&crd)
+	requeue, err := wrkr.CheckAvailability(&crd)
 
 	// Then
+	assert.Nil(t, err)
+	assert.False(t, requeue)
 	assertActions(t, expectedK8sActions, f.Actions())
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Available", stored.Status.Phase)
+}
+
+func TestCheckAvailability_Creating(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	f := fake.NewSimpleClientset()
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, f, fake2.NewSimpleClientset())
+
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBCreating, nil).Times(1)
+
+	requeue, err := wrkr.CheckAvailability(&crd)
+
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	assert.Equal(t, 0, len(f.Actions()))
+}
+
+func TestCheckAvailability_Failed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	wrkr, retDBFailed := getWorker(ctrl, crd, database.StatusFailed, fake.NewSimpleClientset(), crdF)
+
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBFailed, nil).Times(1)
+
+	requeue, err := wrkr.CheckAvailability(&crd)
+
+	assert.NotNil(t, err)
+	assert.False(t, requeue)
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Failed", stored.Status.Phase)
+}
+
+func TestCheckAvailability_IAMAuthentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Spec.IAMAuthentication = true
+	f := fake.NewSimpleClientset()
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, fake2.NewSimpleClientset())
+	storeMasterCred(t, f, crd)
+	retDBAvailable.Host = "db.rds.amazonaws.com"
+	retDBAvailable.Port = 5432
+
+	a := wrkr.IAMAuthenticator.(*mocks.MockIAMAuthenticator)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(1)
+	a.EXPECT().AuthToken(database.Location{}, "db.rds.amazonaws.com:5432", "appuser").Return("token", nil).Times(1)
+	a.EXPECT().GrantIAMRole(gomock.Any(), "appuser").Do(func(master *database.Credential, user string) {
+		assert.Equal(t, "master", master.Username)
+		assert.Equal(t, "db.rds.amazonaws.com", master.Host)
+	}).Return(nil).Times(1)
+
+	requeue, err := wrkr.CheckAvailability(&crd)
+
+	assert.Nil(t, err)
+	assert.False(t, requeue)
+	for _, id := range []string{"appuser", "appadmin", "appreadonly"} {
+		secret, _ := f.CoreV1().Secrets(crd.Namespace).Get(fmt.Sprintf("%s-%s-%s", crd.Namespace, crd.Name, id), v1.GetOptions{})
+		assert.Equal(t, "appuser", secret.StringData["DB_USER"], id)
+		assert.Equal(t, "token", secret.StringData["DB_PASSWORD"], id)
+	}
+	monitoring, _ := f.CoreV1().Secrets(crd.Namespace+"-shadow").Get(fmt.Sprintf("%s-%s-monitoring", crd.Namespace, crd.Name), v1.GetOptions{})
+	assert.Equal(t, "master", monitoring.StringData["DB_USER"])
+}
+
+// memoryStore is an external credential store keeping credentials by id
+type memoryStore map[database.CredentialID]*database.Credential
+
+func (m memoryStore) GetCred(s database.Scope, id database.CredentialID) (*database.Credential, error) {
+	return m[id], nil
+}
+
+func (m memoryStore) CreateCred(cred *database.Credential) error {
+	m[cred.ID] = cred
+	return nil
+}
+
+func (m memoryStore) UpdateCred(cred *database.Credential) error {
+	m[cred.ID] = cred
+	return nil
+}
+
+func (m memoryStore) DeleteCred(cred *database.Credential) error {
+	delete(m, cred.ID)
+	return nil
+}
+
+func TestCredentialStores(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Spec.CredentialStores = []string{database.CredentialStoreVault}
+	f := fake.NewSimpleClientset()
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, fake2.NewSimpleClientset())
+	storeMasterCred(t, f, crd)
+	vault := memoryStore{}
+	wrkr.CredentialsStorer = credstore.NewSyncer(k8s.NewStoreCreds(f), map[string]credstore.Store{database.CredentialStoreVault: vault})
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(3)
+
+	_, err := wrkr.CheckAvailability(&crd)
+
+	assert.Nil(t, err)
+	assert.Len(t, vault, 3)
+	assert.Equal(t, database.Scope("test-namespace"), vault["test-namespace-crdname-appuser"].Scope)
+
+	// taking vault out of the spec removes the copies
+	updated := crd.DeepCopy()
+	updated.Spec.CredentialStores = nil
+	wrkr.OnUpdate(&crd, updated)
+	assert.Len(t, vault, 0)
+
+	wrkr.CheckAvailability(&crd)
+	assert.Len(t, vault, 3)
+	wrkr.OnDelete(&crd)
+	assert.Len(t, vault, 0)
+
+	// postgresdbs deleted while the watch was down are cleaned up too
+	wrkr.CheckAvailability(&crd)
+	assert.Len(t, vault, 3)
+	wrkr.OnDelete(cache.DeletedFinalStateUnknown{Key: "test-namespace/crdname", Obj: &crd})
+	assert.Len(t, vault, 0)
+}
+
+// taggedDBs records the tags reconciled next to the mocked calls
+type taggedDBs struct {
+	*mocks.MockDBCreateGetter
+	tags    map[string]string
+	removed []string
+	err     error
+}
+
+func (d *taggedDBs) ReconcileTags(loc database.Location, id database.DatabaseID, tags map[string]string, removed []string) error {
+	d.tags = tags
+	d.removed = removed
+	return d.err
+}
+
+func TestTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Status.ID = "crdname-8e60dae354"
+	crd.Spec.Tags = map[string]string{"team": "payments", "billing": "ops"}
+	f, crdF := fake.NewSimpleClientset(), fake2.NewSimpleClientset()
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, crdF)
+	storeMasterCred(t, f, crd)
+	dbs := &taggedDBs{MockDBCreateGetter: wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter)}
+	wrkr.DBCreateGetter = dbs
+	dbs.EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(3)
+
+	_, err := wrkr.CheckAvailability(&crd)
+	assert.Nil(t, err)
+	assert.Equal(t, "payments", dbs.tags["team"])
+	assert.Equal(t, "ops", dbs.tags["billing"])
+	assert.Equal(t, "test-namespace", dbs.tags["namespace"])
+	assert.Equal(t, "2098284b-1daf-11e8-b83f-028cde27f28a", dbs.tags["postgresdb-uid"])
+	assert.Empty(t, dbs.removed)
+
+	// updates leave the tags to the check the controller queues
+	dbs.tags = nil
+	updated, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, `["billing","team"]`, updated.Annotations[crds.TagKeysAnnotation])
+	updated.Spec.Tags = map[string]string{"team": "ledger"}
+	updated, _ = crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Update(updated)
+	wrkr.OnUpdate(&crd, updated)
+	assert.Nil(t, dbs.tags)
+
+	// tags that cannot be reconciled fail the check so that it is retried,
+	// the keys already applied are kept
+	dbs.err = awserr.New("Throttling", "rate exceeded", nil)
+	_, err = wrkr.CheckAvailability(updated)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"billing"}, dbs.removed)
+	retried, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, `["billing","team"]`, retried.Annotations[crds.TagKeysAnnotation])
+	assert.Equal(t, database.StatusAvailable.String(), retried.Status.Phase)
+
+	dbs.err = nil
+	_, err = wrkr.CheckAvailability(retried)
+	assert.Nil(t, err)
+	assert.Equal(t, "ledger", dbs.tags["team"])
+	assert.Equal(t, []string{"billing"}, dbs.removed)
+	reconciled, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, `["team"]`, reconciled.Annotations[crds.TagKeysAnnotation])
+}
+
+func TestTags_MalformedAnnotation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Status.ID = "crdname-8e60dae354"
+	crd.Spec.Tags = map[string]string{"team": "payments"}
+	crd.Annotations = map[string]string{crds.TagKeysAnnotation: `["billing"`}
+	f, crdF := fake.NewSimpleClientset(), fake2.NewSimpleClientset()
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, crdF)
+	storeMasterCred(t, f, crd)
+	dbs := &taggedDBs{MockDBCreateGetter: wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter)}
+	wrkr.DBCreateGetter = dbs
+	dbs.EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(1)
+
+	// the error is logged, nothing is removed and the annotation is rewritten
+	_, err := wrkr.CheckAvailability(&crd)
+	assert.Nil(t, err)
+	assert.Equal(t, "payments", dbs.tags["team"])
+	assert.Empty(t, dbs.removed)
+
+	errs := loggedErrors(wrkr)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "unable to read applied tag keys", errs[0].Message)
+	assert.Equal(t, "unexpected end of JSON input", errs[0].Fields["err"])
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, `["team"]`, stored.Annotations[crds.TagKeysAnnotation])
+}
+
+func TestVaultDynamicCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Spec.VaultDynamicCredentials = true
+	f := fake.NewSimpleClientset()
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, f, fake2.NewSimpleClientset())
+	storeMasterCred(t, f, crd)
+	retDBAvailable.Host = "db.rds.amazonaws.com"
+
+	d := wrkr.DynamicCredentials.(*mocks.MockDynamicCredentials)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(1)
+	d.EXPECT().ConfigureDynamicCredentials(database.Scope(crd.Namespace), crd.Name, gomock.Any()).Do(func(s database.Scope, name string, master *database.Credential) {
+		assert.Equal(t, "db.rds.amazonaws.com", master.Host)
+		assert.Equal(t, database.Password("stored-password"), master.Password)
+	}).Return(map[database.CredentialType]string{database.CredTypeAppReadOnly: "postgresdb/test-namespace/crdname/creds/readonly"}, nil).Times(1)
+
+	_, err := wrkr.CheckAvailability(&crd)
+
+	assert.Nil(t, err)
+	secret, _ := f.CoreV1().Secrets(crd.Namespace).Get(fmt.Sprintf("%s-%s-appreadonly", crd.Namespace, crd.Name), v1.GetOptions{})
+	assert.Equal(t, "postgresdb/test-namespace/crdname/creds/readonly", secret.StringData[k8s.VAULT_CREDS_PATH])
+
+	d.EXPECT().RemoveDynamicCredentials(database.Scope(crd.Namespace), crd.Name).Return(nil).Times(1)
+	wrkr.OnDelete(&crd)
+}
+
+func TestCheckAvailability_MissingMasterCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusAvailable, fake.NewSimpleClientset(), fake2.NewSimpleClientset())
+
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(retDBAvailable, nil).Times(1)
+
+	requeue, err := wrkr.CheckAvailability(&crd)
+
+	assert.NotNil(t, err)
+	assert.False(t, requeue)
 }
 
 func TestOnCreate_WrongCRD(t *testing.T) {
@@ -105,15 +443,373 @@ func TestOnCreate_WrongCRD(t *testing.T) {
 	crd.ObjectMeta.UID = "2098284b-1daf-11e8-b83f-028cde27f28a"
 
 	crdF := fake2.NewSimpleClientset()
-	crdF.PostgresdbV1alpha1().PostgresDBs(crd.Namespace).Create(&crd)
+	crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Create(&crd)
 
 	dbWrkr, _ := getWorker(ctrl, crd, database.StatusAvailable, fake.NewSimpleClientset(), crdF)
 	gomock.InOrder(
 		dbWrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(fmt.Errorf("Something exploded")).Times(1),
 	)
-	dbWrkr.Logger.(*mocks.MockLogger).EXPECT().Error("invalid postgresdb object: Something exploded").Times(1)
-	dbWrkr.OnCreate(&crd)
+	err := dbWrkr.OnCreate(&crd)
+	assert.True(t, rds.IsTerminal(err))
+
+	errs := loggedErrors(dbWrkr)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "invalid postgresdb object", errs[0].Message)
+	assert.Equal(t, "Something exploded", errs[0].Fields["err"])
+	assert.Equal(t, crd.Namespace, errs[0].Fields["namespace"])
+	assert.Equal(t, crd.Name, errs[0].Fields["name"])
+	assert.NotEmpty(t, errs[0].Fields["reconcile-id"])
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Errored", stored.Status.Phase)
+	failed := getCondition(stored, crds.ConditionFailed)
+	assert.Equal(t, "InvalidParameter", failed.Reason)
+	assert.Equal(t, "invalid postgresdb: Something exploded", failed.Message)
+
+}
+
+func TestOnCreate_MissingNetwork(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	wrkr, _ := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+	n := mocks.NewMockNetworkPreparer(ctrl)
+	wrkr.NetworkPreparer = n
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	n.EXPECT().PrepareNetwork(gomock.Any()).Return(fmt.Errorf("db subnet group missing does not exist")).Times(1)
+
+	// no credentials are stored and no database is created
+	wrkr.OnCreate(&crd)
+
+	errs := loggedErrors(wrkr)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "unable to prepare network", errs[0].Message)
+	assert.Equal(t, "db subnet group missing does not exist", errs[0].Fields["err"])
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Unavailable", stored.Status.Phase)
+}
+
+func TestOnCreate_ResolvesKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Spec.Encryption = &crds.EncryptionSpec{KMSKeyID: "alias/databases"}
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), fake2.NewSimpleClientset())
+	arn := "arn:aws:kms:ap-southeast-2:123456789012:key/1234"
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	wrkr.KeyResolver.(*mocks.MockKeyResolver).EXPECT().ResolveKey(database.Location{}, "alias/databases").Return(arn, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Do(
+		func(req *database.Request, master *database.Credential) {
+			assert.Equal(t, arn, req.KMSKeyID)
+		}).Return(retDBCreating, nil).Times(1)
+
+	wrkr.OnCreate(&crd)
+}
+
+func TestOnCreate_MissingKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Spec.Encryption = &crds.EncryptionSpec{KMSKeyID: "alias/missing"}
+	crdF := fake2.NewSimpleClientset()
+	wrkr, _ := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(2)
+	wrkr.KeyResolver.(*mocks.MockKeyResolver).EXPECT().ResolveKey(database.Location{}, "alias/missing").Return("", fmt.Errorf("kms key alias/missing does not exist")).Times(2)
+
+	wrkr.OnCreate(&crd)
+
+	errs := loggedErrors(wrkr)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "invalid encryption key", errs[0].Message)
+	assert.Equal(t, "alias/missing", errs[0].Fields["kms-key-id"])
+	assert.Equal(t, "kms key alias/missing does not exist", errs[0].Fields["err"])
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Unavailable", stored.Status.Phase)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(stored, crds.ConditionFailed).Status)
+
+	// the error is not terminal, the database is created on the next check
+	requeue, err := wrkr.CheckAvailability(stored)
+	assert.EqualError(t, err, "kms key alias/missing does not exist")
+	assert.False(t, requeue)
+}
+
+func TestOnCreate_Traced(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	collector := trace.NewCollector()
+	srv := httptest.NewServer(collector)
+	defer srv.Close()
+
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+	tracer := trace.NewTracer(trace.NewOTLPExporter(srv.URL, "postgresdb-controller"))
+	wrkr.Tracer = tracer
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDBCreating, nil).Times(1)
+
+	wrkr.OnCreate(&crd)
+	assert.Nil(t, tracer.Flush())
+
+	spans := collector.Spans()
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{
+		"validate",
+		"rds.DescribeDBInstances",
+		"prepare-network",
+		"get-master-credentials",
+		"generate-credentials",
+		"store-master-credentials",
+		"rds.DescribeDBInstances",
+		"rds.CreateDBInstance",
+		"update-status",
+		"postgresdb.create",
+	}, names)
+
+	// every step is a child of the reconcile, which is recorded on the postgresdb
+	root := spans[len(spans)-1]
+	assert.False(t, root.ParentSpanID.IsValid())
+	for _, s := range spans[:len(spans)-1] {
+		assert.Equal(t, root.TraceID, s.TraceID, s.Name)
+		assert.Equal(t, root.SpanID, s.ParentSpanID, s.Name)
+	}
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, root.TraceID.String(), stored.Annotations[crds.TraceIDAnnotation])
+}
+
+func TestOnCreate_TracedError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	collector := trace.NewCollector()
+	srv := httptest.NewServer(collector)
+	defer srv.Close()
+
+	crd := getCRD()
+	wrkr, _ := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), fake2.NewSimpleClientset())
+	tracer := trace.NewTracer(trace.NewOTLPExporter(srv.URL, "postgresdb-controller"))
+	wrkr.Tracer = tracer
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("InstanceQuotaExceeded")).Times(1)
+
+	wrkr.OnCreate(&crd)
+	assert.Nil(t, tracer.Flush())
+
+	errs := map[string]string{}
+	for _, s := range collector.Spans() {
+		errs[s.Name] = s.Error
+	}
+	assert.Equal(t, "InstanceQuotaExceeded", errs["rds.CreateDBInstance"])
+	assert.Equal(t, "InstanceQuotaExceeded", errs["postgresdb.create"])
+	assert.Equal(t, "", errs["rds.DescribeDBInstances"])
+}
+
+func TestOnCreate_RetriesThrottled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+	r := wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter)
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(2)
+	r.EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	gomock.InOrder(
+		r.EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(nil, awserr.New("Throttling", "Rate exceeded", nil)),
+		r.EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDBCreating, nil),
+	)
+
+	wrkr.OnCreate(&crd)
+
+	// the postgresdb keeps progressing rather than failing
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Unavailable", stored.Status.Phase)
+	progressing := getCondition(stored, crds.ConditionProgressing)
+	assert.Equal(t, corev1.ConditionTrue, progressing.Status)
+	assert.Equal(t, "Throttled", progressing.Reason)
+	assert.Equal(t, "unable to create db: Throttling: Rate exceeded", progressing.Message)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(stored, crds.ConditionFailed).Status)
+
+	// and its database is created on the next check
+	requeue, err := wrkr.CheckAvailability(stored)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	stored, _ = crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Creating", stored.Status.Phase)
+	assert.Equal(t, string(retDBCreating.ID), stored.Status.ID)
+}
+
+func TestOnCreate_RetriesFixedSpec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crd.Generation = 1
+	crdF := fake2.NewSimpleClientset()
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+	r := wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter)
+
+	gomock.InOrder(
+		wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(fmt.Errorf("size cannot be empty")),
+		wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil),
+	)
+	r.EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	r.EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDBCreating, nil).Times(1)
+
+	err := wrkr.OnCreate(&crd)
+	assert.True(t, rds.IsTerminal(err))
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Errored", stored.Status.Phase)
+	assert.Equal(t, int64(1), stored.Status.ObservedGeneration)
+
+	// the database is created once the spec is fixed
+	stored.Generation = 2
+	stored, _ = crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Update(stored)
+	requeue, err := wrkr.CheckAvailability(stored)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	stored, _ = crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Creating", stored.Status.Phase)
+	assert.Equal(t, string(retDBCreating.ID), stored.Status.ID)
+}
+
+func TestOnCreate_QuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	wrkr, _ := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(nil, awserr.New("InstanceQuotaExceeded", "instance quota exceeded", nil)).Times(1)
 
+	wrkr.OnCreate(&crd)
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Errored", stored.Status.Phase)
+	failed := getCondition(stored, crds.ConditionFailed)
+	assert.Equal(t, corev1.ConditionTrue, failed.Status)
+	assert.Equal(t, "QuotaExceeded", failed.Reason)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(stored, crds.ConditionProgressing).Status)
+
+	errs := loggedErrors(wrkr)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, "QuotaExceeded", errs[0].Fields["class"])
+	assert.Equal(t, false, errs[0].Fields["retryable"])
+
+	// failed postgresdbs are not created again
+	requeue, err := wrkr.CheckAvailability(stored)
+	assert.EqualError(t, err, "database crdname-8e60dae354 does not exist")
+	assert.False(t, requeue)
+}
+
+func TestOnCreate_PostgresDBQuotaExceeded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	wrkr, retDBCreating := getWorker(ctrl, crd, database.StatusCreating, fake.NewSimpleClientset(), crdF)
+	r := wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter)
+
+	gomock.InOrder(
+		wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(&database.QuotaExceededError{Quota: "limits", Limit: "3 databases, limited to 2"}),
+		wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil),
+	)
+	r.EXPECT().GetDB(gomock.Any(), gomock.Any()).Return(nil, nil).Times(1)
+	r.EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDBCreating, nil).Times(1)
+
+	err := wrkr.OnCreate(&crd)
+	assert.False(t, rds.IsTerminal(err))
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Unavailable", stored.Status.Phase)
+	assert.Equal(t, corev1.ConditionFalse, getCondition(stored, crds.ConditionFailed).Status)
+	progressing := getCondition(stored, crds.ConditionProgressing)
+	assert.Equal(t, corev1.ConditionTrue, progressing.Status)
+	assert.Equal(t, "PostgresDBQuotaExceeded", progressing.Reason)
+	assert.Equal(t, "unable to create db: postgresdb quota limits exceeded: 3 databases, limited to 2", progressing.Message)
+
+	// its database is created on a check once the quota allows it
+	requeue, err := wrkr.CheckAvailability(stored)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+	stored, _ = crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Creating", stored.Status.Phase)
+}
+
+func TestOnCreate_KeepsLegacyID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the postgresdb of an instance created by an earlier version is added
+	// again when the operator starts
+	crd := getCRD()
+	crd.Status.ID = "crdname-2098284b-1daf-11e8-b83f-028cde27f28a"
+	crdF := fake2.NewSimpleClientset()
+	wrkr, _ := getWorker(ctrl, crd, database.StatusAvailable, fake.NewSimpleClientset(), crdF)
+	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(fmt.Errorf("Something exploded")).Times(1)
+
+	wrkr.OnCreate(&crd)
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, "Errored", stored.Status.Phase)
+	assert.Equal(t, "crdname-2098284b-1daf-11e8-b83f-028cde27f28a", stored.Status.ID)
+	assert.Equal(t, database.DatabaseID("crdname-2098284b-1daf-11e8-b83f-028cde27f28a"), wrkr.CRDToRequest(stored).ID)
+}
+
+func TestCheckAvailability_AdoptsLegacyID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// an earlier version lost the identifier of the postgresdb
+	crd := getCRD()
+	crdF := fake2.NewSimpleClientset()
+	f := fake.NewSimpleClientset()
+	storeMasterCred(t, f, crd)
+	legacy := database.DatabaseID("crdname-2098284b-1daf-11e8-b83f-028cde27f28a")
+	r := mocks.NewMockDBCreateGetter(ctrl)
+	wrkr, retDBAvailable := getWorker(ctrl, crd, database.StatusCreating, f, crdF)
+	wrkr.DBCreateGetter = r
+	retDBAvailable.ID = legacy
+	r.EXPECT().GetDB(gomock.Any(), legacy).Return(retDBAvailable, nil).Times(2)
+
+	requeue, err := wrkr.CheckAvailability(&crd)
+	assert.Nil(t, err)
+	assert.True(t, requeue)
+
+	stored, _ := crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Get(crd.Name, v1.GetOptions{})
+	assert.Equal(t, string(legacy), stored.Status.ID)
+}
+
+func getCondition(crd *crds.PostgresDB, t crds.PostgresDBConditionType) crds.PostgresDBCondition {
+	for _, c := range crd.Status.Conditions {
+		if c.Type == t {
+			return c
+		}
+	}
+	return crds.PostgresDBCondition{}
 }
 
 func isMatchingNamespace(e expectedAction, a k8sTesting.Action) bool {
@@ -166,16 +862,22 @@ func getCRDNameForCredential(namespace string, name string, id database.Credenti
 
 func getWorker(ctrl *gomock.Controller, crd crds.PostgresDB, status database.Status, f *fake.Clientset, crdF *fake2.Clientset) (*worker.DBWorker, *database.Database) {
 
-	config := worker.NewConfig(100, "shadow")
-	crdF.PostgresdbV1alpha1().PostgresDBs(crd.Namespace).Create(&crd)
+	cfg := worker.NewConfig("shadow")
+	crdF.PostgresdbV1beta1().PostgresDBs(crd.Namespace).Create(&crd)
 
 	c := k8s.NewStoreCreds(f)
 	r := mocks.NewMockDBCreateGetter(ctrl)
+	// postgresdbs of the tests have no instance under the legacy identifier
+	r.EXPECT().GetDB(gomock.Any(), rds.LegacyIdentifier(crd.Name, string(crd.UID))).Return(nil, nil).AnyTimes()
 	m := k8s.NewMetricsExporter(f)
 	v := mocks.NewMockPostgresDBValidator(ctrl)
-	l := mocks.NewMockLogger(ctrl)
-	tfm := worker.NewOptimus()
+	l := log.NewRecorder()
+	cl := mocks.NewMockClassResolver(ctrl)
+	cl.EXPECT().Resolve(gomock.Any()).Return(nil, nil).AnyTimes()
+	tfm := worker.NewOptimus(catalogue.Default(), config.Fixed{Config: config.Default()}, cl)
 	s := k8s.NewCRDClient(crdF)
+	n := mocks.NewMockNetworkPreparer(ctrl)
+	n.EXPECT().PrepareNetwork(gomock.Any()).Return(nil).AnyTimes()
 
 	// retVals
 	id := fmt.Sprintf("%s-%s-%s", crd.Namespace, crd.Name, crd.UID)
@@ -194,13 +896,41 @@ func getWorker(ctrl *gomock.Controller, crd crds.PostgresDB, status database.Sta
 		Credentials: creds,
 	}
 
-	wrkr := worker.NewDBWorker(r, c, m, config, v, l, tfm, s)
+	mc := master.NewStore(f, config.Fixed{Config: config.Default()}, nil, nil)
+
+	wrkr := worker.NewDBWorker(r, c, m, cfg, v, l, tfm, s, n, mocks.NewMockKeyResolver(ctrl), mocks.NewMockIAMAuthenticator(ctrl), mocks.NewMockDynamicCredentials(ctrl), mc)
 	return wrkr, retDBAvailable
 }
 
-func alwaysHappyCalls(wrkr *worker.DBWorker, retDB *database.Database) {
-	wrkr.PostgresDBValidator.(*mocks.MockPostgresDBValidator).EXPECT().Validate(gomock.Any()).Return(nil).Times(1)
-	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any()).Return(nil, nil).Times(1)
-	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().CreateDB(gomock.Any(), gomock.Any()).Return(retDB, nil).Times(1)
-	wrkr.DBCreateGetter.(*mocks.MockDBCreateGetter).EXPECT().GetDB(gomock.Any()).Return(retDB, nil).Times(1)
+func loggedErrors(w *worker.DBWorker) []log.Entry {
+	var errs []log.Entry
+	for _, e := range w.Logger.(*log.Recorder).Entries() {
+		if e.Level == log.ErrorLevel {
+			errs = append(errs, e)
+		}
+	}
+	return errs
+}
+
+func getCRD() crds.PostgresDB {
+	crd := crds.PostgresDB{}
+	crd.ObjectMeta.Name = "crdname"
+	crd.ObjectMeta.Namespace = "test-namespace"
+	crd.ObjectMeta.UID = "2098284b-1daf-11e8-b83f-028cde27f28a"
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("5Gi")
+	return crd
+}
+
+// storeMasterCred stores the master credential where earlier versions kept it
+func storeMasterCred(t *testing.T, f *fake.Clientset, crd crds.PostgresDB) {
+	err := k8s.NewStoreCreds(f).CreateCred(&database.Credential{
+		ID:       database.CredentialID(getCRDNameForCredential(crd.Namespace, crd.Name, "master")),
+		Scope:    "kube-system",
+		Username: "master",
+		Password: "stored-password",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 }
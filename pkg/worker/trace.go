@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"context"
+
+	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/log"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/trace"
+)
+
+// startReconcile starts the span of one reconcile of a postgresdb, the spans
+// of its steps are children of the span of the returned context. The logger
+// of the reconcile carries the trace id so messages and spans can be matched.
+func (w *DBWorker) startReconcile(crd *crds.PostgresDB, name string) (context.Context, *trace.Span, log.Logger) {
+	ctx, span := w.Tracer.Start(context.Background(), name, "namespace", crd.Namespace, "name", crd.Name)
+	if crd.Status.ID != "" {
+		span.SetAttributes("db-id", crd.Status.ID)
+	}
+	return ctx, span, w.reconcileLogger(crd).With("trace-id", span.TraceID().String())
+}
+
+// traceID returns the trace id of the span of ctx, or an empty id if the
+// context is not traced
+func traceID(ctx context.Context) string {
+	if span := trace.FromContext(ctx); span != nil {
+		return span.TraceID().String()
+	}
+	return ""
+}
@@ -3,71 +3,244 @@ package worker
 //go:generate mockgen -source=$GOFILE -destination=../mocks/mock_transformer.go -package=mocks
 
 import (
-	"fmt"
-
-	"unicode/utf8"
-
 	"strconv"
 
-	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Transformer interface {
-	CRDToRequest(crd *v1alpha1.PostgresDB) *database.Request
+	CRDToRequest(crd *v1beta1.PostgresDB) *database.Request
+}
+
+// SizeResolver resolves spec.size, a tier name or instance class, for a namespace
+type SizeResolver interface {
+	Resolve(size, namespace string) (*catalogue.Selection, error)
+}
+
+// NamespaceConfig returns the operator configuration for databases of a namespace
+type NamespaceConfig interface {
+	ForNamespace(namespace string) *config.Config
+}
+
+// ClassResolver returns the PostgresDBClass named by spec.className, or the
+// default class if the name is empty. It returns nil if there is no such default.
+type ClassResolver interface {
+	Resolve(className string) (*v1beta1.PostgresDBClass, error)
 }
 
-type Optimus struct{}
+type Optimus struct {
+	sizes   SizeResolver
+	config  NamespaceConfig
+	classes ClassResolver
+}
 
-func NewOptimus() *Optimus {
-	return &Optimus{}
+func NewOptimus(s SizeResolver, c NamespaceConfig, cl ClassResolver) *Optimus {
+	return &Optimus{sizes: s, config: c, classes: cl}
 }
 
-func (o *Optimus) CRDToRequest(crd *v1alpha1.PostgresDB) *database.Request {
+func (o *Optimus) CRDToRequest(crd *v1beta1.PostgresDB) *database.Request {
 
 	crdName := crd.Name
 	crdNS := crd.Namespace
-	name := truncateBytes(fmt.Sprintf("%s-%s", crdName, crd.GetUID()), 63)
-	dbID := database.DatabaseID(name)
+	// the identifier recorded on the status is kept, it stays the identifier
+	// of the instance whatever the generator makes of the name
+	dbID := database.DatabaseID(crd.Status.ID)
+	if dbID == "" {
+		dbID = rds.Identifier(crdName, string(crd.GetUID()))
+	}
 
-	size, _ := rds.GetSizeForInstanceClass(crd.Spec.Size)
+	// the validator has already rejected specs whose class cannot be resolved
+	class, _ := o.classes.Resolve(crd.Spec.ClassName)
+	spec := mergeClass(&crd.Spec, class)
 
 	req := &database.Request{
-		ID:      dbID,
-		Owner:   crd.Namespace,
-		Name:    crdName,
-		Size:    *size,
-		Storage: convertStorageToInt(crd.Spec.Storage),
-		Metadata: map[string]string{
-			"owner":      crdNS,
-			"crd-name":   crdName,
-			"created-by": "ops-kube-db-operator",
-		},
+		ID:          dbID,
+		Location:    database.Location{Region: crd.Spec.Region, Account: crd.Spec.AWSAccount},
+		Owner:       crd.Namespace,
+		OwnerUID:    string(crd.GetUID()),
+		Name:        crdName,
+		Storage:     spec.StorageGiB(),
+		StorageType: spec.StorageType,
+		Iops:        spec.Iops,
+		Metadata:    map[string]string{},
 	}
 
-	if crd.Spec.HA {
-		req.HA = crd.Spec.HA
+	if sel, err := o.sizes.Resolve(sizeOf(spec), crd.Namespace); err == nil {
+		req.InstanceClass = sel.InstanceClass
+		if req.Storage == 0 {
+			req.Storage = sel.DefaultStorage
+		}
+		if sel.Tier != "" {
+			req.Metadata[rds.SizeTierTag] = sel.Tier
+		}
+		if sel.MaxConnections > 0 {
+			req.Metadata[rds.MaxConnectionsTag] = strconv.FormatInt(sel.MaxConnections, 10)
+		}
 	}
 
-	if len(crd.Spec.Tags) > 0 {
-		for k, v := range crd.Spec.Tags {
+	if spec.HA {
+		req.HA = spec.HA
+	}
+	req.IAMAuthentication = spec.IAMAuthentication
+	req.CredentialStores = spec.CredentialStores
+	req.VaultDynamicCredentials = spec.VaultDynamicCredentials
+
+	// the configuration of the namespace fills in what the spec leaves out
+	cfg := o.config.ForNamespace(crd.Namespace)
+	retention := cfg.Defaults.BackupRetentionDays
+	req.EngineVersion = cfg.Defaults.EngineVersion
+	req.BackupRetentionDays = &retention
+	req.BackupWindow = cfg.Defaults.BackupWindow
+	req.MaintenanceWindow = cfg.Defaults.MaintenanceWindow
+	req.SubnetGroup = cfg.AWS.SubnetGroup
+	req.SecurityGroupIDs = cfg.AWS.SecurityGroupIDs
+	// databases in other regions and accounts use the network of their target
+	if req.Location != (database.Location{}) {
+		req.SubnetGroup = ""
+		req.SecurityGroupIDs = nil
+		if target, ok := cfg.Target(req.Location.Region, req.Location.Account); ok {
+			req.SubnetGroup = target.SubnetGroup
+			req.SecurityGroupIDs = target.SecurityGroupIDs
+		}
+	}
+	if req.StorageType == "" {
+		req.StorageType = cfg.Defaults.StorageType
+	}
+
+	if class != nil {
+		req.Metadata[rds.ClassTag] = class.Name
+		req.ParameterGroup = class.Spec.ParameterGroup
+		if class.Spec.EngineVersion != "" {
+			req.EngineVersion = class.Spec.EngineVersion
+		}
+	}
+
+	if b := spec.Backup; b != nil {
+		if b.RetentionDays != nil {
+			req.BackupRetentionDays = b.RetentionDays
+		}
+		if b.Window != "" {
+			req.BackupWindow = b.Window
+		}
+		if b.MaintenanceWindow != "" {
+			req.MaintenanceWindow = b.MaintenanceWindow
+		}
+	}
+
+	if n := spec.Network; n != nil {
+		if n.SubnetGroup != "" {
+			req.SubnetGroup = n.SubnetGroup
+		}
+		if len(n.SecurityGroupIDs) > 0 {
+			req.SecurityGroupIDs = n.SecurityGroupIDs
+		}
+		req.SecurityGroupIDs = append(append([]string(nil), req.SecurityGroupIDs...), n.AdditionalSecurityGroupIDs...)
+		req.PubliclyAccessible = n.PubliclyAccessible
+		if m := n.ManagedSecurityGroup; m != nil {
+			req.ManagedSecurityGroup = &database.SecurityGroupRequest{}
+			for _, r := range m.Ingress {
+				rule := database.IngressRule{CIDR: r.CIDR}
+				if r.NamespaceSelector != nil {
+					// the validator has already rejected invalid selectors
+					if sel, err := metav1.LabelSelectorAsSelector(r.NamespaceSelector); err == nil {
+						rule.NamespaceSelector = sel.String()
+					}
+				}
+				req.ManagedSecurityGroup.Ingress = append(req.ManagedSecurityGroup.Ingress, rule)
+			}
+		}
+	}
+
+	if e := spec.Encryption; e != nil {
+		req.KMSKeyID = e.KMSKeyID
+	}
+
+	if len(spec.Tags) > 0 {
+		for k, v := range spec.Tags {
 			req.Metadata[k] = v
 		}
 	}
 
+	// the tags the operator relies on and those of cost allocation win over
+	// spec.tags
+	req.Metadata[rds.OwnerTag] = crdNS
+	req.Metadata[rds.NameTag] = crdName
+	req.Metadata[rds.CreatedByTag] = rds.CreatedByOperator
+	req.Metadata[rds.NamespaceTag] = crdNS
+	req.Metadata[rds.APIVersionTag] = v1beta1.SchemeGroupVersion.String()
+	if cfg.Tags.ClusterName != "" {
+		req.Metadata[rds.ClusterTag] = cfg.Tags.ClusterName
+	}
+	if cfg.Tags.CostCentre != "" {
+		req.Metadata[rds.CostCentreTag] = cfg.Tags.CostCentre
+	}
+
 	return req
 }
 
-func truncateBytes(s string, n int) string {
-	for len(s) > n {
-		_, i := utf8.DecodeLastRuneInString(s)
-		s = s[:len(s)-i]
+// mergeClass returns a copy of spec with the settings of class filled in
+// where the spec leaves them empty
+func mergeClass(spec *v1beta1.PostgresDBSpec, class *v1beta1.PostgresDBClass) *v1beta1.PostgresDBSpec {
+	merged := spec.DeepCopy()
+	if class == nil {
+		return merged
+	}
+	c := class.Spec
+
+	if merged.StorageType == "" {
+		merged.StorageType = c.StorageType
+	}
+	if merged.Storage.IsZero() && c.Storage != nil {
+		merged.Storage = c.Storage.DeepCopy()
+	}
+	merged.HA = merged.HA || c.HA
+
+	if c.Backup != nil {
+		if merged.Backup == nil {
+			merged.Backup = &v1beta1.BackupSpec{}
+		}
+		if merged.Backup.RetentionDays == nil && c.Backup.RetentionDays != nil {
+			days := *c.Backup.RetentionDays
+			merged.Backup.RetentionDays = &days
+		}
+		if merged.Backup.Window == "" {
+			merged.Backup.Window = c.Backup.Window
+		}
+		if merged.Backup.MaintenanceWindow == "" {
+			merged.Backup.MaintenanceWindow = c.Backup.MaintenanceWindow
+		}
+	}
+
+	if c.Encryption != nil && c.Encryption.KMSKeyID != "" && (merged.Encryption == nil || merged.Encryption.KMSKeyID == "") {
+		merged.Encryption = &v1beta1.EncryptionSpec{KMSKeyID: c.Encryption.KMSKeyID}
 	}
-	return s
+
+	// the network of a class is that of the operator's own region and account,
+	// databases elsewhere keep the network of their target
+	remote := merged.Region != "" || merged.AWSAccount != ""
+	if !remote && (c.SubnetGroup != "" || len(c.SecurityGroupIDs) > 0) {
+		if merged.Network == nil {
+			merged.Network = &v1beta1.NetworkSpec{}
+		}
+		if merged.Network.SubnetGroup == "" {
+			merged.Network.SubnetGroup = c.SubnetGroup
+		}
+		if len(merged.Network.SecurityGroupIDs) == 0 {
+			merged.Network.SecurityGroupIDs = c.SecurityGroupIDs
+		}
+	}
+	return merged
 }
 
-func convertStorageToInt(storage string) int64 {
-	i, _ := strconv.ParseInt(storage, 10, 64)
-	return i
+// sizeOf returns the size requested by a spec, either its size or its instance class
+func sizeOf(spec *v1beta1.PostgresDBSpec) string {
+	if spec.InstanceClass != "" {
+		return spec.InstanceClass
+	}
+	return spec.Size
 }
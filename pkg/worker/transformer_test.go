@@ -3,22 +3,24 @@ package worker
 import (
 	"testing"
 
-	"fmt"
-
-	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestCRDToRequest_DBIDSize(t *testing.T) {
-	crd := &v1alpha1.PostgresDB{}
+	crd := &v1beta1.PostgresDB{}
 	crd.Namespace = "common-ledger-migrations"
 	crd.Name = "bankfeed-migrator"
 	crd.UID = "2098284b-1daf-11e8-b83f-028cde27f28a"
-	crd.Spec.Size = "db.t2.small"
-	crd.Spec.Storage = "5"
+	crd.Spec.InstanceClass = "db.t2.small"
+	crd.Spec.Storage = resource.MustParse("5Gi")
 
-	optimus := NewOptimus()
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
 	req := optimus.CRDToRequest(crd)
 
 	assert.NotNil(t, req)
@@ -29,12 +31,12 @@ func TestCRDToRequest_DBIDSize(t *testing.T) {
 
 func TestCRDToRequest_HappyPath(t *testing.T) {
 
-	crd := &v1alpha1.PostgresDB{}
+	crd := &v1beta1.PostgresDB{}
 	crd.Namespace = "test-ns"
 	crd.Name = "test"
 	crd.UID = "2098284b-1daf-11e8-b83f-028cde27f28a"
-	crd.Spec.Size = "db.t2.small"
-	crd.Spec.Storage = "5"
+	crd.Spec.InstanceClass = "db.t2.small"
+	crd.Spec.Storage = resource.MustParse("5Gi")
 	crd.Spec.HA = true
 	tags := map[string]string{
 		"test2":      "test2",
@@ -46,10 +48,289 @@ func TestCRDToRequest_HappyPath(t *testing.T) {
 
 	crd.Spec.Tags = tags
 
-	optimus := NewOptimus()
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
 	req := optimus.CRDToRequest(crd)
 
 	assert.NotNil(t, req)
-	assert.Equal(t, req.ID, database.DatabaseID(fmt.Sprintf("%s-%s", crd.Name, crd.GetUID())))
+	assert.Equal(t, database.DatabaseID("test-8e60dae354"), req.ID)
+	assert.Equal(t, string(crd.GetUID()), req.OwnerUID)
+	tags["namespace"] = crd.Namespace
+	tags["api-version"] = "myob.com/v1beta1"
 	assert.Equal(t, req.Metadata, tags)
 }
+
+func TestCRDToRequest_MandatoryTags(t *testing.T) {
+	crd := &v1beta1.PostgresDB{}
+	crd.Namespace = "team"
+	crd.Name = "orders"
+	crd.Spec.InstanceClass = "db.t2.small"
+	crd.Spec.Tags = map[string]string{"owner": "someone-else", "team": "payments"}
+
+	cfg := config.Default()
+	cfg.Tags.ClusterName = "prod-syd"
+	cfg.Tags.CostCentre = "cc-1234"
+	optimus := NewOptimus(catalogue.Default(), config.Fixed{Config: cfg}, fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "team", req.Metadata["owner"])
+	assert.Equal(t, "team", req.Metadata["namespace"])
+	assert.Equal(t, "payments", req.Metadata["team"])
+	assert.Equal(t, "prod-syd", req.Metadata["cluster"])
+	assert.Equal(t, "cc-1234", req.Metadata["cost-centre"])
+}
+
+func TestCRDToRequest_StatusID(t *testing.T) {
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "renamed"
+	crd.UID = "2098284b-1daf-11e8-b83f-028cde27f28a"
+	crd.Spec.InstanceClass = "db.t2.small"
+	crd.Status.ID = "test-2098284b-1daf-11e8-b83f-028cde27f28a"
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, database.DatabaseID(crd.Status.ID), req.ID)
+}
+
+func TestCRDToRequest_SizeTier(t *testing.T) {
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("1500Mi")
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "db.m4.large", req.InstanceClass)
+	assert.Equal(t, "large", req.Metadata["size-tier"])
+	assert.Equal(t, int64(2), req.Storage)
+}
+
+func TestCRDToRequest_CatalogueDefaults(t *testing.T) {
+	c, _ := catalogue.Parse([]byte(`{"tiers":{"small":{"instanceClass":"db.t3.medium","defaultStorage":20,"maxConnections":100}}}`))
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "small"
+
+	optimus := NewOptimus(c, testConfig(), fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "db.t3.medium", req.InstanceClass)
+	assert.Equal(t, int64(20), req.Storage)
+	assert.Equal(t, "100", req.Metadata["max-connections"])
+}
+
+func TestCRDToRequest_BackupAndNetwork(t *testing.T) {
+	days := int64(14)
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.InstanceClass = "db.t2.small"
+	crd.Spec.Storage = resource.MustParse("5Gi")
+	crd.Spec.Backup = &v1beta1.BackupSpec{
+		RetentionDays:     &days,
+		Window:            "03:00-04:00",
+		MaintenanceWindow: "sun:05:00-sun:06:00",
+	}
+	crd.Spec.Network = &v1beta1.NetworkSpec{
+		SubnetGroup:      "private",
+		SecurityGroupIDs: []string{"sg-1"},
+	}
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, &days, req.BackupRetentionDays)
+	assert.Equal(t, "03:00-04:00", req.BackupWindow)
+	assert.Equal(t, "sun:05:00-sun:06:00", req.MaintenanceWindow)
+	assert.Equal(t, "private", req.SubnetGroup)
+	assert.Equal(t, []string{"sg-1"}, req.SecurityGroupIDs)
+}
+
+func TestCRDToRequest_ManagedNetwork(t *testing.T) {
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("5Gi")
+	crd.Spec.Network = &v1beta1.NetworkSpec{
+		AdditionalSecurityGroupIDs: []string{"sg-extra"},
+		PubliclyAccessible:         true,
+		ManagedSecurityGroup: &v1beta1.ManagedSecurityGroupSpec{
+			Ingress: []v1beta1.IngressRule{
+				{CIDR: "10.0.0.0/16"},
+				{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}},
+			},
+		},
+	}
+
+	cfg := testConfig()
+	optimus := NewOptimus(catalogue.Default(), cfg, fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, []string{"sg-1", "sg-extra"}, req.SecurityGroupIDs)
+	assert.True(t, req.PubliclyAccessible)
+	assert.Equal(t, &database.SecurityGroupRequest{Ingress: []database.IngressRule{
+		{CIDR: "10.0.0.0/16"},
+		{NamespaceSelector: "team=payments"},
+	}}, req.ManagedSecurityGroup)
+
+	// the config is not changed by the additional security groups
+	assert.Equal(t, []string{"sg-1"}, cfg.Config.AWS.SecurityGroupIDs)
+}
+
+func TestCRDToRequest_ConfigDefaults(t *testing.T) {
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("5Gi")
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "9.6.5", req.EngineVersion)
+	assert.Equal(t, "gp2", req.StorageType)
+	assert.Equal(t, int64(35), *req.BackupRetentionDays)
+	assert.Equal(t, "13:30-14:30", req.BackupWindow)
+	assert.Equal(t, "subnet", req.SubnetGroup)
+	assert.Equal(t, []string{"sg-1"}, req.SecurityGroupIDs)
+}
+
+func TestCRDToRequest_SpecOverridesConfig(t *testing.T) {
+	days := int64(7)
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("5Gi")
+	crd.Spec.StorageType = "io1"
+	crd.Spec.Backup = &v1beta1.BackupSpec{RetentionDays: &days}
+	crd.Spec.Network = &v1beta1.NetworkSpec{SubnetGroup: "other"}
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "io1", req.StorageType)
+	assert.Equal(t, int64(7), *req.BackupRetentionDays)
+	assert.Equal(t, "13:30-14:30", req.BackupWindow)
+	assert.Equal(t, "other", req.SubnetGroup)
+	assert.Equal(t, []string{"sg-1"}, req.SecurityGroupIDs)
+}
+
+func TestCRDToRequest_Location(t *testing.T) {
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("5Gi")
+	crd.Spec.Region = "ap-southeast-1"
+	crd.Spec.AWSAccount = "dr"
+	crd.Spec.CredentialStores = []string{"vault"}
+
+	cfg := testConfig()
+	cfg.Config.AWS.Accounts = []config.Account{{Name: "dr", RoleARN: "arn:aws:iam::123456789012:role/db-operator"}}
+	cfg.Config.AWS.Targets = []config.Target{{Region: "ap-southeast-1", Account: "dr", SubnetGroup: "dr-subnets", SecurityGroupIDs: []string{"sg-9"}}}
+	optimus := NewOptimus(catalogue.Default(), cfg, fixedClass{})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, database.Location{Region: "ap-southeast-1", Account: "dr"}, req.Location)
+	assert.Equal(t, "dr-subnets", req.SubnetGroup)
+	assert.Equal(t, []string{"sg-9"}, req.SecurityGroupIDs)
+	assert.Equal(t, []string{"vault"}, req.CredentialStores)
+}
+
+func testConfig() config.Fixed {
+	c := config.Default()
+	c.AWS.SubnetGroup = "subnet"
+	c.AWS.SecurityGroupIDs = []string{"sg-1"}
+	return config.Fixed{Config: c}
+}
+
+func TestCRDToRequest_Class(t *testing.T) {
+	days := int64(14)
+	storage := resource.MustParse("100Gi")
+	class := &v1beta1.PostgresDBClass{}
+	class.Name = "production"
+	class.Spec = v1beta1.PostgresDBClassSpec{
+		SubnetGroup:    "prod-subnets",
+		ParameterGroup: "prod-params",
+		EngineVersion:  "10.4",
+		StorageType:    "standard",
+		Storage:        &storage,
+		HA:             true,
+		Backup:         &v1beta1.BackupSpec{RetentionDays: &days, Window: "01:00-02:00"},
+	}
+
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Backup = &v1beta1.BackupSpec{Window: "03:00-04:00"}
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{class: class})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "prod-subnets", req.SubnetGroup)
+	assert.Equal(t, []string{"sg-1"}, req.SecurityGroupIDs)
+	assert.Equal(t, "prod-params", req.ParameterGroup)
+	assert.Equal(t, "10.4", req.EngineVersion)
+	assert.Equal(t, "standard", req.StorageType)
+	assert.Equal(t, int64(100), req.Storage)
+	assert.True(t, req.HA)
+	assert.Equal(t, int64(14), *req.BackupRetentionDays)
+	assert.Equal(t, "03:00-04:00", req.BackupWindow)
+	assert.Equal(t, "production", req.Metadata["postgresdb-class"])
+
+	// the class is not modified by the merge
+	assert.Equal(t, "01:00-02:00", class.Spec.Backup.Window)
+	assert.Nil(t, crd.Spec.Network)
+}
+
+func TestCRDToRequest_ClassInOtherRegion(t *testing.T) {
+	class := &v1beta1.PostgresDBClass{}
+	class.Name = "production"
+	class.Spec = v1beta1.PostgresDBClassSpec{
+		SubnetGroup:      "prod-subnets",
+		SecurityGroupIDs: []string{"sg-prod"},
+		ParameterGroup:   "prod-params",
+	}
+	cfg := testConfig()
+	cfg.Config.AWS.Targets = []config.Target{{Region: "ap-southeast-1", SubnetGroup: "sg1-subnets", SecurityGroupIDs: []string{"sg-9"}}}
+
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+	crd.Spec.Region = "ap-southeast-1"
+
+	optimus := NewOptimus(catalogue.Default(), cfg, fixedClass{class: class})
+	req := optimus.CRDToRequest(crd)
+
+	assert.Equal(t, "sg1-subnets", req.SubnetGroup)
+	assert.Equal(t, []string{"sg-9"}, req.SecurityGroupIDs)
+	assert.Equal(t, "prod-params", req.ParameterGroup)
+}
+
+func TestCRDToRequest_Encryption(t *testing.T) {
+	class := &v1beta1.PostgresDBClass{}
+	class.Name = "production"
+	class.Spec.Encryption = &v1beta1.EncryptionSpec{KMSKeyID: "alias/production"}
+
+	crd := &v1beta1.PostgresDB{}
+	crd.Name = "test"
+	crd.Spec.Size = "large"
+
+	optimus := NewOptimus(catalogue.Default(), testConfig(), fixedClass{class: class})
+	assert.Equal(t, "alias/production", optimus.CRDToRequest(crd).KMSKeyID)
+
+	crd.Spec.Encryption = &v1beta1.EncryptionSpec{KMSKeyID: "alias/orders"}
+	assert.Equal(t, "alias/orders", optimus.CRDToRequest(crd).KMSKeyID)
+
+	optimus = NewOptimus(catalogue.Default(), testConfig(), fixedClass{})
+	crd.Spec.Encryption = nil
+	assert.Equal(t, "", optimus.CRDToRequest(crd).KMSKeyID)
+}
+
+// fixedClass resolves every class name to the same class
+type fixedClass struct {
+	class *v1beta1.PostgresDBClass
+	err   error
+}
+
+func (f fixedClass) Resolve(name string) (*v1beta1.PostgresDBClass, error) {
+	return f.class, f.err
+}
@@ -4,40 +4,176 @@ package worker
 
 import (
 	"fmt"
+	"net"
+	"strings"
 
-	"strconv"
-
-	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/database"
 	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/rds"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+var oneGiB = resource.MustParse("1Gi")
+
 type PostgresDBValidator interface {
-	Validate(crd *v1alpha1.PostgresDB) error
+	Validate(crd *v1beta1.PostgresDB) error
+}
+
+// QuotaChecker rejects postgresdbs that would take their namespace over quota
+type QuotaChecker interface {
+	Check(crd *v1beta1.PostgresDB) error
 }
 
-type postgresDBvalidator struct{}
+type postgresDBvalidator struct {
+	sizes   SizeResolver
+	config  NamespaceConfig
+	classes ClassResolver
+	quotas  QuotaChecker
+}
 
-func NewPostgresDBValidator() *postgresDBvalidator {
-	return &postgresDBvalidator{}
+func NewPostgresDBValidator(s SizeResolver, cfg NamespaceConfig, c ClassResolver, q QuotaChecker) *postgresDBvalidator {
+	return &postgresDBvalidator{sizes: s, config: cfg, classes: c, quotas: q}
 }
 
-func (v *postgresDBvalidator) Validate(crd *v1alpha1.PostgresDB) error {
-	if crd.Spec.Storage == "" {
-		return fmt.Errorf("storage cannot be empty")
+func (v *postgresDBvalidator) Validate(crd *v1beta1.PostgresDB) error {
+
+	if crd.Spec.Size == "" && crd.Spec.InstanceClass == "" {
+		return fmt.Errorf("size cannot be empty")
+	}
+
+	if crd.Spec.Size != "" && crd.Spec.InstanceClass != "" {
+		return fmt.Errorf("only one of size and instanceClass can be set")
 	}
 
-	_, err := strconv.ParseInt(crd.Spec.Storage, 10, 64)
+	class, err := v.classes.Resolve(crd.Spec.ClassName)
 	if err != nil {
 		return err
 	}
+	spec := mergeClass(&crd.Spec, class)
 
-	if crd.Spec.Size == "" {
-		return fmt.Errorf("size cannot be empty")
+	if class != nil && len(class.Spec.AllowedSizes) > 0 && !containsString(class.Spec.AllowedSizes, sizeOf(spec)) {
+		return fmt.Errorf("size %s is not allowed by postgresdb class %s", sizeOf(spec), class.Name)
+	}
+
+	sel, err := v.sizes.Resolve(sizeOf(spec), crd.Namespace)
+	if err != nil {
+		return err
+	}
+
+	if spec.Storage.Sign() < 0 {
+		return fmt.Errorf("storage cannot be negative")
+	}
+
+	if spec.Storage.Sign() == 0 && sel.DefaultStorage == 0 {
+		return fmt.Errorf("storage cannot be empty")
+	}
+
+	// storage is rounded up to whole GiB, a quantity without a unit is bytes
+	if spec.Storage.Sign() > 0 && spec.Storage.Cmp(oneGiB) < 0 {
+		return fmt.Errorf("storage %s is less than 1Gi, quantities without a unit are bytes, eg. use 20Gi", spec.Storage.String())
+	}
+
+	switch spec.StorageType {
+	case "", "gp2", "standard":
+		if spec.Iops != 0 {
+			return fmt.Errorf("iops can only be set with storage type io1")
+		}
+	case "io1":
+		if spec.Iops == 0 {
+			return fmt.Errorf("iops must be set with storage type io1")
+		}
+	default:
+		return fmt.Errorf("unsupported storage type: %s", spec.StorageType)
 	}
 
-	if _, err = rds.GetSizeForInstanceClass(crd.Spec.Size); err != nil {
-		return fmt.Errorf("unsupported database size")
+	// databases that already exist keep the storage they were created with
+	if crd.Status.ID == "" {
+		storageType := spec.StorageType
+		if storageType == "" {
+			storageType = v.config.ForNamespace(crd.Namespace).Defaults.StorageType
+		}
+		storage := spec.StorageGiB()
+		if storage == 0 {
+			storage = sel.DefaultStorage
+		}
+		if min := rds.MinStorage(storageType); storage < min {
+			return fmt.Errorf("storage must be at least %dGi with storage type %s, it is %dGi", min, storageType, storage)
+		}
+	}
+
+	if b := spec.Backup; b != nil && b.RetentionDays != nil {
+		if *b.RetentionDays < 0 || *b.RetentionDays > 35 {
+			return fmt.Errorf("backup retention must be between 0 and 35 days")
+		}
+	}
+
+	if err := validateNetwork(spec.Network); err != nil {
+		return err
+	}
+
+	if len(spec.Tags) > rds.MaxSpecTags {
+		return fmt.Errorf("at most %d tags can be set, %d are set", rds.MaxSpecTags, len(spec.Tags))
+	}
+	if err := rds.ValidateTags(spec.Tags); err != nil {
+		return err
+	}
+
+	for i, s := range spec.CredentialStores {
+		if !containsString(database.GetCredentialStores(), s) {
+			return fmt.Errorf("unsupported credential store: %s", s)
+		}
+		if containsString(spec.CredentialStores[:i], s) {
+			return fmt.Errorf("credential store %s is listed twice", s)
+		}
+	}
+
+	// databases that already exist are not taken away when a quota shrinks
+	if crd.Status.ID == "" {
+		if err := v.quotas.Check(crd); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+func validateNetwork(n *v1beta1.NetworkSpec) error {
+	if n == nil {
+		return nil
+	}
+	for _, id := range append(append([]string(nil), n.SecurityGroupIDs...), n.AdditionalSecurityGroupIDs...) {
+		if !strings.HasPrefix(id, "sg-") {
+			return fmt.Errorf("invalid security group %q", id)
+		}
+	}
+	if n.ManagedSecurityGroup == nil {
+		return nil
+	}
+	for i, r := range n.ManagedSecurityGroup.Ingress {
+		if (r.CIDR == "") == (r.NamespaceSelector == nil) {
+			return fmt.Errorf("ingress rule %d must set one of cidr and namespaceSelector", i)
+		}
+		if r.CIDR != "" {
+			ip, _, err := net.ParseCIDR(r.CIDR)
+			if err != nil || ip.To4() == nil {
+				return fmt.Errorf("ingress rule %d has invalid ipv4 cidr %q", i, r.CIDR)
+			}
+		}
+		if r.NamespaceSelector != nil {
+			if _, err := metav1.LabelSelectorAsSelector(r.NamespaceSelector); err != nil {
+				return fmt.Errorf("ingress rule %d has invalid namespaceSelector: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
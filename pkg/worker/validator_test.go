@@ -1,12 +1,18 @@
 package worker
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 
-	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1alpha1"
+	crds "github.com/MYOB-Technology/ops-kube-db-operator/pkg/apis/postgresdb/v1beta1"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/catalogue"
+	"github.com/MYOB-Technology/ops-kube-db-operator/pkg/config"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestValidate_StorageEmpty(t *testing.T) {
@@ -16,27 +22,27 @@ func TestValidate_StorageEmpty(t *testing.T) {
 	crd := crds.PostgresDB{}
 	crd.ObjectMeta.Name = "crdname"
 	crd.ObjectMeta.Namespace = "test-namespace"
-	crd.Spec.Size = "db.m4.large"
-	crd.Spec.Storage = ""
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.Quantity{}
 
-	i := NewPostgresDBValidator()
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
 	err := i.Validate(&crd)
 
 	assert.NotNil(t, err)
 
 }
 
-func TestValidate_StorageInvalid(t *testing.T) {
+func TestValidate_StorageNegative(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	crd := crds.PostgresDB{}
 	crd.ObjectMeta.Name = "crdname"
 	crd.ObjectMeta.Namespace = "test-namespace"
-	crd.Spec.Size = "db.m4.large"
-	crd.Spec.Storage = "banana"
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("-10Gi")
 
-	i := NewPostgresDBValidator()
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
 	err := i.Validate(&crd)
 
 	assert.NotNil(t, err)
@@ -51,9 +57,9 @@ func TestValidate_SizeEmpty(t *testing.T) {
 	crd.ObjectMeta.Name = "crdname"
 	crd.ObjectMeta.Namespace = "test-namespace"
 	crd.Spec.Size = ""
-	crd.Spec.Storage = "10"
+	crd.Spec.Storage = resource.MustParse("20Gi")
 
-	i := NewPostgresDBValidator()
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
 	err := i.Validate(&crd)
 
 	assert.NotNil(t, err)
@@ -67,12 +73,299 @@ func TestValidate_SizeInvalid(t *testing.T) {
 	crd := crds.PostgresDB{}
 	crd.ObjectMeta.Name = "crdname"
 	crd.ObjectMeta.Namespace = "test-namespace"
-	crd.Spec.Size = "nonexistent"
-	crd.Spec.Storage = "10"
+	crd.Spec.InstanceClass = "nonexistent"
+	crd.Spec.Storage = resource.MustParse("20Gi")
 
-	i := NewPostgresDBValidator()
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
 	err := i.Validate(&crd)
 
 	assert.NotNil(t, err)
 
 }
+
+func TestValidate_StorageTypeInvalid(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+	crd.Spec.StorageType = "banana"
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_IopsWithoutIo1(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("100Gi")
+	crd.Spec.StorageType = "gp2"
+	crd.Spec.Iops = 1000
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_Io1WithoutIops(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("100Gi")
+	crd.Spec.StorageType = "io1"
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_Io1WithIops(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("100Gi")
+	crd.Spec.StorageType = "io1"
+	crd.Spec.Iops = 1000
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.Nil(t, err)
+}
+
+func TestValidate_StorageWithoutUnit(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("100")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.EqualError(t, err, "storage 100 is less than 1Gi, quantities without a unit are bytes, eg. use 20Gi")
+
+	crd.Spec.Storage = resource.MustParse("500Mi")
+	err = i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_StorageMinimum(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("10Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.EqualError(t, err, "storage must be at least 20Gi with storage type gp2, it is 10Gi")
+
+	crd.Spec.StorageType = "standard"
+	assert.Nil(t, i.Validate(&crd))
+
+	crd.Spec.StorageType = "io1"
+	crd.Spec.Iops = 1000
+	crd.Spec.Storage = resource.MustParse("50Gi")
+	err = i.Validate(&crd)
+	assert.EqualError(t, err, "storage must be at least 100Gi with storage type io1, it is 50Gi")
+
+	// databases created before the minimum keep their storage
+	crd.Status.ID = "crdname-1234"
+	assert.Nil(t, i.Validate(&crd))
+}
+
+func TestValidate_StorageMinimumOfNamespaceDefault(t *testing.T) {
+	cfg := config.Default()
+	cfg.Defaults.StorageType = "standard"
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("10Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), config.Fixed{Config: cfg}, fixedClass{}, noQuota{})
+	assert.Nil(t, i.Validate(&crd))
+}
+
+func TestValidate_SizeTier(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "medium"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.Nil(t, err)
+}
+
+func TestValidate_SizeAndInstanceClass(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "medium"
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_BackupRetentionInvalid(t *testing.T) {
+	days := int64(36)
+	crd := crds.PostgresDB{}
+	crd.Spec.InstanceClass = "db.m4.large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+	crd.Spec.Backup = &crds.BackupSpec{RetentionDays: &days}
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_StorageFromCatalogue(t *testing.T) {
+	c, _ := catalogue.Parse([]byte(`{"tiers":{"small":{"instanceClass":"db.t3.medium","defaultStorage":20}}}`))
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "small"
+
+	i := NewPostgresDBValidator(c, testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.Nil(t, err)
+}
+
+func TestValidate_SizeNotAllowedInNamespace(t *testing.T) {
+	c, _ := catalogue.Parse([]byte(`{"tiers":{"huge":{"instanceClass":"db.r5.4xlarge","namespaces":["payments"]}}}`))
+	crd := crds.PostgresDB{}
+	crd.Namespace = "test-namespace"
+	crd.Spec.Size = "huge"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+
+	i := NewPostgresDBValidator(c, testConfig(), fixedClass{}, noQuota{})
+	err := i.Validate(&crd)
+	assert.NotNil(t, err)
+}
+
+func TestValidate_ClassNotFound(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+	crd.Spec.ClassName = "missing"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{err: fmt.Errorf("postgresdb class missing does not exist")}, noQuota{})
+	err := i.Validate(&crd)
+
+	assert.NotNil(t, err)
+}
+
+func TestValidate_ClassAllowedSizes(t *testing.T) {
+	class := &crds.PostgresDBClass{}
+	class.Name = "small-only"
+	class.Spec.AllowedSizes = []string{"small"}
+
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{class: class}, noQuota{})
+	assert.NotNil(t, i.Validate(&crd))
+
+	crd.Spec.Size = "small"
+	assert.Nil(t, i.Validate(&crd))
+}
+
+func TestValidate_ClassStorage(t *testing.T) {
+	storage := resource.MustParse("50Gi")
+	class := &crds.PostgresDBClass{}
+	class.Spec.Storage = &storage
+
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{class: class}, noQuota{})
+	assert.Nil(t, i.Validate(&crd))
+}
+
+func TestValidate_Quota(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{err: fmt.Errorf("postgresdb quota team exceeded")})
+	assert.NotNil(t, i.Validate(&crd))
+
+	// existing databases are not checked against the quota
+	crd.Status.ID = "crdname-uid"
+	assert.Nil(t, i.Validate(&crd))
+}
+
+func TestValidate_Network(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+	crd.Spec.Network = &crds.NetworkSpec{
+		AdditionalSecurityGroupIDs: []string{"sg-123"},
+		ManagedSecurityGroup: &crds.ManagedSecurityGroupSpec{
+			Ingress: []crds.IngressRule{
+				{CIDR: "10.0.0.0/16"},
+				{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "payments"}}},
+			},
+		},
+	}
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	assert.Nil(t, i.Validate(&crd))
+
+	invalid := []crds.NetworkSpec{
+		{AdditionalSecurityGroupIDs: []string{"default"}},
+		{ManagedSecurityGroup: &crds.ManagedSecurityGroupSpec{Ingress: []crds.IngressRule{{}}}},
+		{ManagedSecurityGroup: &crds.ManagedSecurityGroupSpec{Ingress: []crds.IngressRule{{CIDR: "10.0.0.0"}}}},
+		{ManagedSecurityGroup: &crds.ManagedSecurityGroupSpec{Ingress: []crds.IngressRule{{CIDR: "fd00::/8"}}}},
+		{ManagedSecurityGroup: &crds.ManagedSecurityGroupSpec{Ingress: []crds.IngressRule{{
+			CIDR:              "10.0.0.0/16",
+			NamespaceSelector: &metav1.LabelSelector{},
+		}}}},
+		{ManagedSecurityGroup: &crds.ManagedSecurityGroupSpec{Ingress: []crds.IngressRule{{
+			NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}}},
+		}}}},
+	}
+	for _, n := range invalid {
+		n := n
+		crd.Spec.Network = &n
+		assert.NotNil(t, i.Validate(&crd))
+	}
+}
+
+func TestValidate_CredentialStores(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+	crd.Spec.CredentialStores = []string{"secretsmanager", "vault"}
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	assert.Nil(t, i.Validate(&crd))
+
+	crd.Spec.CredentialStores = []string{"vault", "vault"}
+	assert.NotNil(t, i.Validate(&crd))
+
+	crd.Spec.CredentialStores = []string{"parameterstore"}
+	assert.NotNil(t, i.Validate(&crd))
+}
+
+func TestValidate_Tags(t *testing.T) {
+	crd := crds.PostgresDB{}
+	crd.Spec.Size = "large"
+	crd.Spec.Storage = resource.MustParse("20Gi")
+	crd.Spec.Tags = map[string]string{"team": "payments", "cost:project": "ledger-2018/q1"}
+
+	i := NewPostgresDBValidator(catalogue.Default(), testConfig(), fixedClass{}, noQuota{})
+	assert.Nil(t, i.Validate(&crd))
+
+	crd.Spec.Tags = map[string]string{"aws:createdBy": "me"}
+	assert.EqualError(t, i.Validate(&crd), `tag key "aws:createdBy" cannot start with aws:`)
+
+	crd.Spec.Tags = map[string]string{"team": "payments, ledger"}
+	assert.EqualError(t, i.Validate(&crd), "value of tag team has characters other than letters, digits, spaces and _.:/=+-@")
+
+	crd.Spec.Tags = map[string]string{strings.Repeat("k", 129): "v"}
+	assert.NotNil(t, i.Validate(&crd))
+
+	crd.Spec.Tags = map[string]string{}
+	for n := 0; n < 40; n++ {
+		crd.Spec.Tags[fmt.Sprintf("tag-%d", n)] = "v"
+	}
+	assert.EqualError(t, i.Validate(&crd), "at most 39 tags can be set, 40 are set")
+}
+
+type noQuota struct {
+	err error
+}
+
+func (q noQuota) Check(crd *crds.PostgresDB) error {
+	return q.err
+}